@@ -1,16 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/config"
+	"gogin/internal/metrics"
 	"gogin/internal/middleware"
 	"gogin/internal/modules/apiclient"
+	"gogin/internal/modules/auditlogs"
 	"gogin/internal/modules/core"
 	"gogin/internal/modules/notifications"
 	"gogin/internal/modules/oauth2"
@@ -20,6 +26,7 @@ import (
 	"gogin/internal/modules/tickets"
 	"gogin/internal/modules/users"
 	"gogin/internal/response"
+	"gogin/internal/utils"
 	"gogin/internal/workers"
 
 	"github.com/gin-gonic/gin"
@@ -63,6 +70,9 @@ import (
 // @tag.name API Clients
 // @tag.description OAuth client management (admin only)
 
+// @tag.name Audit Logs
+// @tag.description Audit log review (admin only)
+
 // @tag.name Notifications
 // @tag.description User notifications and messaging
 
@@ -91,7 +101,9 @@ func main() {
 	}
 
 	// Initialize database
-	db, err := clients.NewDatabase(cfg.Database)
+	db, err := clients.ConnectWithRetry("Database", cfg.App.StartupMaxAttempts, cfg.App.StartupRetryBaseDelay, func() (*clients.Database, error) {
+		return clients.NewDatabase(cfg.Database)
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -99,7 +111,9 @@ func main() {
 	log.Println("✓ Database connected")
 
 	// Initialize Redis
-	redis, err := clients.NewRedisClient(cfg.Redis)
+	redis, err := clients.ConnectWithRetry("Redis", cfg.App.StartupMaxAttempts, cfg.App.StartupRetryBaseDelay, func() (*clients.RedisClient, error) {
+		return clients.NewRedisClient(cfg.Redis)
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
@@ -107,7 +121,9 @@ func main() {
 	log.Println("✓ Redis connected")
 
 	// Initialize NATS
-	nats, err := clients.NewNATSClient(cfg.NATS)
+	nats, err := clients.ConnectWithRetry("NATS", cfg.App.StartupMaxAttempts, cfg.App.StartupRetryBaseDelay, func() (*clients.NATSClient, error) {
+		return clients.NewNATSClient(cfg.NATS)
+	})
 	if err != nil {
 		log.Fatalf("Failed to connect to NATS: %v", err)
 	}
@@ -115,9 +131,9 @@ func main() {
 	log.Println("✓ NATS connected")
 
 	// Start background workers
-	workerManager := workers.NewWorkerManager(db, nats, cfg)
+	workerManager := workers.NewWorkerManager(db, redis, nats, cfg)
 	if err := workerManager.Start(); err != nil {
-		log.Printf("Warning: Failed to start workers: %v", err)
+		log.Printf("Warning: Some workers failed to start: %v", err)
 	}
 	defer workerManager.Stop()
 
@@ -127,19 +143,20 @@ func main() {
 	// Apply global middleware
 	router.Use(middleware.Recovery())
 	router.Use(middleware.RequestID())
-	router.Use(middleware.Logger())
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics())
+	}
+	router.Use(middleware.BodyLimit(cfg.App.MaxBodySize))
+	router.Use(middleware.Logger(cfg.App.LogLevel))
 	router.Use(middleware.ErrorHandler())
-	router.Use(middleware.CORS(cfg.App.AllowOrigins))
+	router.Use(middleware.CORS(cfg.CORS))
 
 	// Add audit logging middleware
-	auditLogger := middleware.NewAuditLogger(db)
+	auditLogger := middleware.NewAuditLogger(db).WithSkipRoutes(cfg.Audit.SkipRoutes)
 	router.Use(auditLogger.Log())
 
-	// Set version in context
-	router.Use(func(c *gin.Context) {
-		c.Set("version", cfg.App.Version)
-		c.Next()
-	})
+	// Resolve API version from the Accept-Version header, defaulting to the configured version
+	router.Use(middleware.APIVersion(cfg.App.Version, cfg.App.SupportedVersions))
 
 	// Trust proxies
 	if len(cfg.App.TrustedProxies) > 0 {
@@ -155,15 +172,29 @@ func main() {
 		})
 	})
 
+	// Prometheus metrics
+	if cfg.Metrics.Enabled {
+		metrics.RegisterDBStats(db.Stats)
+		router.GET("/metrics", metrics.Handler(cfg.Metrics.Token))
+	}
+
 	// Swagger documentation
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// API v1 group
 	v1 := router.Group("/api/v1")
 
+	// Rate limiting must be attached before routes are registered to apply to them
+	// RATE_LIMIT_RPS and oauth_clients.rate_limit_rps are per-second limits
+	rateLimiter := middleware.NewRateLimiter(redis, cfg.App.RateLimitRPS, time.Second).
+		WithClientLimits(db).
+		WithTokenIdentity(utils.NewJWTUtilFromConfig(cfg.OAuth))
+	v1.Use(rateLimiter.Limit())
+
 	// Core routes (health, status)
-	coreModule := core.NewCoreModule(db, redis, nats, cfg)
+	coreModule := core.NewCoreModule(db, redis, nats, cfg).WithWorkers(workerManager)
 	coreModule.RegisterRoutes(v1)
+	coreModule.RegisterProbeRoutes(router)
 
 	// Users module (authentication)
 	usersModule := users.NewUsersModule(db, redis, cfg)
@@ -173,6 +204,7 @@ func main() {
 	// OAuth2 authorization server
 	oauth2Module := oauth2.NewOAuth2Module(db, redis, cfg)
 	oauth2Module.RegisterRoutes(v1)
+	oauth2Module.RegisterWellKnownRoutes(router)
 	log.Println("✓ OAuth2 module registered")
 
 	// API Client management (admin only)
@@ -196,7 +228,7 @@ func main() {
 	log.Println("✓ Settings module registered")
 
 	// Tickets module
-	ticketsModule := tickets.NewTicketsModule(db, redis, cfg)
+	ticketsModule := tickets.NewTicketsModule(db, redis, nats, notificationsModule.Service(), cfg)
 	ticketsModule.RegisterRoutes(v1)
 	log.Println("✓ Tickets module registered")
 
@@ -205,9 +237,10 @@ func main() {
 	storageModule.RegisterRoutes(v1)
 	log.Println("✓ Storage module registered")
 
-	// Apply rate limiting after authentication routes
-	rateLimiter := middleware.NewRateLimiter(redis, cfg.App.RateLimitRPS, 60)
-	v1.Use(rateLimiter.Limit())
+	// Audit logs module (admin only)
+	auditLogsModule := auditlogs.NewAuditLogsModule(db, redis, cfg)
+	auditLogsModule.RegisterRoutes(v1)
+	log.Println("✓ Audit Logs module registered")
 
 	// Handle 404
 	router.NoRoute(middleware.NotFoundHandler())
@@ -221,9 +254,15 @@ func main() {
 	log.Printf("   Environment: %s", cfg.App.Env)
 	log.Printf("   Version: %s", cfg.App.Version)
 
-	// Graceful shutdown
+	server := &http.Server{
+		Addr:    serverAddr,
+		Handler: router,
+	}
+	// Long-lived event streams would otherwise hold the drain for the full timeout
+	server.RegisterOnShutdown(notificationsModule.CloseStreams)
+
 	go func() {
-		if err := router.Run(serverAddr); err != nil {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Server failed to start: %v", err)
 		}
 	}()
@@ -233,6 +272,16 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	// Graceful shutdown: stop accepting connections and let in-flight requests finish.
+	// Workers and client connections are closed by the deferred calls above once this returns.
 	log.Println("Shutting down server...")
-	log.Println("Server stopped")
+	drainStart := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Server forced to shut down after %s: %v", time.Since(drainStart), err)
+		return
+	}
+	log.Printf("Server stopped, drained in %s", time.Since(drainStart))
 }
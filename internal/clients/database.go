@@ -1,6 +1,7 @@
 package clients
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -13,6 +14,38 @@ import (
 // Database wraps the sql.DB connection
 type Database struct {
 	*sql.DB
+	queryTimeout time.Duration
+}
+
+// Rows wraps sql.Rows so closing them also releases the query's timeout context
+type Rows struct {
+	*sql.Rows
+	cancel context.CancelFunc
+}
+
+// Close closes the rows and releases the query context
+func (r *Rows) Close() error {
+	defer r.cancel()
+	return r.Rows.Close()
+}
+
+// Row wraps sql.Row so scanning it also releases the query's timeout context
+type Row struct {
+	*sql.Row
+	cancel context.CancelFunc
+}
+
+// Scan copies the row into dest and releases the query context
+func (r *Row) Scan(dest ...any) error {
+	defer r.cancel()
+	return r.Row.Scan(dest...)
+}
+
+// Now returns the timestamp to store for "now": the current time in UTC, rounded to the
+// microsecond precision of Postgres timestamps. Pass it as a query argument rather than
+// using SQL NOW(), whose value in TIMESTAMP columns depends on the session's timezone.
+func Now() time.Time {
+	return time.Now().UTC().Round(time.Microsecond)
 }
 
 // NewDatabase creates a new database connection
@@ -34,22 +67,88 @@ func NewDatabase(cfg config.DatabaseConfig) (*Database, error) {
 
 	// Verify connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Database{db}, nil
+	return &Database{DB: db, queryTimeout: cfg.QueryTimeout}, nil
 }
 
-// HealthCheck performs a health check on the database
-func (d *Database) HealthCheck() error {
+// withQueryTimeout bounds ctx by the default query timeout unless it already has a deadline
+func (d *Database) withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || d.queryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d.queryTimeout)
+}
+
+// QueryRowContext runs a single-row query that is aborted when ctx is cancelled or the default query timeout elapses
+func (d *Database) QueryRowContext(ctx context.Context, query string, args ...any) *Row {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	return &Row{Row: d.DB.QueryRowContext(ctx, query, args...), cancel: cancel}
+}
+
+// QueryContext runs a query that is aborted when ctx is cancelled or the default query timeout elapses.
+// The returned rows must be closed to release the query context.
+func (d *Database) QueryContext(ctx context.Context, query string, args ...any) (*Rows, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	rows, err := d.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &Rows{Rows: rows, cancel: cancel}, nil
+}
+
+// ExecContext runs a statement that is aborted when ctx is cancelled or the default query timeout elapses
+func (d *Database) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	ctx, cancel := d.withQueryTimeout(ctx)
+	defer cancel()
+	return d.DB.ExecContext(ctx, query, args...)
+}
+
+// WithTransaction runs fn inside a transaction, committing if it returns nil and rolling back
+// if it returns an error or panics
+func (d *Database) WithTransaction(fn func(*sql.Tx) error) error {
+	return d.WithTransactionContext(context.Background(), fn)
+}
+
+// WithTransactionContext is WithTransaction bound to ctx; cancelling ctx rolls the transaction back
+func (d *Database) WithTransactionContext(ctx context.Context, fn func(*sql.Tx) error) (err error) {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// HealthCheck performs a health check on the database and returns the ping round-trip latency
+func (d *Database) HealthCheck() (time.Duration, error) {
 	ctx, cancel := createContext(5 * time.Second)
 	defer cancel()
 
+	start := time.Now()
 	if err := d.PingContext(ctx); err != nil {
-		return fmt.Errorf("database health check failed: %w", err)
+		return time.Since(start), fmt.Errorf("database health check failed: %w", err)
 	}
 
-	return nil
+	return time.Since(start), nil
 }
 
 // Close closes the database connection
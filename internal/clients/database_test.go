@@ -0,0 +1,112 @@
+package clients_test
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"gogin/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+func TestWithTransactionRollsBackOnProfileFailure(t *testing.T) {
+	db := testutil.Database(t)
+
+	insertUser := func(tx *sql.Tx, id string) error {
+		_, err := tx.Exec(`
+			INSERT INTO users (id, email, password_hash, first_name, last_name)
+			VALUES ($1, $2, 'x', 'Test', 'User')
+		`, id, id+"@example.com")
+		return err
+	}
+	userExists := func(id string) bool {
+		var exists bool
+		if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists); err != nil {
+			t.Fatalf("check user: %v", err)
+		}
+		return exists
+	}
+
+	tests := []struct {
+		name    string
+		profile func(tx *sql.Tx, userID string) error
+		want    bool
+	}{
+		{
+			name: "profile created",
+			profile: func(tx *sql.Tx, userID string) error {
+				_, err := tx.Exec(`INSERT INTO user_profiles (user_id) VALUES ($1)`, userID)
+				return err
+			},
+			want: true,
+		},
+		{
+			name: "profile insert fails",
+			profile: func(tx *sql.Tx, userID string) error {
+				// References a user that does not exist
+				_, err := tx.Exec(`INSERT INTO user_profiles (user_id) VALUES ($1)`, uuid.New().String())
+				return err
+			},
+			want: false,
+		},
+		{
+			name: "profile step returns an error",
+			profile: func(tx *sql.Tx, userID string) error {
+				return errors.New("profile failed")
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := uuid.New().String()
+			t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+
+			err := db.WithTransaction(func(tx *sql.Tx) error {
+				if err := insertUser(tx, userID); err != nil {
+					return err
+				}
+				return tt.profile(tx, userID)
+			})
+			if (err == nil) != tt.want {
+				t.Errorf("WithTransaction error = %v, want success %v", err, tt.want)
+			}
+			if got := userExists(userID); got != tt.want {
+				t.Errorf("user exists = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithTransactionRollsBackOnPanic(t *testing.T) {
+	db := testutil.Database(t)
+	userID := uuid.New().String()
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, userID) })
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("WithTransaction swallowed the panic")
+			}
+		}()
+		db.WithTransaction(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(`
+				INSERT INTO users (id, email, password_hash, first_name, last_name)
+				VALUES ($1, $2, 'x', 'Test', 'User')
+			`, userID, userID+"@example.com"); err != nil {
+				t.Fatalf("insert user: %v", err)
+			}
+			panic("profile creation panicked")
+		})
+	}()
+
+	var exists bool
+	if err := db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, userID).Scan(&exists); err != nil {
+		t.Fatalf("check user: %v", err)
+	}
+	if exists {
+		t.Error("user was committed despite the panic")
+	}
+}
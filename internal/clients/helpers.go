@@ -2,10 +2,35 @@ package clients
 
 import (
 	"context"
+	"log"
 	"time"
 )
 
+// maxStartupRetryDelay caps the backoff between startup connection attempts
+const maxStartupRetryDelay = 30 * time.Second
+
 // createContext creates a context with timeout
 func createContext(timeout time.Duration) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(context.Background(), timeout)
 }
+
+// ConnectWithRetry calls connect until it succeeds or maxAttempts is reached, waiting
+// baseDelay after the first failure and doubling the wait after each one. It lets the
+// app start alongside dependencies that are still coming up.
+func ConnectWithRetry[T any](name string, maxAttempts int, baseDelay time.Duration, connect func() (T, error)) (T, error) {
+	delay := baseDelay
+	for attempt := 1; ; attempt++ {
+		client, err := connect()
+		if err == nil || attempt >= maxAttempts {
+			return client, err
+		}
+
+		log.Printf("%s not ready (attempt %d/%d): %v; retrying in %s", name, attempt, maxAttempts, err, delay)
+		time.Sleep(delay)
+
+		delay *= 2
+		if delay > maxStartupRetryDelay {
+			delay = maxStartupRetryDelay
+		}
+	}
+}
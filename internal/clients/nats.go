@@ -1,7 +1,11 @@
 package clients
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"gogin/internal/config"
@@ -9,11 +13,31 @@ import (
 	"github.com/nats-io/nats.go"
 )
 
+const (
+	// defaultPublishTimeout bounds how long Publish waits for a JetStream ack
+	defaultPublishTimeout = 5 * time.Second
+
+	// maxPendingAsyncPublishes caps unacknowledged async publishes; further
+	// PublishAsync calls block until acks arrive, applying backpressure
+	maxPendingAsyncPublishes = 256
+)
+
+// DeadLetterSubject receives messages that workers gave up on, relative to the stream name
+const DeadLetterSubject = "dlq"
+
+// Headers attached to dead-lettered messages
+const (
+	DeadLetterOriginHeader    = "Dlq-Original-Subject"
+	DeadLetterReasonHeader    = "Dlq-Reason"
+	DeadLetterDeliveredHeader = "Dlq-Delivered"
+)
+
 // NATSClient wraps the NATS JetStream client
 type NATSClient struct {
 	conn   *nats.Conn
 	js     nats.JetStreamContext
 	stream string
+	cfg    config.NATSConfig
 }
 
 // NewNATSClient creates a new NATS JetStream client
@@ -23,6 +47,24 @@ func NewNATSClient(cfg config.NATSConfig) (*NATSClient, error) {
 		nats.Timeout(10 * time.Second),
 		nats.ReconnectWait(2 * time.Second),
 		nats.MaxReconnects(-1), // Infinite reconnects
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("Warning: NATS disconnected: %v", err)
+				return
+			}
+			log.Println("Warning: NATS disconnected")
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Printf("✓ NATS reconnected to %s", nc.ConnectedUrlRedacted())
+		}),
+		// A closed connection never reconnects; HealthCheck reports it from the connection status
+		nats.ClosedHandler(func(nc *nats.Conn) {
+			if err := nc.LastError(); err != nil {
+				log.Printf("Warning: NATS connection closed: %v", err)
+				return
+			}
+			log.Println("Warning: NATS connection closed")
+		}),
 	}
 
 	// Add token if provided
@@ -40,7 +82,7 @@ func NewNATSClient(cfg config.NATSConfig) (*NATSClient, error) {
 	}
 
 	// Create JetStream context
-	js, err := conn.JetStream()
+	js, err := conn.JetStream(nats.PublishAsyncMaxPending(maxPendingAsyncPublishes))
 	if err != nil {
 		conn.Close()
 		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
@@ -50,6 +92,7 @@ func NewNATSClient(cfg config.NATSConfig) (*NATSClient, error) {
 		conn:   conn,
 		js:     js,
 		stream: cfg.StreamName,
+		cfg:    cfg,
 	}
 
 	// Ensure the stream exists
@@ -61,44 +104,155 @@ func NewNATSClient(cfg config.NATSConfig) (*NATSClient, error) {
 	return client, nil
 }
 
-// ensureStream creates the stream if it doesn't exist
+// ensureStream creates the stream if it doesn't exist, or applies the configured retention to it
 func (n *NATSClient) ensureStream() error {
+	storage := nats.FileStorage
+	if n.cfg.StorageType == "memory" {
+		storage = nats.MemoryStorage
+	}
+
+	streamCfg := &nats.StreamConfig{
+		Name:     n.stream,
+		Subjects: n.cfg.Subjects,
+		Storage:  storage,
+		MaxAge:   n.cfg.MaxAge,
+		MaxBytes: limitOrUnlimited(n.cfg.MaxBytes),
+		MaxMsgs:  limitOrUnlimited(n.cfg.MaxMsgs),
+	}
+
 	// Check if stream exists
-	_, err := n.js.StreamInfo(n.stream)
-	if err == nil {
-		return nil // Stream already exists
+	if _, err := n.js.StreamInfo(n.stream); err == nil {
+		if _, err := n.js.UpdateStream(streamCfg); err != nil {
+			return fmt.Errorf("failed to update stream: %w", err)
+		}
+		return nil
 	}
 
 	// Create stream
-	_, err = n.js.AddStream(&nats.StreamConfig{
-		Name:     n.stream,
-		Subjects: []string{n.stream + ".*"},
-		Storage:  nats.FileStorage,
-		MaxAge:   7 * 24 * time.Hour, // Keep messages for 7 days
-	})
+	if _, err := n.js.AddStream(streamCfg); err != nil {
+		return fmt.Errorf("failed to create stream: %w", err)
+	}
 
+	return nil
+}
+
+// ensureConsumer creates the durable push consumer behind a subscription, or brings an
+// existing one up to the configured ack wait, max deliver and max ack pending. Passing
+// these as subscribe options instead makes the subscribe fail whenever the consumer was
+// created by an earlier deploy with other values. Handlers ack explicitly, and MaxDeliver
+// stops poison messages from redelivering forever. A zero maxAckPending keeps the
+// server default.
+func (n *NATSClient) ensureConsumer(fullSubject, queue, durableName string, maxAckPending int) error {
+	info, err := n.js.ConsumerInfo(n.stream, durableName)
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = n.js.AddConsumer(n.stream, &nats.ConsumerConfig{
+			Durable:        durableName,
+			DeliverSubject: nats.NewInbox(),
+			DeliverGroup:   queue,
+			DeliverPolicy:  nats.DeliverAllPolicy,
+			FilterSubject:  fullSubject,
+			AckPolicy:      nats.AckExplicitPolicy,
+			AckWait:        n.cfg.AckWait,
+			MaxDeliver:     n.cfg.MaxDeliver,
+			MaxAckPending:  maxAckPending,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create consumer %s: %w", durableName, err)
+		}
+		return nil
+	}
 	if err != nil {
-		return fmt.Errorf("failed to create stream: %w", err)
+		return fmt.Errorf("failed to get consumer %s: %w", durableName, err)
 	}
 
+	cfg := info.Config
+	if cfg.AckWait == n.cfg.AckWait && cfg.MaxDeliver == n.cfg.MaxDeliver && (maxAckPending == 0 || cfg.MaxAckPending == maxAckPending) {
+		return nil
+	}
+	cfg.AckWait = n.cfg.AckWait
+	cfg.MaxDeliver = n.cfg.MaxDeliver
+	if maxAckPending > 0 {
+		cfg.MaxAckPending = maxAckPending
+	}
+	if _, err := n.js.UpdateConsumer(n.stream, &cfg); err != nil {
+		return fmt.Errorf("failed to update consumer %s: %w", durableName, err)
+	}
+	log.Printf("✓ Updated NATS consumer %s delivery settings", durableName)
 	return nil
 }
 
-// Publish publishes a message to a subject
+// bindOpts binds a subscription to a consumer set up by ensureConsumer
+func (n *NATSClient) bindOpts(durableName string) []nats.SubOpt {
+	return []nats.SubOpt{
+		nats.Bind(n.stream, durableName),
+		nats.ManualAck(),
+	}
+}
+
+// Publish publishes a message to a subject and waits for JetStream to persist it
 func (n *NATSClient) Publish(subject string, data []byte) error {
+	_, err := n.PublishWithAck(subject, data, defaultPublishTimeout)
+	return err
+}
+
+// PublishWithAck publishes a message and returns the JetStream ack, failing if
+// the stream does not confirm the write within timeout
+func (n *NATSClient) PublishWithAck(subject string, data []byte, timeout time.Duration) (*nats.PubAck, error) {
 	fullSubject := n.stream + "." + subject
-	_, err := n.js.Publish(fullSubject, data)
+	ack, err := n.js.Publish(fullSubject, data, nats.AckWait(timeout))
 	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+		return nil, fmt.Errorf("failed to publish message: %w", err)
+	}
+	return ack, nil
+}
+
+// PublishAsync publishes without waiting for the ack. Callers must check the
+// returned future; when too many acks are outstanding this blocks for up to
+// the stall wait and then returns an error instead of buffering without bound.
+func (n *NATSClient) PublishAsync(subject string, data []byte) (nats.PubAckFuture, error) {
+	fullSubject := n.stream + "." + subject
+	future, err := n.js.PublishAsync(fullSubject, data, nats.StallWait(defaultPublishTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to publish message: %w", err)
+	}
+	return future, nil
+}
+
+// PublishDeadLetter copies a message verbatim to the dead-letter subject, recording why it was
+// abandoned. The caller is still responsible for terminating the original message.
+func (n *NATSClient) PublishDeadLetter(msg *nats.Msg, reason string) error {
+	dlq := nats.NewMsg(n.stream + "." + DeadLetterSubject)
+	dlq.Data = msg.Data
+	dlq.Header.Set(DeadLetterOriginHeader, msg.Subject)
+	dlq.Header.Set(DeadLetterReasonHeader, reason)
+	if meta, err := msg.Metadata(); err == nil {
+		dlq.Header.Set(DeadLetterDeliveredHeader, strconv.FormatUint(meta.NumDelivered, 10))
+	}
+
+	if _, err := n.js.PublishMsg(dlq, nats.AckWait(defaultPublishTimeout)); err != nil {
+		return fmt.Errorf("failed to publish dead letter: %w", err)
 	}
 	return nil
 }
 
+// DeadLetterCount returns the number of messages currently held on the dead-letter subject
+func (n *NATSClient) DeadLetterCount() (uint64, error) {
+	subject := n.stream + "." + DeadLetterSubject
+	info, err := n.js.StreamInfo(n.stream, &nats.StreamInfoRequest{SubjectsFilter: subject})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stream info: %w", err)
+	}
+	return info.State.Subjects[subject], nil
+}
+
 // Subscribe creates a durable subscription to a subject
 func (n *NATSClient) Subscribe(subject, durableName string, handler nats.MsgHandler) (*nats.Subscription, error) {
 	fullSubject := n.stream + "." + subject
+	if err := n.ensureConsumer(fullSubject, "", durableName, 0); err != nil {
+		return nil, err
+	}
 
-	sub, err := n.js.Subscribe(fullSubject, handler, nats.Durable(durableName))
+	sub, err := n.js.Subscribe(fullSubject, handler, n.bindOpts(durableName)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to subscribe: %w", err)
 	}
@@ -109,8 +263,40 @@ func (n *NATSClient) Subscribe(subject, durableName string, handler nats.MsgHand
 // QueueSubscribe creates a queue subscription for load balancing
 func (n *NATSClient) QueueSubscribe(subject, queue, durableName string, handler nats.MsgHandler) (*nats.Subscription, error) {
 	fullSubject := n.stream + "." + subject
+	if err := n.ensureConsumer(fullSubject, queue, durableName, 0); err != nil {
+		return nil, err
+	}
+
+	sub, err := n.js.QueueSubscribe(fullSubject, queue, handler, n.bindOpts(durableName)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue subscribe: %w", err)
+	}
+
+	return sub, nil
+}
+
+// QueueSubscribeConcurrent creates a queue subscription whose handler runs for up to
+// concurrency messages at once. The consumer's max ack pending is set to match, so
+// the server holds further messages back rather than letting them wait in the client
+// while their ack deadline runs. Messages are not handled in delivery order.
+func (n *NATSClient) QueueSubscribeConcurrent(subject, queue, durableName string, concurrency int, handler nats.MsgHandler) (*nats.Subscription, error) {
+	fullSubject := n.stream + "." + subject
+	if err := n.ensureConsumer(fullSubject, queue, durableName, concurrency); err != nil {
+		return nil, err
+	}
+
+	// Blocking on a free slot holds up the subscription's delivery goroutine, which
+	// is what bounds the pool
+	slots := make(chan struct{}, concurrency)
+	dispatch := func(msg *nats.Msg) {
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots }()
+			handler(msg)
+		}()
+	}
 
-	sub, err := n.js.QueueSubscribe(fullSubject, queue, handler, nats.Durable(durableName))
+	sub, err := n.js.QueueSubscribe(fullSubject, queue, dispatch, n.bindOpts(durableName)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to queue subscribe: %w", err)
 	}
@@ -118,19 +304,42 @@ func (n *NATSClient) QueueSubscribe(subject, queue, durableName string, handler
 	return sub, nil
 }
 
-// HealthCheck performs a health check on NATS
-func (n *NATSClient) HealthCheck() error {
-	if n.conn == nil || !n.conn.IsConnected() {
-		return fmt.Errorf("NATS connection is not active")
+// PublishCore publishes a message on core NATS without JetStream persistence.
+// Use it for live events that are only meaningful to currently connected subscribers.
+func (n *NATSClient) PublishCore(subject string, data []byte) error {
+	if err := n.conn.Publish(subject, data); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+// SubscribeCore creates an ephemeral core NATS subscription
+func (n *NATSClient) SubscribeCore(subject string, handler nats.MsgHandler) (*nats.Subscription, error) {
+	sub, err := n.conn.Subscribe(subject, handler)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	return sub, nil
+}
+
+// HealthCheck performs a health check on NATS and returns the stream info round-trip latency
+func (n *NATSClient) HealthCheck() (time.Duration, error) {
+	if n.conn == nil {
+		return 0, fmt.Errorf("NATS connection is not active")
+	}
+	if status := n.conn.Status(); status != nats.CONNECTED {
+		return 0, fmt.Errorf("NATS connection is %s", strings.ToLower(status.String()))
 	}
 
 	// Try to get stream info
+	start := time.Now()
 	_, err := n.js.StreamInfo(n.stream)
 	if err != nil {
-		return fmt.Errorf("NATS health check failed: %w", err)
+		return time.Since(start), fmt.Errorf("NATS health check failed: %w", err)
 	}
 
-	return nil
+	return time.Since(start), nil
 }
 
 // Close closes the NATS connection
@@ -145,11 +354,24 @@ func (n *NATSClient) GetJetStream() nats.JetStreamContext {
 	return n.js
 }
 
+// StreamName returns the JetStream stream name, which prefixes every published subject
+func (n *NATSClient) StreamName() string {
+	return n.stream
+}
+
 // GetConnection returns the underlying NATS connection
 func (n *NATSClient) GetConnection() *nats.Conn {
 	return n.conn
 }
 
+// limitOrUnlimited maps a zero limit to JetStream's "no limit" value
+func limitOrUnlimited(limit int64) int64 {
+	if limit == 0 {
+		return -1
+	}
+	return limit
+}
+
 // formatNATSURLs formats multiple NATS URLs into a single string
 func formatNATSURLs(urls []string) string {
 	if len(urls) == 0 {
@@ -54,6 +54,7 @@ func NewRedisClient(cfg config.RedisConfig) (*RedisClient, error) {
 	defer cancel()
 
 	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
@@ -152,16 +153,17 @@ func (r *RedisClient) Pipeline() redis.Pipeliner {
 	return r.client.Pipeline()
 }
 
-// HealthCheck performs a health check on Redis
-func (r *RedisClient) HealthCheck() error {
+// HealthCheck performs a health check on Redis and returns the ping round-trip latency
+func (r *RedisClient) HealthCheck() (time.Duration, error) {
 	ctx, cancel := createContext(5 * time.Second)
 	defer cancel()
 
+	start := time.Now()
 	if err := r.client.Ping(ctx).Err(); err != nil {
-		return fmt.Errorf("Redis health check failed: %w", err)
+		return time.Since(start), fmt.Errorf("Redis health check failed: %w", err)
 	}
 
-	return nil
+	return time.Since(start), nil
 }
 
 // Close closes the Redis connection
@@ -1,9 +1,12 @@
 package config
 
 import (
+	"crypto/rsa"
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,6 +15,7 @@ import (
 // Config holds all application configuration
 type Config struct {
 	App      AppConfig
+	CORS     CORSConfig
 	Database DatabaseConfig
 	Redis    RedisConfig
 	NATS     NATSConfig
@@ -20,6 +24,12 @@ type Config struct {
 	Twilio   TwilioConfig
 	Storage  StorageConfig
 	GA4      GA4Config
+	Notifications NotificationsConfig
+	Audit    AuditConfig
+	Metrics  MetricsConfig
+	Users    UsersConfig
+	Cleanup  CleanupConfig
+	Tickets  TicketsConfig
 }
 
 // AppConfig holds application-level configuration
@@ -28,10 +38,34 @@ type AppConfig struct {
 	Env         string
 	Port        string
 	Version     string
+	SupportedVersions []string // versions clients may request via Accept-Version
 	LogLevel    string
 	TrustedProxies []string
-	AllowOrigins   []string
 	RateLimitRPS   int
+	AuthRateLimit  int // per-minute limit on login, registration and token endpoints
+	LoginRateLimit  int           // login attempts allowed per email and IP within LoginRateWindow
+	LoginRateWindow time.Duration
+	MaxBodySize    int64 // default request body limit; routes may override it
+	HealthDegradedThreshold time.Duration // dependency latency above which /status reports degraded
+	ShutdownTimeout         time.Duration // how long in-flight requests may take to drain on shutdown
+	StartupMaxAttempts      int           // connection attempts per dependency before startup fails
+	StartupRetryBaseDelay   time.Duration // delay after the first failed attempt, doubled after each one
+	// EncryptionKey encrypts secrets stored in the database (TOTP secrets, encrypted
+	// settings). It is independent of JWT_SECRET, which RS256 deployments don't set.
+	EncryptionKey string
+	// PublicURL is the scheme and host clients reach the API on, used to build download
+	// links. When empty, links use the host of the request they are returned from.
+	PublicURL string
+}
+
+// CORSConfig holds Cross-Origin Resource Sharing configuration
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowCredentials bool // when set, matching origins are echoed instead of "*"
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	MaxAge           time.Duration // how long browsers may cache preflight results
 }
 
 // DatabaseConfig holds database configuration
@@ -45,6 +79,7 @@ type DatabaseConfig struct {
 	MaxOpenConns int
 	MaxIdleConns int
 	ConnMaxLifetime time.Duration
+	QueryTimeout    time.Duration // default deadline for context-aware queries
 }
 
 // RedisConfig holds Redis configuration with Sentinel support
@@ -63,6 +98,21 @@ type NATSConfig struct {
 	URLs     []string
 	Token    string
 	StreamName string
+
+	// Stream retention
+	Subjects    []string // defaults to "<StreamName>.>"
+	StorageType string   // "file" or "memory"
+	MaxAge      time.Duration
+	MaxBytes    int64 // 0 means unlimited
+	MaxMsgs     int64 // 0 means unlimited
+
+	// Consumer delivery
+	MaxDeliver int
+	AckWait    time.Duration
+	// WorkerConcurrency is how many notification deliveries each instance handles in
+	// parallel. It is also the consumer's max ack pending, applied to the existing durable
+	// consumer on startup like NATS_ACK_WAIT and NATS_MAX_DELIVER.
+	WorkerConcurrency int
 }
 
 // OAuthConfig holds OAuth2 server configuration
@@ -71,6 +121,18 @@ type OAuthConfig struct {
 	RefreshTokenExpiry time.Duration
 	JWTSecret          string
 	JWTIssuer          string
+	JWTAudience        string   // optional aud claim required on every token
+	JWTLeeway          time.Duration // clock skew tolerated when checking exp and nbf
+	JWTAlgorithm       string   // HS256 or RS256, used for signing new tokens
+	JWTKeyID           string   // kid of the RS256 signing key
+	JWTPrivateKeyPath  string
+	JWTPublicKeyPaths  []string // extra verification keys as kid=path entries
+	JWTPrivateKey      *rsa.PrivateKey
+	JWTPublicKeys      map[string]*rsa.PublicKey // verification keys by kid, loaded from the paths above
+	AllowedScopes      []string // scope catalog API clients may request
+	// UntypedRefreshUntil is the Unix time until which tokens minted before the
+	// token_type claim existed are still accepted as refresh tokens; 0 rejects them
+	UntypedRefreshUntil int64
 }
 
 // SMTPConfig holds SendGrid configuration
@@ -83,9 +145,10 @@ type SMTPConfig struct {
 
 // TwilioConfig holds Twilio configuration
 type TwilioConfig struct {
-	AccountSID string
-	AuthToken  string
-	FromNumber string
+	AccountSID        string
+	AuthToken         string
+	FromNumber        string
+	StatusCallbackURL string // public URL of POST /notifications/twilio-callback
 }
 
 // StorageConfig holds file storage configuration
@@ -97,6 +160,70 @@ type StorageConfig struct {
 	S3AccessKey string
 	S3SecretKey string
 	MaxFileSize int64
+	PresignExpiry time.Duration
+	AllowedMimeTypes []string // supports wildcards like image/*; "*" allows any type
+	AvatarMaxSize    int64    // avatars have their own, usually smaller, limit
+	AvatarMimeTypes  []string // image types accepted as avatars
+	DeletedRetention time.Duration // how long soft-deleted files are kept before purge
+}
+
+// NotificationsConfig holds notification delivery configuration
+type NotificationsConfig struct {
+	MaxAttempts       int
+	RetryBaseDelay    time.Duration
+	RetryMaxDelay     time.Duration
+	SchedulerInterval time.Duration
+	AsyncPublish      bool // queue without waiting for the JetStream ack
+	WebhookTimeout    time.Duration
+	RestoreWindow     time.Duration // how long a deleted notification can still be restored
+	DeletedRetention  time.Duration // how long soft-deleted notifications are kept before purge
+}
+
+// UsersConfig holds user account configuration
+type UsersConfig struct {
+	RestoreWindow    time.Duration // how long a soft-deleted account can still be restored
+	DeletedRetention time.Duration // how long soft-deleted accounts are kept before purge
+	// ReserveDeletedEmails stops new registrations from taking the email of a
+	// soft-deleted account, so that account can always be restored. When off,
+	// the email is free again and restoring fails once it has been reused.
+	ReserveDeletedEmails bool
+	PhoneCodeRateLimit   int           // verification SMS a user may request per window
+	PhoneCodeRateWindow  time.Duration
+	// Password policy applied whenever a user sets a password
+	PasswordMinLength     int
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	// PasswordBreachCheck rejects passwords found by the breached password range API
+	PasswordBreachCheck   bool
+	PasswordBreachAPIURL  string
+	PasswordBreachTimeout time.Duration
+}
+
+// CleanupConfig holds configuration for purging soft-deleted rows. Each table's
+// retention lives with its own section, e.g. StorageConfig.DeletedRetention.
+type CleanupConfig struct {
+	Interval time.Duration
+}
+
+// TicketsConfig holds support ticket configuration
+type TicketsConfig struct {
+	// FreeTextCategories accepts ticket categories missing from the managed list
+	// instead of rejecting them
+	FreeTextCategories bool
+}
+
+// MetricsConfig holds Prometheus metrics export configuration
+type MetricsConfig struct {
+	Enabled bool
+	Token   string // optional bearer token required to scrape /metrics
+}
+
+// AuditConfig holds audit logging configuration
+type AuditConfig struct {
+	SkipRoutes     []string      // paths, or "METHOD /path" entries, that are never audited
+	ExportMaxRange time.Duration // widest date range a single CSV export may cover
 }
 
 // GA4Config holds Google Analytics 4 configuration
@@ -111,16 +238,41 @@ func Load() (*Config, error) {
 	// Load .env file if exists (not in production)
 	_ = godotenv.Load()
 
+	// Optional YAML/JSON config file; values already set in the environment win
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := loadConfigFile(path); err != nil {
+			return nil, err
+		}
+	}
+
 	cfg := &Config{
 		App: AppConfig{
 			Name:        getEnv("APP_NAME", "Go API System"),
 			Env:         getEnv("APP_ENV", "development"),
 			Port:        getEnv("APP_PORT", "8080"),
 			Version:     getEnv("APP_VERSION", "v1"),
+			SupportedVersions: getEnvSlice("SUPPORTED_API_VERSIONS", []string{"v1"}),
 			LogLevel:    getEnv("LOG_LEVEL", "info"),
 			TrustedProxies: getEnvSlice("TRUSTED_PROXIES", []string{"127.0.0.1"}),
-			AllowOrigins:   getEnvSlice("ALLOW_ORIGINS", []string{"http://localhost:3000"}),
 			RateLimitRPS:   getEnvInt("RATE_LIMIT_RPS", 100),
+			AuthRateLimit:  getEnvInt("AUTH_RATE_LIMIT", 10),
+			LoginRateLimit:  getEnvInt("LOGIN_RATE_LIMIT", 5),
+			LoginRateWindow: time.Duration(getEnvInt("LOGIN_RATE_WINDOW", 900)) * time.Second,
+			MaxBodySize:    int64(getEnvInt("MAX_BODY_SIZE", 1048576)), // 1MB default
+			HealthDegradedThreshold: time.Duration(getEnvInt("HEALTH_DEGRADED_THRESHOLD_MS", 500)) * time.Millisecond,
+			ShutdownTimeout:         time.Duration(getEnvInt("SHUTDOWN_TIMEOUT", 30)) * time.Second,
+			StartupMaxAttempts:      getEnvInt("STARTUP_MAX_ATTEMPTS", 10),
+			StartupRetryBaseDelay:   time.Duration(getEnvInt("STARTUP_RETRY_BASE_DELAY_MS", 500)) * time.Millisecond,
+			EncryptionKey:           getEnv("ENCRYPTION_KEY", ""),
+			PublicURL:               strings.TrimSuffix(getEnv("APP_PUBLIC_URL", ""), "/"),
+		},
+		CORS: CORSConfig{
+			AllowOrigins:     getEnvSlice("ALLOW_ORIGINS", []string{"http://localhost:3000"}),
+			AllowCredentials: getEnvBool("CORS_ALLOW_CREDENTIALS", true),
+			AllowMethods:     getEnvSlice("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowHeaders:     getEnvSlice("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Content-Length", "Accept-Encoding", "Authorization", "X-CSRF-Token", "X-Request-ID", "Accept-Version"}),
+			ExposeHeaders:    getEnvSlice("CORS_EXPOSE_HEADERS", []string{"Content-Length", "X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "API-Version"}),
+			MaxAge:           time.Duration(getEnvInt("CORS_MAX_AGE", 43200)) * time.Second,
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -132,6 +284,7 @@ func Load() (*Config, error) {
 			MaxOpenConns: getEnvInt("DB_MAX_OPEN_CONNS", 25),
 			MaxIdleConns: getEnvInt("DB_MAX_IDLE_CONNS", 5),
 			ConnMaxLifetime: time.Duration(getEnvInt("DB_CONN_MAX_LIFETIME", 5)) * time.Minute,
+			QueryTimeout:    time.Duration(getEnvInt("DB_QUERY_TIMEOUT", 10)) * time.Second,
 		},
 		Redis: RedisConfig{
 			Addresses:    getEnvSlice("REDIS_ADDRESSES", []string{"localhost:6379"}),
@@ -146,12 +299,28 @@ func Load() (*Config, error) {
 			URLs:       getEnvSlice("NATS_URLS", []string{"nats://localhost:4222"}),
 			Token:      getEnv("NATS_TOKEN", ""),
 			StreamName: getEnv("NATS_STREAM_NAME", "NOTIFICATIONS"),
+			Subjects:    getEnvSlice("NATS_STREAM_SUBJECTS", nil),
+			StorageType: getEnv("NATS_STORAGE_TYPE", "file"),
+			MaxAge:      time.Duration(getEnvInt("NATS_MAX_AGE", 7*24*3600)) * time.Second,
+			MaxBytes:    getEnvInt64("NATS_MAX_BYTES", 0),
+			MaxMsgs:     getEnvInt64("NATS_MAX_MSGS", 0),
+			MaxDeliver:  getEnvInt("NATS_MAX_DELIVER", 10),
+			AckWait:     time.Duration(getEnvInt("NATS_ACK_WAIT", 30)) * time.Second,
+			WorkerConcurrency: getEnvInt("NATS_WORKER_CONCURRENCY", 4),
 		},
 		OAuth: OAuthConfig{
 			AccessTokenExpiry:  time.Duration(getEnvInt("OAUTH_ACCESS_TOKEN_EXPIRY", 3600)) * time.Second,
 			RefreshTokenExpiry: time.Duration(getEnvInt("OAUTH_REFRESH_TOKEN_EXPIRY", 2592000)) * time.Second,
 			JWTSecret:          getEnv("JWT_SECRET", ""),
 			JWTIssuer:          getEnv("JWT_ISSUER", "goapi"),
+			JWTAudience:        getEnv("JWT_AUDIENCE", ""),
+			JWTLeeway:          time.Duration(getEnvInt("JWT_LEEWAY", 30)) * time.Second,
+			JWTAlgorithm:       getEnv("JWT_ALGORITHM", "HS256"),
+			JWTKeyID:           getEnv("JWT_KEY_ID", "default"),
+			JWTPrivateKeyPath:  getEnv("JWT_PRIVATE_KEY_PATH", ""),
+			JWTPublicKeyPaths:  getEnvSlice("JWT_PUBLIC_KEYS", []string{}),
+			AllowedScopes:      getEnvSlice("OAUTH_ALLOWED_SCOPES", []string{"openid", "profile", "email", "read", "write"}),
+			UntypedRefreshUntil: getEnvInt64("JWT_UNTYPED_REFRESH_UNTIL", 0),
 		},
 		SMTP: SMTPConfig{
 			APIKey:       getEnv("SENDGRID_API_KEY", ""),
@@ -160,9 +329,10 @@ func Load() (*Config, error) {
 			ReplyToEmail: getEnv("SENDGRID_REPLY_TO_EMAIL", ""),
 		},
 		Twilio: TwilioConfig{
-			AccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
-			AuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
-			FromNumber: getEnv("TWILIO_FROM_NUMBER", ""),
+			AccountSID:        getEnv("TWILIO_ACCOUNT_SID", ""),
+			AuthToken:         getEnv("TWILIO_AUTH_TOKEN", ""),
+			FromNumber:        getEnv("TWILIO_FROM_NUMBER", ""),
+			StatusCallbackURL: getEnv("TWILIO_STATUS_CALLBACK_URL", ""),
 		},
 		Storage: StorageConfig{
 			Type:        getEnv("STORAGE_TYPE", "local"),
@@ -172,12 +342,65 @@ func Load() (*Config, error) {
 			S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
 			S3SecretKey: getEnv("S3_SECRET_KEY", ""),
 			MaxFileSize: int64(getEnvInt("MAX_FILE_SIZE", 10485760)), // 10MB default
+			PresignExpiry: time.Duration(getEnvInt("STORAGE_PRESIGN_EXPIRY", 900)) * time.Second,
+			AllowedMimeTypes: getEnvSlice("STORAGE_ALLOWED_MIME_TYPES", []string{"image/*", "application/pdf", "text/plain"}),
+			AvatarMaxSize:    getEnvInt64("AVATAR_MAX_FILE_SIZE", 2097152), // 2MB default
+			AvatarMimeTypes:  getEnvSlice("AVATAR_ALLOWED_MIME_TYPES", []string{"image/jpeg", "image/png", "image/gif", "image/webp"}),
+			DeletedRetention: time.Duration(getEnvInt("STORAGE_DELETED_RETENTION_DAYS", 30)) * 24 * time.Hour,
 		},
 		GA4: GA4Config{
 			MeasurementID: getEnv("GA4_MEASUREMENT_ID", ""),
 			APISecret:     getEnv("GA4_API_SECRET", ""),
 			Enabled:       getEnvBool("GA4_ENABLED", false),
 		},
+		Notifications: NotificationsConfig{
+			MaxAttempts:       getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 5),
+			RetryBaseDelay:    time.Duration(getEnvInt("NOTIFICATION_RETRY_BASE_DELAY", 5)) * time.Second,
+			RetryMaxDelay:     time.Duration(getEnvInt("NOTIFICATION_RETRY_MAX_DELAY", 3600)) * time.Second,
+			SchedulerInterval: time.Duration(getEnvInt("NOTIFICATION_SCHEDULER_INTERVAL", 30)) * time.Second,
+			AsyncPublish:      getEnvBool("NOTIFICATION_ASYNC_PUBLISH", false),
+			WebhookTimeout:    time.Duration(getEnvInt("NOTIFICATION_WEBHOOK_TIMEOUT", 10)) * time.Second,
+			RestoreWindow:     time.Duration(getEnvInt("NOTIFICATION_RESTORE_WINDOW", 3600)) * time.Second,
+			DeletedRetention:  time.Duration(getEnvInt("NOTIFICATION_DELETED_RETENTION_DAYS", 7)) * 24 * time.Hour,
+		},
+		Audit: AuditConfig{
+			SkipRoutes:     getEnvSlice("AUDIT_SKIP_ROUTES", []string{"POST /api/v1/users/login"}),
+			ExportMaxRange: time.Duration(getEnvInt("AUDIT_EXPORT_MAX_DAYS", 31)) * 24 * time.Hour,
+		},
+		Metrics: MetricsConfig{
+			Enabled: getEnvBool("METRICS_ENABLED", false),
+			Token:   getEnv("METRICS_TOKEN", ""),
+		},
+		Users: UsersConfig{
+			RestoreWindow:        time.Duration(getEnvInt("USER_RESTORE_WINDOW_DAYS", 30)) * 24 * time.Hour,
+			DeletedRetention:     time.Duration(getEnvInt("USER_DELETED_RETENTION_DAYS", 90)) * 24 * time.Hour,
+			ReserveDeletedEmails: getEnvBool("USER_RESERVE_DELETED_EMAILS", false),
+			PhoneCodeRateLimit:   getEnvInt("PHONE_CODE_RATE_LIMIT", 3),
+			PhoneCodeRateWindow:  time.Duration(getEnvInt("PHONE_CODE_RATE_WINDOW", 3600)) * time.Second,
+			PasswordMinLength:     getEnvInt("PASSWORD_MIN_LENGTH", 8),
+			PasswordRequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", true),
+			PasswordRequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", true),
+			PasswordRequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", true),
+			PasswordRequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+			PasswordBreachCheck:   getEnvBool("PASSWORD_BREACH_CHECK", false),
+			PasswordBreachAPIURL:  getEnv("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com/range/"),
+			PasswordBreachTimeout: time.Duration(getEnvInt("PASSWORD_BREACH_TIMEOUT", 3)) * time.Second,
+		},
+		Cleanup: CleanupConfig{
+			Interval: time.Duration(getEnvInt("CLEANUP_INTERVAL", 60)) * time.Minute,
+		},
+		Tickets: TicketsConfig{
+			FreeTextCategories: getEnvBool("TICKET_FREE_TEXT_CATEGORIES", false),
+		},
+	}
+
+	if len(cfg.NATS.Subjects) == 0 {
+		cfg.NATS.Subjects = []string{cfg.NATS.StreamName + ".>"}
+	}
+
+	// Load RS256 key material
+	if err := cfg.OAuth.loadSigningKeys(); err != nil {
+		return nil, err
 	}
 
 	// Validate critical configuration
@@ -190,8 +413,91 @@ func Load() (*Config, error) {
 
 // Validate checks if critical configuration values are set
 func (c *Config) Validate() error {
+	if len(c.App.EncryptionKey) < 32 {
+		return fmt.Errorf("ENCRYPTION_KEY is required and must be at least 32 characters")
+	}
+
+	if c.App.PublicURL != "" {
+		u, err := url.Parse(c.App.PublicURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("APP_PUBLIC_URL %q must be a URL like https://api.example.com", c.App.PublicURL)
+		}
+	}
+
+	switch c.OAuth.JWTAlgorithm {
+	case "HS256":
+	case "RS256":
+		if c.OAuth.JWTPrivateKey == nil {
+			return fmt.Errorf("JWT_PRIVATE_KEY_PATH is required when JWT_ALGORITHM is RS256")
+		}
+	default:
+		return fmt.Errorf("JWT_ALGORITHM must be HS256 or RS256")
+	}
+
+	if c.OAuth.AccessTokenExpiry <= 0 {
+		return fmt.Errorf("OAUTH_ACCESS_TOKEN_EXPIRY must be positive")
+	}
+	if c.OAuth.RefreshTokenExpiry <= 0 {
+		return fmt.Errorf("OAUTH_REFRESH_TOKEN_EXPIRY must be positive")
+	}
+
+	if c.App.RateLimitRPS <= 0 {
+		return fmt.Errorf("RATE_LIMIT_RPS must be positive")
+	}
+	if c.App.AuthRateLimit <= 0 {
+		return fmt.Errorf("AUTH_RATE_LIMIT must be positive")
+	}
+	if c.App.LoginRateLimit <= 0 || c.App.LoginRateWindow <= 0 {
+		return fmt.Errorf("LOGIN_RATE_LIMIT and LOGIN_RATE_WINDOW must be positive")
+	}
+	if c.App.StartupMaxAttempts <= 0 || c.App.StartupRetryBaseDelay <= 0 {
+		return fmt.Errorf("STARTUP_MAX_ATTEMPTS and STARTUP_RETRY_BASE_DELAY_MS must be positive")
+	}
+	if c.Users.RestoreWindow < 0 || c.Users.DeletedRetention < c.Users.RestoreWindow {
+		return fmt.Errorf("USER_RESTORE_WINDOW_DAYS must not be negative or longer than USER_DELETED_RETENTION_DAYS")
+	}
+	if c.Users.DeletedRetention <= 0 || c.Storage.DeletedRetention <= 0 || c.Notifications.DeletedRetention <= 0 {
+		return fmt.Errorf("USER_DELETED_RETENTION_DAYS, STORAGE_DELETED_RETENTION_DAYS and NOTIFICATION_DELETED_RETENTION_DAYS must be positive")
+	}
+	if c.Audit.ExportMaxRange <= 0 {
+		return fmt.Errorf("AUDIT_EXPORT_MAX_DAYS must be positive")
+	}
+	if c.Cleanup.Interval <= 0 {
+		return fmt.Errorf("CLEANUP_INTERVAL must be positive")
+	}
+	if c.Users.PhoneCodeRateLimit <= 0 || c.Users.PhoneCodeRateWindow <= 0 {
+		return fmt.Errorf("PHONE_CODE_RATE_LIMIT and PHONE_CODE_RATE_WINDOW must be positive")
+	}
+	// bcrypt ignores everything after 72 bytes, so a longer minimum can't add strength
+	if c.Users.PasswordMinLength < 8 || c.Users.PasswordMinLength > 72 {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH must be between 8 and 72")
+	}
+	if c.Users.PasswordBreachCheck && (c.Users.PasswordBreachAPIURL == "" || c.Users.PasswordBreachTimeout <= 0) {
+		return fmt.Errorf("PASSWORD_BREACH_API_URL and a positive PASSWORD_BREACH_TIMEOUT are required when PASSWORD_BREACH_CHECK is enabled")
+	}
+
+	if err := c.CORS.validate(); err != nil {
+		return err
+	}
+	if err := c.Storage.validate(); err != nil {
+		return err
+	}
+	if err := c.NATS.validate(); err != nil {
+		return err
+	}
+	if c.Notifications.WebhookTimeout <= 0 {
+		return fmt.Errorf("NOTIFICATION_WEBHOOK_TIMEOUT must be positive")
+	}
+	// Purging a notification before its restore window ends would make restores fail unpredictably
+	if c.Notifications.RestoreWindow < 0 || c.Notifications.DeletedRetention < c.Notifications.RestoreWindow {
+		return fmt.Errorf("NOTIFICATION_RESTORE_WINDOW must not be negative or longer than NOTIFICATION_DELETED_RETENTION_DAYS")
+	}
+	if c.NATS.MaxDeliver < c.Notifications.MaxAttempts {
+		return fmt.Errorf("NATS_MAX_DELIVER (%d) must be at least NOTIFICATION_MAX_ATTEMPTS (%d)", c.NATS.MaxDeliver, c.Notifications.MaxAttempts)
+	}
+
 	if c.App.Env == "production" {
-		if c.OAuth.JWTSecret == "" {
+		if c.OAuth.JWTSecret == "" && c.OAuth.JWTAlgorithm == "HS256" {
 			return fmt.Errorf("JWT_SECRET is required in production")
 		}
 		if c.Database.Password == "" {
@@ -201,6 +507,84 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validate checks that every allowed origin is "*" or a bare http(s) origin. Browsers
+// send Origin without a path, so an entry with one, even a trailing "/", never matches.
+func (c *CORSConfig) validate() error {
+	for _, origin := range c.AllowOrigins {
+		if origin == "*" {
+			continue
+		}
+		u, err := url.Parse(origin)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.Fragment != "" {
+			return fmt.Errorf("ALLOW_ORIGINS entry %q must be a URL like https://example.com, without a trailing slash", origin)
+		}
+	}
+	return nil
+}
+
+// validate checks the storage backend and its credentials
+func (s *StorageConfig) validate() error {
+	switch s.Type {
+	case "local":
+		if s.BasePath == "" {
+			return fmt.Errorf("STORAGE_BASE_PATH is required when STORAGE_TYPE is local")
+		}
+	case "s3":
+		if s.S3Bucket == "" || s.S3Region == "" {
+			return fmt.Errorf("S3_BUCKET and S3_REGION are required when STORAGE_TYPE is s3")
+		}
+		if s.S3AccessKey == "" || s.S3SecretKey == "" {
+			return fmt.Errorf("S3_ACCESS_KEY and S3_SECRET_KEY are required when STORAGE_TYPE is s3")
+		}
+	default:
+		return fmt.Errorf("STORAGE_TYPE must be local or s3")
+	}
+
+	if s.MaxFileSize <= 0 {
+		return fmt.Errorf("MAX_FILE_SIZE must be positive")
+	}
+	if s.AvatarMaxSize <= 0 {
+		return fmt.Errorf("AVATAR_MAX_FILE_SIZE must be positive")
+	}
+	for _, mimeType := range s.AvatarMimeTypes {
+		if !strings.HasPrefix(mimeType, "image/") {
+			return fmt.Errorf("AVATAR_ALLOWED_MIME_TYPES may only contain image types, got %q", mimeType)
+		}
+	}
+	return nil
+}
+
+// validate checks the JetStream stream and consumer settings
+func (n *NATSConfig) validate() error {
+	if n.StreamName == "" {
+		return fmt.Errorf("NATS_STREAM_NAME is required")
+	}
+	if n.StorageType != "file" && n.StorageType != "memory" {
+		return fmt.Errorf("NATS_STORAGE_TYPE must be file or memory")
+	}
+	if n.MaxAge < 0 || n.MaxBytes < 0 || n.MaxMsgs < 0 {
+		return fmt.Errorf("NATS_MAX_AGE, NATS_MAX_BYTES and NATS_MAX_MSGS must not be negative")
+	}
+	if n.MaxDeliver < 1 {
+		return fmt.Errorf("NATS_MAX_DELIVER must be at least 1")
+	}
+	if n.AckWait <= 0 {
+		return fmt.Errorf("NATS_ACK_WAIT must be positive")
+	}
+	if n.WorkerConcurrency < 1 {
+		return fmt.Errorf("NATS_WORKER_CONCURRENCY must be at least 1")
+	}
+
+	// Publishers prefix every subject with the stream name, so the stream must capture that namespace
+	prefix := n.StreamName + "."
+	for _, subject := range n.Subjects {
+		if len(subject) <= len(prefix) || subject[:len(prefix)] != prefix {
+			return fmt.Errorf("NATS_STREAM_SUBJECTS entry %q must start with %q", subject, prefix)
+		}
+	}
+	return nil
+}
+
 // IsDevelopment returns true if running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.App.Env == "development"
@@ -229,6 +613,15 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+func getEnvInt64(key string, defaultVal int64) int64 {
+	if val := os.Getenv(key); val != "" {
+		if intVal, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return intVal
+		}
+	}
+	return defaultVal
+}
+
 func getEnvBool(key string, defaultVal bool) bool {
 	if val := os.Getenv(key); val != "" {
 		if boolVal, err := strconv.ParseBool(val); err == nil {
@@ -0,0 +1,28 @@
+package config
+
+import "testing"
+
+func TestCORSConfigValidate(t *testing.T) {
+	tests := []struct {
+		origin  string
+		wantErr bool
+	}{
+		{"*", false},
+		{"https://app.example.com", false},
+		{"http://localhost:3000", false},
+		{"https://app.example.com/", true},
+		{"https://app.example.com/app", true},
+		{"https://app.example.com?x=1", true},
+		{"app.example.com", true},
+		{"ftp://app.example.com", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			cfg := &CORSConfig{AllowOrigins: []string{tt.origin}}
+			if err := cfg.validate(); (err != nil) != tt.wantErr {
+				t.Errorf("validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+// loadConfigFile reads a YAML or JSON file of environment variable names to values,
+// e.g. "DB_HOST: db.internal", and exports every entry not already set in the
+// environment so real env vars always take precedence. Lists are joined with commas
+// to match the env var format.
+func loadConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read CONFIG_FILE: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	default:
+		return fmt.Errorf("CONFIG_FILE must be a .yaml, .yml or .json file")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse CONFIG_FILE %s: %w", path, err)
+	}
+
+	for key, value := range values {
+		key = strings.ToUpper(key)
+		if _, set := os.LookupEnv(key); set {
+			continue
+		}
+
+		str, err := configValueString(value)
+		if err != nil {
+			return fmt.Errorf("invalid CONFIG_FILE value for %s: %w", key, err)
+		}
+		if err := os.Setenv(key, str); err != nil {
+			return fmt.Errorf("failed to apply CONFIG_FILE value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// configValueString renders a scalar or list of scalars the way it would appear in an env var
+func configValueString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			str, err := configValueString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, str)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("nested objects are not supported, use the env var name as the key")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
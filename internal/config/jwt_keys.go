@@ -0,0 +1,98 @@
+package config
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// loadSigningKeys reads the RSA key files configured for RS256 signing and verification
+func (o *OAuthConfig) loadSigningKeys() error {
+	o.JWTPublicKeys = map[string]*rsa.PublicKey{}
+
+	if o.JWTPrivateKeyPath != "" {
+		privateKey, err := readRSAPrivateKey(o.JWTPrivateKeyPath)
+		if err != nil {
+			return err
+		}
+		o.JWTPrivateKey = privateKey
+		o.JWTPublicKeys[o.JWTKeyID] = &privateKey.PublicKey
+	}
+
+	// Additional verification keys, e.g. retired signing keys still in rotation
+	for _, entry := range o.JWTPublicKeyPaths {
+		keyID, path, found := strings.Cut(entry, "=")
+		if !found || keyID == "" || path == "" {
+			return fmt.Errorf("invalid JWT_PUBLIC_KEYS entry %q, expected kid=path", entry)
+		}
+
+		publicKey, err := readRSAPublicKey(path)
+		if err != nil {
+			return err
+		}
+		o.JWTPublicKeys[keyID] = publicKey
+	}
+
+	return nil
+}
+
+// readRSAPrivateKey loads a PKCS#1 or PKCS#8 PEM encoded RSA private key
+func readRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT private key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("JWT private key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// readRSAPublicKey loads a PKIX or PKCS#1 PEM encoded RSA public key
+func readRSAPublicKey(path string) (*rsa.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT public key %s: %w", path, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("JWT public key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// readPEMBlock reads the first PEM block from a file
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key file %s: %w", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"database/sql"
+	"strings"
+
+	"gogin/internal/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// RegisterDBStats exposes connection pool statistics, read from stats on every scrape
+func RegisterDBStats(stats func() sql.DBStats) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_pool_max_open_connections",
+		Help: "Maximum number of open connections to the database",
+	}, func() float64 { return float64(stats().MaxOpenConnections) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_pool_open_connections",
+		Help: "Number of established connections, both in use and idle",
+	}, func() float64 { return float64(stats().OpenConnections) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_pool_in_use_connections",
+		Help: "Number of connections currently in use",
+	}, func() float64 { return float64(stats().InUse) })
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "db_pool_idle_connections",
+		Help: "Number of idle connections",
+	}, func() float64 { return float64(stats().Idle) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_pool_wait_count_total",
+		Help: "Total number of connections waited for",
+	}, func() float64 { return float64(stats().WaitCount) })
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "db_pool_wait_duration_seconds_total",
+		Help: "Total time blocked waiting for a new connection",
+	}, func() float64 { return stats().WaitDuration.Seconds() })
+}
+
+// Handler serves the default registry in the Prometheus exposition format. When
+// token is set, scrapers must send it as a bearer token.
+func Handler(token string) gin.HandlerFunc {
+	promHandler := promhttp.Handler()
+
+	return func(c *gin.Context) {
+		if token != "" {
+			provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				response.Unauthorized(c, "Invalid metrics token")
+				return
+			}
+		}
+
+		promHandler.ServeHTTP(c.Writer, c.Request)
+	}
+}
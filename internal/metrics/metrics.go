@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultBuckets are latency histogram bounds in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Application metrics, registered with the default Prometheus registry
+var (
+	HTTPRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Total number of HTTP requests by method, route and status",
+	}, []string{"method", "route", "status"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds by method, route and status",
+		Buckets: DefaultBuckets,
+	}, []string{"method", "route", "status"})
+	RateLimitRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_rejections_total",
+		Help: "Requests rejected by the rate limiter, by identifier kind (user, client, ip or route)",
+	}, []string{"kind"})
+	NotificationSends = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "notification_sends_total",
+		Help: "Notification delivery attempts by channel and outcome (sent, retry or failed)",
+	}, []string{"channel", "outcome"})
+)
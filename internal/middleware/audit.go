@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"io"
+	"strings"
 	"time"
 
 	"gogin/internal/clients"
@@ -12,21 +13,76 @@ import (
 	"github.com/google/uuid"
 )
 
+// redactedValue replaces sensitive values in captured request bodies
+const redactedValue = "[REDACTED]"
+
+// sensitiveFieldMarkers are substrings of JSON keys whose values are never stored
+var sensitiveFieldMarkers = []string{"password", "secret", "token"}
+
+// maxCapturedErrorBody caps how much of an error response is kept to read its error codes
+const maxCapturedErrorBody = 8 << 10
+
+// auditResponseWriter passes the response through unchanged, so streamed downloads and
+// event streams still flush as they are written, while keeping the start of error
+// response bodies to extract their error codes
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	errorBody bytes.Buffer
+}
+
+func (w *auditResponseWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditResponseWriter) WriteString(data string) (int, error) {
+	w.capture([]byte(data))
+	return w.ResponseWriter.WriteString(data)
+}
+
+// capture keeps the start of the body when the response is an error
+func (w *auditResponseWriter) capture(data []byte) {
+	if w.ResponseWriter.Status() < 400 {
+		return
+	}
+	if remaining := maxCapturedErrorBody - w.errorBody.Len(); remaining > 0 {
+		w.errorBody.Write(data[:min(len(data), remaining)])
+	}
+}
+
 // AuditLogger middleware logs API requests to audit_logs table
 type AuditLogger struct {
-	db *clients.Database
+	db         *clients.Database
+	skipRoutes map[string]bool
 }
 
 // NewAuditLogger creates a new audit logger middleware
 func NewAuditLogger(db *clients.Database) *AuditLogger {
-	return &AuditLogger{db: db}
+	return &AuditLogger{
+		db: db,
+		skipRoutes: map[string]bool{
+			"/api/v1/health": true,
+			"/api/v1/status": true,
+			"/healthz":       true,
+			"/readyz":        true,
+			"/metrics":       true,
+		},
+	}
+}
+
+// WithSkipRoutes excludes routes from auditing. Each entry is either a path,
+// skipped for every method, or "METHOD /path" to skip a single method.
+func (a *AuditLogger) WithSkipRoutes(routes []string) *AuditLogger {
+	for _, route := range routes {
+		a.skipRoutes[strings.TrimSpace(route)] = true
+	}
+	return a
 }
 
 // Log returns middleware that logs requests to audit log
 func (a *AuditLogger) Log() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Skip logging for health/status endpoints
-		if c.Request.URL.Path == "/api/v1/health" || c.Request.URL.Path == "/api/v1/status" {
+		if a.shouldSkip(c.Request.Method, c.Request.URL.Path) {
 			c.Next()
 			return
 		}
@@ -34,14 +90,17 @@ func (a *AuditLogger) Log() gin.HandlerFunc {
 		// Record start time
 		startTime := time.Now()
 
-		// Get request body
-		var requestBody string
-		if c.Request.Body != nil {
+		// Capture JSON request bodies only; uploads and forms are not worth storing
+		var requestBody interface{}
+		if c.Request.Body != nil && strings.HasPrefix(c.ContentType(), "application/json") {
 			bodyBytes, _ := io.ReadAll(c.Request.Body)
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-			requestBody = string(bodyBytes)
+			requestBody = redactRequestBody(bodyBytes)
 		}
 
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
 		// Process request
 		c.Next()
 
@@ -56,16 +115,26 @@ func (a *AuditLogger) Log() gin.HandlerFunc {
 			clientID = cid.(string)
 		}
 
+		statusCode := c.Writer.Status()
+
 		// Prepare metadata
 		metadata := map[string]interface{}{
-			"method":         c.Request.Method,
-			"path":           c.Request.URL.Path,
-			"query":          c.Request.URL.RawQuery,
-			"ip":             c.ClientIP(),
-			"user_agent":     c.Request.UserAgent(),
-			"status_code":    c.Writer.Status(),
-			"duration_ms":    time.Since(startTime).Milliseconds(),
-			"request_id":     c.GetString("request_id"),
+			"method":        c.Request.Method,
+			"path":          c.Request.URL.Path,
+			"query":         c.Request.URL.RawQuery,
+			"ip":            c.ClientIP(),
+			"user_agent":    c.Request.UserAgent(),
+			"status_code":   statusCode,
+			"duration_ms":   time.Since(startTime).Milliseconds(),
+			"request_id":    c.GetString("request_id"),
+			"response_size": max(writer.Size(), 0),
+		}
+		if requestBody != nil {
+			metadata["request_body"] = requestBody
+		}
+		if codes, message := responseErrorDetails(writer.errorBody.Bytes()); len(codes) > 0 {
+			metadata["error_codes"] = codes
+			metadata["error_message"] = message
 		}
 
 		metadataJSON, _ := json.Marshal(metadata)
@@ -75,21 +144,102 @@ func (a *AuditLogger) Log() gin.HandlerFunc {
 			userID,
 			clientID,
 			c.Request.Method+" "+c.Request.URL.Path,
-			requestBody,
 			string(metadataJSON),
 			c.ClientIP(),
+			statusCode,
 		)
 	}
 }
 
-func (a *AuditLogger) insertAuditLog(userID, clientID, action, requestData, metadata, ipAddress string) {
+// shouldSkip reports whether the request matches a configured skip route
+func (a *AuditLogger) shouldSkip(method, path string) bool {
+	return a.skipRoutes[path] || a.skipRoutes[method+" "+path]
+}
+
+// responseErrorDetails reads the error codes and message from an error response
+// envelope. Bodies that are not an envelope, or were truncated, yield no codes.
+func responseErrorDetails(body []byte) ([]string, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+
+	var envelope struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, ""
+	}
+
+	codes := make([]string, 0, len(envelope.Errors))
+	for _, e := range envelope.Errors {
+		if e.Code != "" {
+			codes = append(codes, e.Code)
+		}
+	}
+	return codes, envelope.Message
+}
+
+// redactRequestBody parses a JSON body and masks sensitive fields at any depth.
+// Bodies that are not valid JSON are dropped rather than stored verbatim.
+func redactRequestBody(body []byte) interface{} {
+	if len(body) == 0 {
+		return nil
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil
+	}
+	return redactValue(parsed)
+}
+
+// redactValue walks decoded JSON, replacing values of sensitive keys
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitiveField(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(field)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// isSensitiveField reports whether a JSON key names a credential
+func isSensitiveField(key string) bool {
+	key = strings.ToLower(key)
+	for _, marker := range sensitiveFieldMarkers {
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *AuditLogger) insertAuditLog(userID, clientID, action, metadata, ipAddress string, statusCode int) {
 	// Parse action to extract resource (e.g., "GET /api/v1/users" -> resource: "/api/v1/users")
 	resource := action
 	status := "success"
+	if statusCode >= 400 {
+		status = "failure"
+	}
 
 	query := `
-		INSERT INTO audit_logs (id, user_id, client_id, action, resource, ip_address, user_agent, metadata, status, created_at)
-		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, ''), $4, $5, $6, $7, $8::jsonb, $9, NOW())
+		INSERT INTO audit_logs (id, user_id, client_id, action, resource, ip_address, user_agent, metadata, status, status_code, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, ''), $4, $5, $6, $7, $8::jsonb, $9, $10, $11)
 	`
 
 	_, err := a.db.Exec(query,
@@ -102,6 +252,8 @@ func (a *AuditLogger) insertAuditLog(userID, clientID, action, requestData, meta
 		"", // user_agent is already in metadata
 		metadata,
 		status,
+		statusCode,
+		clients.Now(),
 	)
 
 	if err != nil {
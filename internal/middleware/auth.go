@@ -53,6 +53,13 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Refresh tokens only work at the token endpoint
+		if !claims.IsAccessToken() {
+			response.Unauthorized(c, "Refresh tokens cannot be used for authentication")
+			c.Abort()
+			return
+		}
+
 		// Check if token is revoked
 		revoked, err := am.redisHelper.IsTokenRevoked(claims.TokenID)
 		if err == nil && revoked {
@@ -61,6 +68,13 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
+		// Tokens from a login session die with it
+		if !am.sessionActive(claims) {
+			response.Unauthorized(c, "Session has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Set user context
 		if claims.UserID != "" {
 			c.Set("user_id", claims.UserID)
@@ -71,11 +85,24 @@ func (am *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		}
 		c.Set("scopes", claims.Scopes)
 		c.Set("token_id", claims.TokenID)
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
 
 		c.Next()
 	}
 }
 
+// sessionActive reports whether the token's login session, if it has one, still exists.
+// Like the revocation check it fails open when Redis is unavailable.
+func (am *AuthMiddleware) sessionActive(claims *utils.JWTClaims) bool {
+	if claims.SessionID == "" {
+		return true
+	}
+	active, err := am.redisHelper.SessionExists(claims.SessionID)
+	return err != nil || active
+}
+
 // OptionalAuth validates JWT if present, but doesn't require it
 func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -93,14 +120,14 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 
 		tokenString := parts[1]
 		claims, err := am.jwtUtil.ValidateToken(tokenString)
-		if err != nil {
+		if err != nil || !claims.IsAccessToken() {
 			c.Next()
 			return
 		}
 
 		// Check if token is revoked
 		revoked, err := am.redisHelper.IsTokenRevoked(claims.TokenID)
-		if err == nil && revoked {
+		if (err == nil && revoked) || !am.sessionActive(claims) {
 			c.Next()
 			return
 		}
@@ -115,6 +142,9 @@ func (am *AuthMiddleware) OptionalAuth() gin.HandlerFunc {
 		}
 		c.Set("scopes", claims.Scopes)
 		c.Set("token_id", claims.TokenID)
+		if claims.SessionID != "" {
+			c.Set("session_id", claims.SessionID)
+		}
 
 		c.Next()
 	}
@@ -153,36 +183,35 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 // RequireScope checks if the token has the required scope
 func RequireScope(requiredScopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		scopesInterface, exists := c.Get("scopes")
-		if !exists {
-			response.Forbidden(c, "Access denied: scope information missing")
-			c.Abort()
+		claims, ok := scopeClaims(c)
+		if !ok {
 			return
 		}
 
-		scopes, ok := scopesInterface.([]string)
-		if !ok {
-			response.Forbidden(c, "Access denied: invalid scope information")
+		// Check if token has any of the required scopes
+		if !hasWildcardScope(claims) && !claims.HasAnyScope(requiredScopes) {
+			response.Forbidden(c, "Access denied: required scope not present")
 			c.Abort()
 			return
 		}
 
-		// Check if token has any of the required scopes
-		hasScope := false
-		for _, required := range requiredScopes {
-			for _, scope := range scopes {
-				if scope == required || scope == "*" {
-					hasScope = true
-					break
-				}
-			}
-			if hasScope {
-				break
-			}
+		c.Next()
+	}
+}
+
+// RequireAllScopes checks that the token carries every one of the required scopes.
+// Routes that both read and modify a user's data on behalf of an OAuth client, such
+// as the storage file update, share and ownership transfer routes, should adopt it
+// with "read" and "write" once third-party clients are allowed to call them.
+func RequireAllScopes(requiredScopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := scopeClaims(c)
+		if !ok {
+			return
 		}
 
-		if !hasScope {
-			response.Forbidden(c, "Access denied: required scope not present")
+		if !hasWildcardScope(claims) && !claims.HasAllScopes(requiredScopes) {
+			response.Forbidden(c, "Access denied: required scopes not present")
 			c.Abort()
 			return
 		}
@@ -191,6 +220,53 @@ func RequireScope(requiredScopes ...string) gin.HandlerFunc {
 	}
 }
 
+// RequireScopeOrRole allows the request when the user holds one of roles, and
+// otherwise requires any of the given scopes, so staff are not bound by client scopes
+func RequireScopeOrRole(requiredScopes []string, roles ...string) gin.HandlerFunc {
+	requireScope := RequireScope(requiredScopes...)
+
+	return func(c *gin.Context) {
+		userRole := c.GetString("role")
+		for _, role := range roles {
+			if userRole == role {
+				c.Next()
+				return
+			}
+		}
+
+		requireScope(c)
+	}
+}
+
+// RequireScopeOrAdmin is a convenience middleware letting admins bypass a scope requirement
+func RequireScopeOrAdmin(requiredScopes ...string) gin.HandlerFunc {
+	return RequireScopeOrRole(requiredScopes, "admin", "superadmin")
+}
+
+// scopeClaims reads the token scopes set by the auth middleware, aborting with 403 when absent
+func scopeClaims(c *gin.Context) (*utils.JWTClaims, bool) {
+	scopesInterface, exists := c.Get("scopes")
+	if !exists {
+		response.Forbidden(c, "Access denied: scope information missing")
+		c.Abort()
+		return nil, false
+	}
+
+	scopes, ok := scopesInterface.([]string)
+	if !ok {
+		response.Forbidden(c, "Access denied: invalid scope information")
+		c.Abort()
+		return nil, false
+	}
+
+	return &utils.JWTClaims{Scopes: scopes}, true
+}
+
+// hasWildcardScope reports whether the token grants every scope
+func hasWildcardScope(claims *utils.JWTClaims) bool {
+	return claims.HasScope("*")
+}
+
 // RequireAdmin is a convenience middleware for admin-only routes
 func RequireAdmin() gin.HandlerFunc {
 	return RequireRole("admin", "superadmin")
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gogin/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAuthRejectsRefreshTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtUtil := utils.NewJWTUtil("test-secret-with-at-least-32-characters", "test")
+	refresh, _, err := jwtUtil.GenerateRefreshToken("user-1", "client-1", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+
+	// The token type is checked before revocation, so no Redis is needed
+	router := gin.New()
+	router.GET("/me", NewAuthMiddleware(jwtUtil, nil).RequireAuth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+refresh)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"runtime"
+
+	"gogin/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// bodyLimitOriginalKey holds the unwrapped request body so route-level limits can replace the global one
+	bodyLimitOriginalKey = "body_limit_original"
+	// bodyLimitSeenKey counts the BodyLimit handlers that have run for the request
+	bodyLimitSeenKey = "body_limit_seen"
+)
+
+// BodyLimit caps the request body at maxBytes, rejecting larger requests with 413.
+// A BodyLimit registered on a route overrides any limit applied earlier in the chain:
+// only the last one in the chain rejects on Content-Length, and its reader replaces
+// the ones before it.
+func BodyLimit(maxBytes int64) gin.HandlerFunc {
+	var name string
+	handler := func(c *gin.Context) {
+		seen := c.GetInt(bodyLimitSeenKey) + 1
+		c.Set(bodyLimitSeenKey, seen)
+
+		if seen == countHandlers(c.HandlerNames(), name) && c.Request.ContentLength > maxBytes {
+			response.PayloadTooLarge(c, fmt.Sprintf("Request body exceeds maximum allowed size of %d bytes", maxBytes))
+			c.Abort()
+			return
+		}
+
+		// Wrap the original body rather than a previously limited one
+		body := c.Request.Body
+		if original, exists := c.Get(bodyLimitOriginalKey); exists {
+			body = original.(io.ReadCloser)
+		} else {
+			c.Set(bodyLimitOriginalKey, body)
+		}
+
+		// Bodies without a declared length are cut off once they pass the limit
+		c.Request.Body = http.MaxBytesReader(c.Writer, body, maxBytes)
+
+		c.Next()
+	}
+	// Every BodyLimit handler shares this name, which is how gin reports it in HandlerNames
+	name = runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+
+	return handler
+}
+
+// countHandlers returns how many handlers in the chain have the given name
+func countHandlers(names []string, name string) int {
+	count := 0
+	for _, n := range names {
+		if n == name {
+			count++
+		}
+	}
+	return count
+}
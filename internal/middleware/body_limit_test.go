@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gogin/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.POST("/echo", BodyLimit(32), func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BindError(c, err)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	large := `{"name":"` + strings.Repeat("x", 64) + `"}`
+	tests := []struct {
+		name          string
+		body          string
+		declareLength bool
+		want          int
+	}{
+		{"small body", `{"name":"ok"}`, true, http.StatusOK},
+		{"declared length over limit", large, true, http.StatusRequestEntityTooLarge},
+		{"undeclared length over limit", large, false, http.StatusRequestEntityTooLarge},
+		{"invalid body within limit", `{}`, false, http.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if !tt.declareLength {
+				// Chunked uploads arrive without a Content-Length
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyLimitRouteOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(BodyLimit(32))
+	readBody := func(c *gin.Context) {
+		if _, err := io.ReadAll(c.Request.Body); err != nil {
+			if response.BodyTooLarge(c, err) {
+				return
+			}
+			response.BadRequest(c, err.Error())
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	router.POST("/upload", BodyLimit(128), readBody)
+	router.POST("/echo", readBody)
+
+	tests := []struct {
+		name          string
+		path          string
+		size          int
+		declareLength bool
+		want          int
+	}{
+		{"override allows body over global limit", "/upload", 64, true, http.StatusOK},
+		{"override allows undeclared body over global limit", "/upload", 64, false, http.StatusOK},
+		{"override rejects body over its limit", "/upload", 256, true, http.StatusRequestEntityTooLarge},
+		{"override rejects undeclared body over its limit", "/upload", 256, false, http.StatusRequestEntityTooLarge},
+		{"global limit applies without override", "/echo", 64, true, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			if !tt.declareLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,40 +1,66 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gogin/internal/config"
+
 	"github.com/gin-gonic/gin"
 )
 
 // CORS middleware handles Cross-Origin Resource Sharing
-func CORS(allowOrigins []string) gin.HandlerFunc {
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	allowMethods := strings.Join(cfg.AllowMethods, ", ")
+	allowHeaders := strings.Join(cfg.AllowHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposeHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
 		// Check if origin is allowed
 		allowed := false
-		for _, allowedOrigin := range allowOrigins {
-			if allowedOrigin == "*" || allowedOrigin == origin {
+		wildcard := false
+		for _, allowedOrigin := range cfg.AllowOrigins {
+			if allowedOrigin == "*" {
+				allowed, wildcard = true, true
+				break
+			}
+			if allowedOrigin == origin {
 				allowed = true
 				break
 			}
 		}
 
-		if allowed {
-			if origin != "" {
+		if origin != "" && allowed {
+			// Browsers reject "*" on credentialed requests, so echo the origin instead
+			if wildcard && !cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Origin", "*")
+			} else {
 				c.Header("Access-Control-Allow-Origin", origin)
-			} else if len(allowOrigins) > 0 {
-				c.Header("Access-Control-Allow-Origin", allowOrigins[0])
+				c.Writer.Header().Add("Vary", "Origin")
 			}
 
-			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, Authorization, X-CSRF-Token, X-Request-ID")
-			c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID")
-			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Max-Age", "43200")
+			if cfg.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			if exposeHeaders != "" {
+				c.Header("Access-Control-Expose-Headers", exposeHeaders)
+			}
 		}
 
 		// Handle preflight requests
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		if c.Request.Method == http.MethodOptions && c.Request.Header.Get("Access-Control-Request-Method") != "" {
+			if origin != "" && allowed {
+				c.Header("Access-Control-Allow-Methods", allowMethods)
+				c.Header("Access-Control-Allow-Headers", allowHeaders)
+				if cfg.MaxAge > 0 {
+					c.Header("Access-Control-Max-Age", maxAge)
+				}
+			}
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 
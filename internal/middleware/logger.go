@@ -1,70 +1,73 @@
 package middleware
 
 import (
-	"fmt"
+	"log/slog"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Logger middleware logs HTTP requests
-func Logger() gin.HandlerFunc {
+// Logger middleware logs HTTP requests as structured JSON at or above logLevel
+func Logger(logLevel string) gin.HandlerFunc {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level: parseLogLevel(logLevel),
+	}))
+
 	return func(c *gin.Context) {
 		// Start timer
 		start := time.Now()
-		path := c.Request.URL.Path
-		raw := c.Request.URL.RawQuery
 
 		// Process request
 		c.Next()
 
-		// Calculate latency
-		latency := time.Since(start)
-
-		// Get request ID
-		requestID, _ := c.Get("request_id")
-
-		// Get client IP
-		clientIP := c.ClientIP()
-
-		// Get status code
 		statusCode := c.Writer.Status()
-
-		// Get method
-		method := c.Request.Method
-
-		// Get error if any
-		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
-
-		// Build query string
-		if raw != "" {
-			path = path + "?" + raw
+		attrs := []slog.Attr{
+			slog.String("request_id", c.GetString("request_id")),
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.String("query", c.Request.URL.RawQuery),
+			slog.Int("status", statusCode),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Int("bytes_written", c.Writer.Size()),
 		}
 
-		// Log format: [TIME] STATUS | LATENCY | IP | METHOD | PATH | REQUEST_ID | ERROR
-		logMessage := fmt.Sprintf("[%s] %d | %13v | %15s | %-7s | %s | %s",
-			time.Now().Format("2006-01-02 15:04:05"),
-			statusCode,
-			latency,
-			clientIP,
-			method,
-			path,
-			requestID,
-		)
+		// Actor identifiers are set by auth middleware further down the chain
+		if userID := c.GetString("user_id"); userID != "" {
+			attrs = append(attrs, slog.String("user_id", userID))
+		}
+		if clientID := c.GetString("client_id"); clientID != "" {
+			attrs = append(attrs, slog.String("client_id", clientID))
+		}
 
-		if errorMessage != "" {
-			logMessage += " | " + errorMessage
+		if errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String(); errorMessage != "" {
+			attrs = append(attrs, slog.String("error", errorMessage))
 		}
 
-		// Color-code by status
+		// Level by status
+		level := slog.LevelInfo
 		if statusCode >= 500 {
-			fmt.Printf("\033[31m%s\033[0m\n", logMessage) // Red for 5xx
+			level = slog.LevelError
 		} else if statusCode >= 400 {
-			fmt.Printf("\033[33m%s\033[0m\n", logMessage) // Yellow for 4xx
-		} else if statusCode >= 300 {
-			fmt.Printf("\033[36m%s\033[0m\n", logMessage) // Cyan for 3xx
-		} else {
-			fmt.Printf("\033[32m%s\033[0m\n", logMessage) // Green for 2xx
+			level = slog.LevelWarn
 		}
+
+		logger.LogAttrs(c.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// parseLogLevel maps a LOG_LEVEL value to a slog level, defaulting to info
+func parseLogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
 }
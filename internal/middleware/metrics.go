@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"gogin/internal/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records request count and latency by method, route template and status.
+// Requests that panic are recorded as 500, the status Recovery answers them with.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		completed := false
+
+		defer func() {
+			// Use the route template rather than the raw path to keep label cardinality bounded
+			route := c.FullPath()
+			if route == "" {
+				route = "unmatched"
+			}
+			status := c.Writer.Status()
+			if !completed {
+				status = http.StatusInternalServerError
+			}
+
+			labels := []string{c.Request.Method, route, strconv.Itoa(status)}
+			metrics.HTTPRequestsTotal.WithLabelValues(labels...).Inc()
+			metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		}()
+
+		c.Next()
+		completed = true
+	}
+}
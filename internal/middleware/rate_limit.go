@@ -2,18 +2,33 @@ package middleware
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
 	"time"
 
 	"gogin/internal/clients"
+	"gogin/internal/metrics"
+	"gogin/internal/modules/redishelper"
 	"gogin/internal/response"
+	"gogin/internal/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
 )
 
-// RateLimiter implements token bucket rate limiting using Redis
+// clientRateLimitCacheTTL is how long a client's configured rate limit is cached
+const clientRateLimitCacheTTL = 5 * time.Minute
+
+// RateLimiter implements sliding window rate limiting using Redis
 type RateLimiter struct {
 	redis       *clients.RedisClient
+	redisHelper *redishelper.RedisHelper
+	db          *clients.Database
+	jwtUtil     *utils.JWTUtil
 	maxRequests int
 	window      time.Duration
 }
@@ -22,58 +37,157 @@ type RateLimiter struct {
 func NewRateLimiter(redis *clients.RedisClient, maxRequests int, window time.Duration) *RateLimiter {
 	return &RateLimiter{
 		redis:       redis,
+		redisHelper: redishelper.NewRedisHelper(redis),
 		maxRequests: maxRequests,
 		window:      window,
 	}
 }
 
+// WithClientLimits enables per-client limits configured on oauth_clients.rate_limit_rps
+func (rl *RateLimiter) WithClientLimits(db *clients.Database) *RateLimiter {
+	rl.db = db
+	return rl
+}
+
+// WithTokenIdentity lets Limit identify callers from their bearer token. Limit runs
+// ahead of the per-module RequireAuth, so without it every caller is keyed by IP.
+func (rl *RateLimiter) WithTokenIdentity(jwtUtil *utils.JWTUtil) *RateLimiter {
+	rl.jwtUtil = jwtUtil
+	return rl
+}
+
+// ClientRateLimitCacheKey returns the cache key holding a client's configured rate limit
+func ClientRateLimitCacheKey(clientID string) string {
+	return fmt.Sprintf("client_rate_limit:%s", clientID)
+}
+
 // Limit returns a middleware that limits requests per IP
 func (rl *RateLimiter) Limit() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get client identifier (IP or user ID if authenticated)
 		identifier := rl.getIdentifier(c)
-
-		// Check rate limit
-		allowed, err := rl.checkLimit(identifier)
-		if err != nil {
-			// Log error but allow request to proceed
-			fmt.Printf("[RATE LIMIT ERROR] %v\n", err)
-			c.Next()
-			return
+		clientID := c.GetString("client_id")
+		if claims := rl.tokenClaims(c); claims != nil {
+			clientID = claims.ClientID
+			if claims.UserID != "" {
+				identifier = fmt.Sprintf("user:%s", claims.UserID)
+			} else if claims.ClientID != "" {
+				identifier = fmt.Sprintf("client:%s", claims.ClientID)
+			}
 		}
 
-		if !allowed {
-			response.TooManyRequests(c, "Rate limit exceeded. Please try again later.")
-			c.Abort()
-			return
+		// Use the calling client's own limit when one is configured
+		maxRequests := rl.maxRequests
+		if clientID != "" {
+			maxRequests = rl.clientLimit(clientID)
 		}
 
+		rl.enforce(c, identifier, maxRequests, rl.window)
+	}
+}
+
+// LimitRoute returns middleware applying its own, usually stricter, limit to a single route.
+// Each caller gets a separate bucket per route, on top of the global limit.
+func (rl *RateLimiter) LimitRoute(maxRequests int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		identifier := fmt.Sprintf("route:%s %s:%s", c.Request.Method, c.FullPath(), rl.getIdentifier(c))
+		rl.enforce(c, identifier, maxRequests, window)
+	}
+}
+
+// enforce records the request against identifier's bucket, sets the quota headers
+// and aborts with 429 once the limit is exceeded
+func (rl *RateLimiter) enforce(c *gin.Context, identifier string, maxRequests int, window time.Duration) {
+	// Check rate limit
+	result, err := slidingWindowLimit(rl.redis, identifier, maxRequests, window)
+	if err != nil {
+		// Log error but allow request to proceed
+		fmt.Printf("[RATE LIMIT ERROR] %v\n", err)
 		c.Next()
+		return
 	}
+
+	// Let clients see their quota on every response
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", strconv.FormatInt(result.Reset.Unix(), 10))
+
+	if !result.Allowed {
+		metrics.RateLimitRejections.WithLabelValues(rateLimitKind(identifier)).Inc()
+		c.Header("Retry-After", strconv.Itoa(result.RetryAfterSeconds()))
+		response.TooManyRequests(c, "Rate limit exceeded. Please try again later.")
+		c.Abort()
+		return
+	}
+
+	c.Next()
 }
 
-// checkLimit checks if the request is within rate limit
-func (rl *RateLimiter) checkLimit(identifier string) (bool, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
+// rateLimitKind returns the identifier's kind (user, client, ip or route) for metrics
+func rateLimitKind(identifier string) string {
+	return strings.SplitN(identifier, ":", 2)[0]
+}
 
-	key := fmt.Sprintf("rate_limit:%s", identifier)
+// RateLimitResult describes the state of a sliding window after a request
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	Reset     time.Time // when the oldest request in the window expires
+}
 
-	// Increment counter
-	count, err := rl.redis.Incr(ctx, key)
-	if err != nil {
-		return false, fmt.Errorf("failed to increment rate limit counter: %w", err)
+// RetryAfterSeconds returns the whole seconds until the window resets, at least 1
+func (r *RateLimitResult) RetryAfterSeconds() int {
+	seconds := int(math.Ceil(time.Until(r.Reset).Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
+
+// tokenClaims returns the claims of a valid bearer access token on the request, or nil.
+// Revocation is left to RequireAuth; a revoked token only buys its owner their own bucket.
+func (rl *RateLimiter) tokenClaims(c *gin.Context) *utils.JWTClaims {
+	if rl.jwtUtil == nil {
+		return nil
 	}
 
-	// Set expiration on first request
-	if count == 1 {
-		if err := rl.redis.Expire(ctx, key, rl.window); err != nil {
-			return false, fmt.Errorf("failed to set rate limit expiration: %w", err)
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil
+	}
+
+	claims, err := rl.jwtUtil.ValidateToken(parts[1])
+	if err != nil || !claims.IsAccessToken() {
+		return nil
+	}
+	return claims
+}
+
+// clientLimit returns the client's configured limit, falling back to the global limit when unset
+func (rl *RateLimiter) clientLimit(clientID string) int {
+	if rl.db == nil {
+		return rl.maxRequests
+	}
+
+	// Cached value of 0 means the client has no limit of its own
+	var limit int
+	cacheKey := ClientRateLimitCacheKey(clientID)
+	if err := rl.redisHelper.CacheGet(cacheKey, &limit); err != nil {
+		var rateLimit sql.NullInt64
+		err := rl.db.QueryRow(`SELECT rate_limit_rps FROM oauth_clients WHERE client_id = $1 AND deleted_at IS NULL`, clientID).Scan(&rateLimit)
+		if err != nil && err != sql.ErrNoRows {
+			fmt.Printf("[RATE LIMIT ERROR] failed to load client rate limit: %v\n", err)
+			return rl.maxRequests
 		}
+		limit = int(rateLimit.Int64)
+		rl.redisHelper.CacheSet(cacheKey, limit, clientRateLimitCacheTTL)
 	}
 
-	// Check if limit exceeded
-	return count <= int64(rl.maxRequests), nil
+	if limit <= 0 {
+		return rl.maxRequests
+	}
+	return limit
 }
 
 // getIdentifier returns a unique identifier for the client
@@ -83,30 +197,65 @@ func (rl *RateLimiter) getIdentifier(c *gin.Context) string {
 		return fmt.Sprintf("user:%s", userID)
 	}
 
+	// Machine-to-machine callers are identified by their client
+	if clientID := c.GetString("client_id"); clientID != "" {
+		return fmt.Sprintf("client:%s", clientID)
+	}
+
 	// Fall back to IP address
 	return fmt.Sprintf("ip:%s", c.ClientIP())
 }
 
-// RateLimitByKey limits requests by a custom key
-func RateLimitByKey(redis *clients.RedisClient, key string, maxRequests int, window time.Duration) (bool, error) {
+// RateLimitByKey records a request against a custom key and returns the window state
+func RateLimitByKey(redis *clients.RedisClient, key string, maxRequests int, window time.Duration) (*RateLimitResult, error) {
+	return slidingWindowLimit(redis, key, maxRequests, window)
+}
+
+// slidingWindowLimit records a request in a sorted-set log of request timestamps
+// and reports whether it fits within maxRequests over the trailing window
+func slidingWindowLimit(redis *clients.RedisClient, identifier string, maxRequests int, window time.Duration) (*RateLimitResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 	defer cancel()
 
-	rateLimitKey := fmt.Sprintf("rate_limit:%s", key)
+	key := fmt.Sprintf("rate_limit:%s", identifier)
+	now := time.Now()
+	member := fmt.Sprintf("%d-%s", now.UnixNano(), uuid.New().String())
 
-	// Increment counter
-	count, err := redis.Incr(ctx, rateLimitKey)
+	// Trim expired entries, log this request and count the window atomically
+	var countCmd *goredis.IntCmd
+	var oldestCmd *goredis.ZSliceCmd
+	_, err := redis.GetClient().TxPipelined(ctx, func(pipe goredis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Add(-window).UnixNano(), 10))
+		pipe.ZAdd(ctx, key, goredis.Z{Score: float64(now.UnixNano()), Member: member})
+		countCmd = pipe.ZCard(ctx, key)
+		oldestCmd = pipe.ZRangeWithScores(ctx, key, 0, 0)
+		pipe.PExpire(ctx, key, window)
+		return nil
+	})
 	if err != nil {
-		return false, fmt.Errorf("failed to increment rate limit counter: %w", err)
+		return nil, fmt.Errorf("failed to update rate limit window: %w", err)
+	}
+
+	count := int(countCmd.Val())
+	reset := now.Add(window)
+	if oldest := oldestCmd.Val(); len(oldest) > 0 {
+		reset = time.Unix(0, int64(oldest[0].Score)).Add(window)
+	}
+
+	result := &RateLimitResult{
+		Allowed:   count <= maxRequests,
+		Limit:     maxRequests,
+		Remaining: maxRequests - count,
+		Reset:     reset,
 	}
 
-	// Set expiration on first request
-	if count == 1 {
-		if err := redis.Expire(ctx, rateLimitKey, window); err != nil {
-			return false, fmt.Errorf("failed to set rate limit expiration: %w", err)
+	if !result.Allowed {
+		// Rejected requests do not consume quota
+		if err := redis.GetClient().ZRem(ctx, key, member).Err(); err != nil {
+			return nil, fmt.Errorf("failed to update rate limit window: %w", err)
 		}
+		result.Remaining = 0
 	}
 
-	// Check if limit exceeded
-	return count <= int64(maxRequests), nil
+	return result, nil
 }
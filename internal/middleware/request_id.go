@@ -1,25 +1,34 @@
 package middleware
 
 import (
+	"regexp"
+
+	"gogin/internal/utils"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-// RequestID middleware adds a unique request ID to each request
+// validRequestID limits client-supplied request IDs to short, log-safe tokens
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID middleware adds a unique request ID to each request. A valid X-Request-ID
+// sent by the client is reused so calls can be traced across services; the ID is echoed
+// in the response header and carried on the request context for outbound calls.
 func RequestID() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Check if request ID is already set (from headers)
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			// Generate a new UUID if not present
+		requestID := c.GetHeader(utils.RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			// Generate a new UUID if missing or malformed
 			requestID = uuid.New().String()
 		}
 
 		// Set request ID in context
 		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(utils.WithRequestID(c.Request.Context(), requestID))
 
 		// Add request ID to response headers
-		c.Header("X-Request-ID", requestID)
+		c.Header(utils.RequestIDHeader, requestID)
 
 		c.Next()
 	}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strconv"
+
+	"gogin/internal/response"
+	"gogin/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SignedURL validates presigned download links carrying `expires` and `signature`
+// query parameters. Requests without a signature pass through unchanged so that
+// regular authentication still applies; a valid signature sets "signed_file_id".
+func SignedURL(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signature := c.Query("signature")
+		if signature == "" {
+			c.Next()
+			return
+		}
+
+		fileID := c.Param("id")
+		expiresAt, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+		if err != nil || !utils.VerifyFileURL(secret, fileID, expiresAt, signature) {
+			response.Forbidden(c, "Invalid or expired download link")
+			c.Abort()
+			return
+		}
+
+		c.Set("signed_file_id", fileID)
+		c.Next()
+	}
+}
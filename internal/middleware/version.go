@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"gogin/internal/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersion resolves the requested API version and sets it in context. An
+// Accept-Version header pins the version regardless of path; otherwise the
+// defaultVersion applies. Unsupported versions are rejected with 400.
+func APIVersion(defaultVersion string, supported []string) gin.HandlerFunc {
+	// The default version is always served
+	supportedSet := map[string]bool{normalizeVersion(defaultVersion): true}
+	for _, version := range supported {
+		supportedSet[normalizeVersion(version)] = true
+	}
+	supportedList := strings.Join(supported, ", ")
+
+	return func(c *gin.Context) {
+		version := normalizeVersion(defaultVersion)
+		if requested := c.GetHeader("Accept-Version"); requested != "" {
+			version = normalizeVersion(requested)
+		}
+
+		if !supportedSet[version] {
+			response.Error(c, http.StatusBadRequest,
+				fmt.Sprintf("Unsupported API version %q. Supported versions: %s", version, supportedList),
+				"UNSUPPORTED_VERSION")
+			c.Abort()
+			return
+		}
+
+		c.Set("version", version)
+		c.Header("API-Version", version)
+
+		c.Next()
+	}
+}
+
+// normalizeVersion accepts "1", "v1" or "V1" and returns "v1"
+func normalizeVersion(version string) string {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version != "" && !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version
+}
@@ -17,6 +17,7 @@ type AuditLog struct {
 	UserAgent   sql.NullString `json:"user_agent,omitempty" db:"user_agent"`
 	Metadata    sql.NullString `json:"metadata,omitempty" db:"metadata"` // JSON
 	Status      string         `json:"status" db:"status"` // success, failure
+	StatusCode  sql.NullInt64  `json:"status_code,omitempty" db:"status_code"`
 	ErrorMsg    sql.NullString `json:"error_msg,omitempty" db:"error_msg"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 }
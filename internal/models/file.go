@@ -17,6 +17,7 @@ type File struct {
 	StorageType string         `json:"storage_type" db:"storage_type"` // local, s3
 	Visibility  string         `json:"visibility" db:"visibility"` // public, private
 	Metadata    sql.NullString `json:"metadata,omitempty" db:"metadata"` // JSON
+	Checksum    sql.NullString `json:"checksum,omitempty" db:"checksum"` // hex SHA-256 of the contents
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 	DeletedAt   sql.NullTime   `json:"deleted_at,omitempty" db:"deleted_at"`
@@ -26,3 +27,12 @@ type File struct {
 func (f *File) IsPublic() bool {
 	return f.Visibility == "public"
 }
+
+// FileShare grants a user read access to a private file
+type FileShare struct {
+	ID        string         `json:"id" db:"id"`
+	FileID    string         `json:"file_id" db:"file_id"`
+	UserID    string         `json:"user_id" db:"user_id"`
+	SharedBy  sql.NullString `json:"shared_by,omitempty" db:"shared_by"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+}
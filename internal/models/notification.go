@@ -7,22 +7,59 @@ import (
 
 // Notification represents a notification record
 type Notification struct {
+	ID             string         `json:"id" db:"id"`
+	UserID         string         `json:"user_id" db:"user_id"`
+	Type           string         `json:"type" db:"type"`
+	Channel        string         `json:"channel" db:"channel"` // email, sms, push, in_app, webhook
+	Title          string         `json:"title" db:"title"`
+	Content        string         `json:"content" db:"content"`
+	HTMLContent    sql.NullString `json:"html_content,omitempty" db:"html_content"`
+	IsRead         bool           `json:"is_read" db:"is_read"`
+	ReadAt         sql.NullTime   `json:"read_at,omitempty" db:"read_at"`
+	Status         string         `json:"status" db:"status"` // scheduled, pending, sent, delivered, failed, skipped
+	Recipient      sql.NullString `json:"recipient,omitempty" db:"recipient"`
+	Subject        sql.NullString `json:"subject,omitempty" db:"subject"`
+	Provider       sql.NullString `json:"provider,omitempty" db:"provider"`
+	ProviderID     sql.NullString `json:"provider_id,omitempty" db:"provider_id"`
+	ProviderStatus sql.NullString `json:"provider_status,omitempty" db:"provider_status"`
+	ErrorMsg       sql.NullString `json:"error_msg,omitempty" db:"error_msg"`
+	Attempts       int            `json:"attempts" db:"attempts"`
+	SentAt         sql.NullTime   `json:"sent_at,omitempty" db:"sent_at"`
+	ScheduledAt    sql.NullTime   `json:"scheduled_at,omitempty" db:"scheduled_at"`
+	DeletedAt      sql.NullTime   `json:"deleted_at,omitempty" db:"deleted_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationPreference records whether a user receives a notification type on a channel
+type NotificationPreference struct {
+	ID        string    `json:"id" db:"id"`
+	UserID    string    `json:"user_id" db:"user_id"`
+	Type      string    `json:"type" db:"type"`
+	Channel   string    `json:"channel" db:"channel"` // email, sms, push, in_app, webhook
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NotificationTemplate is a reusable notification body with {{variable}} placeholders
+type NotificationTemplate struct {
 	ID          string         `json:"id" db:"id"`
-	UserID      string         `json:"user_id" db:"user_id"`
-	Type        string         `json:"type" db:"type"`
-	Channel     string         `json:"channel" db:"channel"` // email, sms, push
+	Name        string         `json:"name" db:"name"`
+	Channel     string         `json:"channel" db:"channel"` // email, sms, push, in_app, webhook
 	Title       string         `json:"title" db:"title"`
 	Content     string         `json:"content" db:"content"`
-	IsRead      bool           `json:"is_read" db:"is_read"`
-	ReadAt      sql.NullTime   `json:"read_at,omitempty" db:"read_at"`
-	Status      string         `json:"status" db:"status"` // pending, sent, failed
-	Recipient   sql.NullString `json:"recipient,omitempty" db:"recipient"`
-	Subject     sql.NullString `json:"subject,omitempty" db:"subject"`
-	Provider    sql.NullString `json:"provider,omitempty" db:"provider"`
-	ProviderID  sql.NullString `json:"provider_id,omitempty" db:"provider_id"`
-	ErrorMsg    sql.NullString `json:"error_msg,omitempty" db:"error_msg"`
-	Attempts    int            `json:"attempts" db:"attempts"`
-	SentAt      sql.NullTime   `json:"sent_at,omitempty" db:"sent_at"`
+	HTMLContent sql.NullString `json:"html_content,omitempty" db:"html_content"`
+	Description sql.NullString `json:"description,omitempty" db:"description"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 }
+
+// UserWebhook is the endpoint a user's webhook notifications are posted to
+type UserWebhook struct {
+	UserID    string    `json:"user_id" db:"user_id"`
+	URL       string    `json:"url" db:"url"`
+	Secret    string    `json:"-" db:"secret"` // encrypted HMAC signing secret
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -17,6 +17,8 @@ type OAuthClient struct {
 	GrantTypes       string         `json:"grant_types" db:"grant_types"` // Space-separated grant types
 	IsPublic         bool           `json:"is_public" db:"is_public"` // Public client (no secret required)
 	IsActive         bool           `json:"is_active" db:"is_active"`
+	RateLimitRPS     sql.NullInt64  `json:"rate_limit_rps,omitempty" db:"rate_limit_rps"` // NULL uses the global limit
+	LastUsedAt       sql.NullTime   `json:"last_used_at,omitempty" db:"last_used_at"`
 	CreatedBy        string         `json:"created_by" db:"created_by"`
 	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
@@ -7,24 +7,35 @@ import (
 
 // Review represents a user review
 type Review struct {
+	ID               string         `json:"id" db:"id"`
+	UserID           string         `json:"user_id" db:"user_id"`
+	ResourceType     string         `json:"resource_type" db:"resource_type"` // product, service, etc.
+	ResourceID       string         `json:"resource_id" db:"resource_id"`
+	Rating           int            `json:"rating" db:"rating"` // 1-5
+	Title            sql.NullString `json:"title,omitempty" db:"title"`
+	Content          string         `json:"content" db:"content"`
+	Status           string         `json:"status" db:"status"` // published, pending, rejected
+	ModeratedBy      sql.NullString `json:"moderated_by,omitempty" db:"moderated_by"`
+	ModeratedAt      sql.NullTime   `json:"moderated_at,omitempty" db:"moderated_at"`
+	ModerationReason sql.NullString `json:"moderation_reason,omitempty" db:"moderation_reason"`
+	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+	DeletedAt        sql.NullTime   `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// ReviewReply represents a resource owner's public response to a review
+type ReviewReply struct {
 	ID          string         `json:"id" db:"id"`
-	UserID      string         `json:"user_id" db:"user_id"`
-	ResourceType string        `json:"resource_type" db:"resource_type"` // product, service, etc.
-	ResourceID  string         `json:"resource_id" db:"resource_id"`
-	Rating      int            `json:"rating" db:"rating"` // 1-5
-	Title       sql.NullString `json:"title,omitempty" db:"title"`
+	ReviewID    string         `json:"review_id" db:"review_id"`
+	ResponderID sql.NullString `json:"responder_id,omitempty" db:"responder_id"`
 	Content     string         `json:"content" db:"content"`
-	Status      string         `json:"status" db:"status"` // pending, approved, rejected
-	ModeratedBy sql.NullString `json:"moderated_by,omitempty" db:"moderated_by"`
-	ModeratedAt sql.NullTime   `json:"moderated_at,omitempty" db:"moderated_at"`
 	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
-	DeletedAt   sql.NullTime   `json:"deleted_at,omitempty" db:"deleted_at"`
 }
 
-// IsApproved returns true if the review is approved
+// IsApproved returns true if the review is published
 func (r *Review) IsApproved() bool {
-	return r.Status == "approved"
+	return r.Status == "published"
 }
 
 // IsPending returns true if the review is pending moderation
@@ -27,3 +27,16 @@ func (s *Setting) IsSystemSetting() bool {
 func (s *Setting) IsUserSetting() bool {
 	return s.UserID.Valid
 }
+
+// SettingHistory represents an immutable change record for a system setting
+type SettingHistory struct {
+	ID           string         `json:"id" db:"id"`
+	SettingKey   string         `json:"setting_key" db:"setting_key"`
+	Action       string         `json:"action" db:"action"` // create, update, delete
+	OldValue     sql.NullString `json:"old_value,omitempty" db:"old_value"`
+	NewValue     sql.NullString `json:"new_value,omitempty" db:"new_value"`
+	ValueChanged bool           `json:"value_changed" db:"value_changed"`
+	IsEncrypted  bool           `json:"is_encrypted" db:"is_encrypted"` // values are redacted when true
+	ChangedBy    sql.NullString `json:"changed_by,omitempty" db:"changed_by"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+}
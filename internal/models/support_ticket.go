@@ -7,30 +7,41 @@ import (
 
 // SupportTicket represents a user support ticket
 type SupportTicket struct {
-	ID          string         `json:"id" db:"id"`
-	UserID      string         `json:"user_id" db:"user_id"`
-	Subject     string         `json:"subject" db:"subject"`
-	Description string         `json:"description" db:"description"`
-	Status      string         `json:"status" db:"status"` // open, in_progress, resolved, closed
-	Priority    string         `json:"priority" db:"priority"` // low, medium, high, urgent
-	Category    sql.NullString `json:"category,omitempty" db:"category"`
-	AssignedTo  sql.NullString `json:"assigned_to,omitempty" db:"assigned_to"`
-	ResolvedAt  sql.NullTime   `json:"resolved_at,omitempty" db:"resolved_at"`
-	ClosedAt    sql.NullTime   `json:"closed_at,omitempty" db:"closed_at"`
-	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
+	ID              string         `json:"id" db:"id"`
+	UserID          string         `json:"user_id" db:"user_id"`
+	Subject         string         `json:"subject" db:"subject"`
+	Description     string         `json:"description" db:"description"`
+	Status          string         `json:"status" db:"status"`     // open, in_progress, resolved, closed
+	Priority        string         `json:"priority" db:"priority"` // low, medium, high, urgent
+	Category        sql.NullString `json:"category,omitempty" db:"category"`
+	AssignedTo      sql.NullString `json:"assigned_to,omitempty" db:"assigned_to"`
+	ResolvedAt      sql.NullTime   `json:"resolved_at,omitempty" db:"resolved_at"`
+	ClosedAt        sql.NullTime   `json:"closed_at,omitempty" db:"closed_at"`
+	FirstResponseAt sql.NullTime   `json:"first_response_at,omitempty" db:"first_response_at"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 // SupportTicketReply represents a reply to a support ticket
 type SupportTicketReply struct {
-	ID        string       `json:"id" db:"id"`
-	TicketID  string       `json:"ticket_id" db:"ticket_id"`
-	UserID    string       `json:"user_id" db:"user_id"`
-	IsStaff   bool         `json:"is_staff" db:"is_staff"`
-	Content   string       `json:"content" db:"content"`
-	CreatedAt time.Time    `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at" db:"updated_at"`
-	DeletedAt sql.NullTime `json:"deleted_at,omitempty" db:"deleted_at"`
+	ID         string       `json:"id" db:"id"`
+	TicketID   string       `json:"ticket_id" db:"ticket_id"`
+	UserID     string       `json:"user_id" db:"user_id"`
+	IsStaff    bool         `json:"is_staff" db:"is_staff"`
+	IsInternal bool         `json:"is_internal" db:"is_internal"` // staff-only note hidden from the ticket owner
+	Content    string       `json:"content" db:"content"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at" db:"updated_at"`
+	DeletedAt  sql.NullTime `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// TicketCategory is an admin-managed category tickets may be filed under
+type TicketCategory struct {
+	ID          string         `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Description sql.NullString `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 // IsOpen returns true if the ticket is open
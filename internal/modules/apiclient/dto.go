@@ -10,6 +10,7 @@ type CreateClientRequest struct {
 	Scopes       []string `json:"scopes" binding:"required"`
 	GrantTypes   []string `json:"grant_types" binding:"required"`
 	IsPublic     bool     `json:"is_public"`
+	RateLimitRPS *int     `json:"rate_limit_rps" binding:"omitempty,min=1"`
 }
 
 // UpdateClientRequest represents a client update request
@@ -19,23 +20,26 @@ type UpdateClientRequest struct {
 	RedirectURIs []string `json:"redirect_uris" binding:"required"`
 	Scopes       []string `json:"scopes" binding:"required"`
 	GrantTypes   []string `json:"grant_types" binding:"required"`
+	RateLimitRPS *int     `json:"rate_limit_rps" binding:"omitempty,min=1"` // admin only; omitted keeps the current limit
 }
 
 // ClientResponse represents a client response
 type ClientResponse struct {
-	ID           string    `json:"id"`
-	ClientID     string    `json:"client_id"`
-	ClientSecret string    `json:"client_secret,omitempty"`
-	Name         string    `json:"name"`
-	Description  string    `json:"description,omitempty"`
-	RedirectURIs []string  `json:"redirect_uris"`
-	Scopes       []string  `json:"scopes"`
-	GrantTypes   []string  `json:"grant_types"`
-	IsPublic     bool      `json:"is_public"`
-	IsActive     bool      `json:"is_active"`
-	CreatedBy    string    `json:"created_by"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           string     `json:"id"`
+	ClientID     string     `json:"client_id"`
+	ClientSecret string     `json:"client_secret,omitempty"`
+	Name         string     `json:"name"`
+	Description  string     `json:"description,omitempty"`
+	RedirectURIs []string   `json:"redirect_uris"`
+	Scopes       []string   `json:"scopes"`
+	GrantTypes   []string   `json:"grant_types"`
+	IsPublic     bool       `json:"is_public"`
+	IsActive     bool       `json:"is_active"`
+	RateLimitRPS *int       `json:"rate_limit_rps,omitempty"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy    string     `json:"created_by"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 // ClientsListResponse represents a paginated list of clients
@@ -46,3 +50,15 @@ type ClientsListResponse struct {
 	Limit      int               `json:"limit"`
 	TotalPages int               `json:"total_pages"`
 }
+
+// ClientStatsResponse represents token issuance statistics for a client over a date range
+type ClientStatsResponse struct {
+	From                    time.Time  `json:"from"`
+	To                      time.Time  `json:"to"`
+	TokensIssued            int        `json:"tokens_issued"`
+	UserTokens              int        `json:"user_tokens"`
+	ClientCredentialsTokens int        `json:"client_credentials_tokens"`
+	DistinctUsers           int        `json:"distinct_users"`
+	ActiveTokens            int        `json:"active_tokens"`
+	LastUsedAt              *time.Time `json:"last_used_at,omitempty"`
+}
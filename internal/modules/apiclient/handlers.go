@@ -3,6 +3,7 @@ package apiclient
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"gogin/internal/response"
 
@@ -25,15 +26,17 @@ import (
 func (m *APIClientModule) createClient(c *gin.Context) {
 	var req CreateClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
+		response.BindError(c, err)
+		return
+	}
+
+	if errors := m.validateClientConfig(req.RedirectURIs, req.Scopes, req.GrantTypes); len(errors) > 0 {
 		response.ValidationError(c, errors)
 		return
 	}
 
 	userID, _ := c.Get("user_id")
-	client, err := m.service.CreateClient(userID.(string), &req)
+	client, err := m.service.CreateClient(c.Request.Context(), userID.(string), &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -58,7 +61,46 @@ func (m *APIClientModule) listClients(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	clients, total, err := m.service.ListClients(page, limit)
+	clients, total, err := m.service.ListClients(c.Request.Context(), "", page, limit)
+	if err != nil {
+		response.InternalError(c, "Failed to list clients")
+		return
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	response.Success(c, http.StatusOK, "Clients retrieved successfully", gin.H{
+		"clients":     clients,
+		"total":       total,
+		"page":        page,
+		"limit":       limit,
+		"total_pages": totalPages,
+	})
+}
+
+// listMyClients lists OAuth clients created by the current user
+// @Summary List My API Clients
+// @Description Get a paginated list of OAuth clients created by the authenticated user
+// @Tags API Clients
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=ClientsListResponse}
+// @Failure 401 {object} response.Response
+// @Router /clients/mine [get]
+func (m *APIClientModule) listMyClients(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	userID, _ := c.Get("user_id")
+	clients, total, err := m.service.ListClients(c.Request.Context(), userID.(string), page, limit)
 	if err != nil {
 		response.InternalError(c, "Failed to list clients")
 		return
@@ -77,7 +119,7 @@ func (m *APIClientModule) listClients(c *gin.Context) {
 
 // getClient retrieves a client by ID
 // @Summary Get API Client
-// @Description Get an OAuth client by ID (admin only)
+// @Description Get an OAuth client by ID (admin or client owner)
 // @Tags API Clients
 // @Produce json
 // @Security BearerAuth
@@ -90,18 +132,23 @@ func (m *APIClientModule) listClients(c *gin.Context) {
 func (m *APIClientModule) getClient(c *gin.Context) {
 	id := c.Param("id")
 
-	client, err := m.service.GetClient(id)
+	client, err := m.service.GetClient(c.Request.Context(), id)
 	if err != nil {
 		response.NotFound(c, "Client not found")
 		return
 	}
 
+	if !m.canManageClient(c, client) {
+		response.Forbidden(c, "Access denied")
+		return
+	}
+
 	response.Success(c, http.StatusOK, "Client retrieved successfully", client)
 }
 
 // updateClient updates a client
 // @Summary Update API Client
-// @Description Update an OAuth client (admin only)
+// @Description Update an OAuth client (admin or client owner). Only admins may set rate_limit_rps.
 // @Tags API Clients
 // @Accept json
 // @Produce json
@@ -119,14 +166,26 @@ func (m *APIClientModule) updateClient(c *gin.Context) {
 
 	var req UpdateClientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
+		response.BindError(c, err)
+		return
+	}
+
+	if errors := m.validateClientConfig(req.RedirectURIs, req.Scopes, req.GrantTypes); len(errors) > 0 {
 		response.ValidationError(c, errors)
 		return
 	}
 
-	client, err := m.service.UpdateClient(id, &req)
+	if !m.authorizeClient(c, id) {
+		return
+	}
+
+	// Owners could otherwise lift their own client past the global limiter
+	if req.RateLimitRPS != nil && !isAdmin(c) {
+		response.Forbidden(c, "Only admins can set rate_limit_rps")
+		return
+	}
+
+	client, err := m.service.UpdateClient(c.Request.Context(), id, &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -137,7 +196,7 @@ func (m *APIClientModule) updateClient(c *gin.Context) {
 
 // deleteClient deletes a client
 // @Summary Delete API Client
-// @Description Delete an OAuth client (admin only)
+// @Description Delete an OAuth client (admin or client owner)
 // @Tags API Clients
 // @Produce json
 // @Security BearerAuth
@@ -150,7 +209,11 @@ func (m *APIClientModule) updateClient(c *gin.Context) {
 func (m *APIClientModule) deleteClient(c *gin.Context) {
 	id := c.Param("id")
 
-	err := m.service.DeleteClient(id)
+	if !m.authorizeClient(c, id) {
+		return
+	}
+
+	err := m.service.DeleteClient(c.Request.Context(), id)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -159,6 +222,69 @@ func (m *APIClientModule) deleteClient(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Client deleted successfully", nil)
 }
 
+// getClientStats returns token issuance statistics for a client
+// @Summary Get API Client Stats
+// @Description Get token issuance counts for an OAuth client over a date range (admin or client owner). Defaults to the last 30 days.
+// @Tags API Clients
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Client ID"
+// @Param from query string false "Start date (YYYY-MM-DD)"
+// @Param to query string false "End date, inclusive (YYYY-MM-DD)"
+// @Success 200 {object} response.Response{data=ClientStatsResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /clients/{id}/stats [get]
+func (m *APIClientModule) getClientStats(c *gin.Context) {
+	id := c.Param("id")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -30)
+	to := today.AddDate(0, 0, 1)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		// Include the whole end day
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		response.BadRequest(c, "from date must not be after to date")
+		return
+	}
+
+	if !m.authorizeClient(c, id) {
+		return
+	}
+
+	stats, err := m.service.GetClientStats(c.Request.Context(), id, from, to)
+	if err != nil {
+		if err.Error() == "client not found" {
+			response.NotFound(c, "Client not found")
+			return
+		}
+		response.InternalError(c, "Failed to get client stats")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Client stats retrieved successfully", stats)
+}
+
 // regenerateSecret regenerates client secret
 // @Summary Regenerate Client Secret
 // @Description Generate a new secret for an OAuth client (admin only)
@@ -174,7 +300,7 @@ func (m *APIClientModule) deleteClient(c *gin.Context) {
 func (m *APIClientModule) regenerateSecret(c *gin.Context) {
 	id := c.Param("id")
 
-	newSecret, err := m.service.RegenerateSecret(id)
+	newSecret, err := m.service.RegenerateSecret(c.Request.Context(), id)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -206,14 +332,11 @@ func (m *APIClientModule) updateStatus(c *gin.Context) {
 		IsActive bool `json:"is_active" binding:"required"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	err := m.service.UpdateStatus(id, req.IsActive)
+	err := m.service.UpdateStatus(c.Request.Context(), id, req.IsActive)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -223,3 +346,33 @@ func (m *APIClientModule) updateStatus(c *gin.Context) {
 		"is_active": req.IsActive,
 	})
 }
+
+// isAdmin reports whether the current user has an admin role
+func isAdmin(c *gin.Context) bool {
+	role := c.GetString("role")
+	return role == "admin" || role == "superadmin"
+}
+
+// canManageClient reports whether the current user is an admin or created the client
+func (m *APIClientModule) canManageClient(c *gin.Context, client *ClientResponse) bool {
+	if isAdmin(c) {
+		return true
+	}
+	return client.CreatedBy == c.GetString("user_id")
+}
+
+// authorizeClient loads the client and aborts with 404/403 unless the current user may manage it
+func (m *APIClientModule) authorizeClient(c *gin.Context, id string) bool {
+	client, err := m.service.GetClient(c.Request.Context(), id)
+	if err != nil {
+		response.NotFound(c, "Client not found")
+		return false
+	}
+
+	if !m.canManageClient(c, client) {
+		response.Forbidden(c, "Access denied")
+		return false
+	}
+
+	return true
+}
@@ -23,7 +23,7 @@ type APIClientModule struct {
 // NewAPIClientModule creates a new API client module
 func NewAPIClientModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *APIClientModule {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	service := NewAPIClientService(db, redisHelper)
 
 	return &APIClientModule{
@@ -40,14 +40,22 @@ func NewAPIClientModule(db *clients.Database, redis *clients.RedisClient, cfg *c
 func (m *APIClientModule) RegisterRoutes(router *gin.RouterGroup) {
 	authMiddleware := middleware.NewAuthMiddleware(m.jwtUtil, m.redisHelper)
 
+	// Self-service routes: admins or the user who created the client
+	owned := router.Group("/clients")
+	owned.Use(authMiddleware.RequireAuth())
+	{
+		owned.GET("/mine", m.listMyClients)
+		owned.GET("/:id", m.getClient)
+		owned.PUT("/:id", m.updateClient)
+		owned.DELETE("/:id", m.deleteClient)
+		owned.GET("/:id/stats", m.getClientStats)
+	}
+
 	clients := router.Group("/clients")
 	clients.Use(authMiddleware.RequireAuth(), middleware.RequireAdmin())
 	{
 		clients.POST("", m.createClient)
 		clients.GET("", m.listClients)
-		clients.GET("/:id", m.getClient)
-		clients.PUT("/:id", m.updateClient)
-		clients.DELETE("/:id", m.deleteClient)
 		clients.POST("/:id/regenerate-secret", m.regenerateSecret)
 		clients.PUT("/:id/status", m.updateStatus)
 	}
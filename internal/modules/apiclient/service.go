@@ -1,7 +1,9 @@
 package apiclient
 
 import (
+	"context"
 	"crypto/rand"
+	"database/sql"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"gogin/internal/clients"
+	"gogin/internal/middleware"
 	"gogin/internal/models"
 	"gogin/internal/modules/redishelper"
 
@@ -30,7 +33,7 @@ func NewAPIClientService(db *clients.Database, redisHelper *redishelper.RedisHel
 }
 
 // CreateClient creates a new OAuth client
-func (s *APIClientService) CreateClient(userID string, req *CreateClientRequest) (*ClientResponse, error) {
+func (s *APIClientService) CreateClient(ctx context.Context, userID string, req *CreateClientRequest) (*ClientResponse, error) {
 	clientID := s.generateClientID()
 	clientSecret := s.generateClientSecret()
 
@@ -41,13 +44,13 @@ func (s *APIClientService) CreateClient(userID string, req *CreateClientRequest)
 	id := uuid.New().String()
 	query := `
 		INSERT INTO oauth_clients
-		(id, client_id, client_secret, name, description, redirect_uris, scopes, grant_types, is_public, is_active, created_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW(), NOW())
+		(id, client_id, client_secret, name, description, redirect_uris, scopes, grant_types, is_public, is_active, rate_limit_rps, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $13)
 		RETURNING created_at, updated_at
 	`
 
 	var createdAt, updatedAt time.Time
-	err := s.db.QueryRow(query,
+	err := s.db.QueryRowContext(ctx, query,
 		id,
 		clientID,
 		clientSecret,
@@ -58,7 +61,9 @@ func (s *APIClientService) CreateClient(userID string, req *CreateClientRequest)
 		grantTypes,
 		req.IsPublic,
 		true,
+		toNullInt(req.RateLimitRPS),
 		userID,
+		clients.Now(),
 	).Scan(&createdAt, &updatedAt)
 
 	if err != nil {
@@ -76,6 +81,7 @@ func (s *APIClientService) CreateClient(userID string, req *CreateClientRequest)
 		GrantTypes:   req.GrantTypes,
 		IsPublic:     req.IsPublic,
 		IsActive:     true,
+		RateLimitRPS: req.RateLimitRPS,
 		CreatedBy:    userID,
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
@@ -83,19 +89,18 @@ func (s *APIClientService) CreateClient(userID string, req *CreateClientRequest)
 }
 
 // GetClient retrieves a client by ID
-func (s *APIClientService) GetClient(id string) (*ClientResponse, error) {
+func (s *APIClientService) GetClient(ctx context.Context, id string) (*ClientResponse, error) {
 	var client models.OAuthClient
 	query := `
-		SELECT id, client_id, client_secret, name, description, redirect_uris,
-		       scopes, grant_types, is_public, is_active, created_by, created_at, updated_at
+		SELECT id, client_id, name, description, redirect_uris,
+		       scopes, grant_types, is_public, is_active, rate_limit_rps, last_used_at, created_by, created_at, updated_at
 		FROM oauth_clients
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	err := s.db.QueryRow(query, id).Scan(
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
 		&client.ID,
 		&client.ClientID,
-		&client.ClientSecret,
 		&client.Name,
 		&client.Description,
 		&client.RedirectURIs,
@@ -103,6 +108,8 @@ func (s *APIClientService) GetClient(id string) (*ClientResponse, error) {
 		&client.GrantTypes,
 		&client.IsPublic,
 		&client.IsActive,
+		&client.RateLimitRPS,
+		&client.LastUsedAt,
 		&client.CreatedBy,
 		&client.CreatedAt,
 		&client.UpdatedAt,
@@ -115,28 +122,39 @@ func (s *APIClientService) GetClient(id string) (*ClientResponse, error) {
 	return s.toClientResponse(&client), nil
 }
 
-// ListClients lists all clients with pagination
-func (s *APIClientService) ListClients(page, limit int) ([]*ClientResponse, int, error) {
+// ListClients lists clients with pagination, optionally only those created by createdBy
+func (s *APIClientService) ListClients(ctx context.Context, createdBy string, page, limit int) ([]*ClientResponse, int, error) {
 	offset := (page - 1) * limit
 
+	where := "WHERE deleted_at IS NULL"
+	args := []interface{}{}
+	argCount := 1
+
+	if createdBy != "" {
+		where += fmt.Sprintf(" AND created_by = $%d", argCount)
+		args = append(args, createdBy)
+		argCount++
+	}
+
 	// Get total count
 	var total int
-	err := s.db.QueryRow("SELECT COUNT(*) FROM oauth_clients WHERE deleted_at IS NULL").Scan(&total)
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM oauth_clients "+where, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	// Get clients
-	query := `
-		SELECT id, client_id, client_secret, name, description, redirect_uris,
-		       scopes, grant_types, is_public, is_active, created_by, created_at, updated_at
+	query := fmt.Sprintf(`
+		SELECT id, client_id, name, description, redirect_uris,
+		       scopes, grant_types, is_public, is_active, rate_limit_rps, last_used_at, created_by, created_at, updated_at
 		FROM oauth_clients
-		WHERE deleted_at IS NULL
+		%s
 		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		LIMIT $%d OFFSET $%d
+	`, where, argCount, argCount+1)
+	args = append(args, limit, offset)
 
-	rows, err := s.db.Query(query, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -148,7 +166,6 @@ func (s *APIClientService) ListClients(page, limit int) ([]*ClientResponse, int,
 		err := rows.Scan(
 			&client.ID,
 			&client.ClientID,
-			&client.ClientSecret,
 			&client.Name,
 			&client.Description,
 			&client.RedirectURIs,
@@ -156,6 +173,8 @@ func (s *APIClientService) ListClients(page, limit int) ([]*ClientResponse, int,
 			&client.GrantTypes,
 			&client.IsPublic,
 			&client.IsActive,
+			&client.RateLimitRPS,
+			&client.LastUsedAt,
 			&client.CreatedBy,
 			&client.CreatedAt,
 			&client.UpdatedAt,
@@ -169,24 +188,26 @@ func (s *APIClientService) ListClients(page, limit int) ([]*ClientResponse, int,
 	return clients, total, nil
 }
 
-// UpdateClient updates a client
-func (s *APIClientService) UpdateClient(id string, req *UpdateClientRequest) (*ClientResponse, error) {
+// UpdateClient updates a client. A nil RateLimitRPS keeps the stored limit.
+func (s *APIClientService) UpdateClient(ctx context.Context, id string, req *UpdateClientRequest) (*ClientResponse, error) {
 	redirectURIsJSON, _ := json.Marshal(req.RedirectURIs)
 	scopes := strings.Join(req.Scopes, " ")
 	grantTypes := strings.Join(req.GrantTypes, " ")
 
 	query := `
 		UPDATE oauth_clients
-		SET name = $1, description = $2, redirect_uris = $3, scopes = $4, grant_types = $5, updated_at = NOW()
-		WHERE id = $6 AND deleted_at IS NULL
+		SET name = $1, description = $2, redirect_uris = $3, scopes = $4, grant_types = $5, rate_limit_rps = COALESCE($6, rate_limit_rps), updated_at = $7
+		WHERE id = $8 AND deleted_at IS NULL
 	`
 
-	result, err := s.db.Exec(query,
+	result, err := s.db.ExecContext(ctx, query,
 		req.Name,
 		req.Description,
 		string(redirectURIsJSON),
 		scopes,
 		grantTypes,
+		toNullInt(req.RateLimitRPS),
+		clients.Now(),
 		id,
 	)
 
@@ -199,13 +220,21 @@ func (s *APIClientService) UpdateClient(id string, req *UpdateClientRequest) (*C
 		return nil, fmt.Errorf("client not found")
 	}
 
-	return s.GetClient(id)
+	client, err := s.GetClient(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop the cached rate limit so the new value applies immediately
+	s.redisHelper.CacheDelete(middleware.ClientRateLimitCacheKey(client.ClientID))
+
+	return client, nil
 }
 
 // DeleteClient soft deletes a client
-func (s *APIClientService) DeleteClient(id string) error {
-	query := `UPDATE oauth_clients SET deleted_at = NOW(), updated_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
-	result, err := s.db.Exec(query, id)
+func (s *APIClientService) DeleteClient(ctx context.Context, id string) error {
+	query := `UPDATE oauth_clients SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -219,11 +248,11 @@ func (s *APIClientService) DeleteClient(id string) error {
 }
 
 // RegenerateSecret generates a new client secret
-func (s *APIClientService) RegenerateSecret(id string) (string, error) {
+func (s *APIClientService) RegenerateSecret(ctx context.Context, id string) (string, error) {
 	newSecret := s.generateClientSecret()
 
-	query := `UPDATE oauth_clients SET client_secret = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
-	result, err := s.db.Exec(query, newSecret, id)
+	query := `UPDATE oauth_clients SET client_secret = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, newSecret, clients.Now(), id)
 	if err != nil {
 		return "", err
 	}
@@ -237,9 +266,9 @@ func (s *APIClientService) RegenerateSecret(id string) (string, error) {
 }
 
 // UpdateStatus updates client status
-func (s *APIClientService) UpdateStatus(id string, isActive bool) error {
-	query := `UPDATE oauth_clients SET is_active = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
-	result, err := s.db.Exec(query, isActive, id)
+func (s *APIClientService) UpdateStatus(ctx context.Context, id string, isActive bool) error {
+	query := `UPDATE oauth_clients SET is_active = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, isActive, clients.Now(), id)
 	if err != nil {
 		return err
 	}
@@ -252,6 +281,43 @@ func (s *APIClientService) UpdateStatus(id string, isActive bool) error {
 	return nil
 }
 
+// GetClientStats returns token issuance statistics for a client within [from, to)
+func (s *APIClientService) GetClientStats(ctx context.Context, id string, from, to time.Time) (*ClientStatsResponse, error) {
+	client, err := s.GetClient(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("client not found")
+	}
+
+	stats := &ClientStatsResponse{
+		From:       from,
+		To:         to,
+		LastUsedAt: client.LastUsedAt,
+	}
+
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(*) FILTER (WHERE user_id IS NOT NULL),
+			COUNT(*) FILTER (WHERE user_id IS NULL),
+			COUNT(DISTINCT user_id),
+			COUNT(*) FILTER (WHERE is_revoked = FALSE AND expires_at > $4)
+		FROM oauth_tokens
+		WHERE client_id = $1 AND created_at >= $2 AND created_at < $3
+	`
+	err = s.db.QueryRowContext(ctx, query, client.ClientID, from, to, clients.Now()).Scan(
+		&stats.TokensIssued,
+		&stats.UserTokens,
+		&stats.ClientCredentialsTokens,
+		&stats.DistinctUsers,
+		&stats.ActiveTokens,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client stats: %w", err)
+	}
+
+	return stats, nil
+}
+
 // Helper functions
 
 func (s *APIClientService) generateClientID() string {
@@ -264,6 +330,8 @@ func (s *APIClientService) generateClientSecret() string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
+// toClientResponse never includes the client secret; it is only returned by
+// CreateClient and RegenerateSecret
 func (s *APIClientService) toClientResponse(client *models.OAuthClient) *ClientResponse {
 	var redirectURIs []string
 	json.Unmarshal([]byte(client.RedirectURIs), &redirectURIs)
@@ -276,7 +344,7 @@ func (s *APIClientService) toClientResponse(client *models.OAuthClient) *ClientR
 		description = client.Description.String
 	}
 
-	return &ClientResponse{
+	resp := &ClientResponse{
 		ID:           client.ID,
 		ClientID:     client.ClientID,
 		Name:         client.Name,
@@ -290,4 +358,23 @@ func (s *APIClientService) toClientResponse(client *models.OAuthClient) *ClientR
 		CreatedAt:    client.CreatedAt,
 		UpdatedAt:    client.UpdatedAt,
 	}
+
+	if client.RateLimitRPS.Valid {
+		rateLimit := int(client.RateLimitRPS.Int64)
+		resp.RateLimitRPS = &rateLimit
+	}
+
+	if client.LastUsedAt.Valid {
+		lastUsedAt := client.LastUsedAt.Time
+		resp.LastUsedAt = &lastUsedAt
+	}
+
+	return resp
+}
+
+func toNullInt(value *int) sql.NullInt64 {
+	if value == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*value), Valid: true}
 }
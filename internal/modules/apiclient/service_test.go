@@ -0,0 +1,97 @@
+package apiclient
+
+import (
+	"context"
+	"testing"
+
+	"gogin/internal/models"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/testutil"
+)
+
+func TestToClientResponseOmitsSecret(t *testing.T) {
+	service := NewAPIClientService(nil, nil)
+
+	resp := service.toClientResponse(&models.OAuthClient{
+		ClientID:     "client",
+		ClientSecret: "stored-secret",
+		RedirectURIs: `["https://example.com/callback"]`,
+		Scopes:       "read",
+		GrantTypes:   "authorization_code",
+	})
+	if resp.ClientSecret != "" {
+		t.Errorf("ClientSecret = %q, want empty", resp.ClientSecret)
+	}
+}
+
+func TestClientSecretOnlyReturnedOnCreate(t *testing.T) {
+	db := testutil.Database(t)
+	userID := testutil.CreateUser(t, db, "admin")
+	service := NewAPIClientService(db, nil)
+
+	created, err := service.CreateClient(context.Background(), userID, &CreateClientRequest{
+		Name:         "Secret test",
+		RedirectURIs: []string{"https://example.com/callback"},
+		Scopes:       []string{"read"},
+		GrantTypes:   []string{"client_credentials"},
+	})
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM oauth_clients WHERE id = $1`, created.ID) })
+	if created.ClientSecret == "" {
+		t.Fatal("CreateClient did not return the secret")
+	}
+
+	fetched, err := service.GetClient(context.Background(), created.ID)
+	if err != nil {
+		t.Fatalf("GetClient: %v", err)
+	}
+	if fetched.ClientSecret != "" {
+		t.Errorf("GetClient ClientSecret = %q, want empty", fetched.ClientSecret)
+	}
+
+	listed, _, err := service.ListClients(context.Background(), userID, 1, 10)
+	if err != nil {
+		t.Fatalf("ListClients: %v", err)
+	}
+	for _, client := range listed {
+		if client.ClientSecret != "" {
+			t.Errorf("ListClients ClientSecret for %s = %q, want empty", client.ID, client.ClientSecret)
+		}
+	}
+}
+
+func TestUpdateClientKeepsRateLimitWhenOmitted(t *testing.T) {
+	db := testutil.Database(t)
+	redisHelper := redishelper.NewRedisHelper(testutil.Redis(t))
+	userID := testutil.CreateUser(t, db, "admin")
+	service := NewAPIClientService(db, redisHelper)
+
+	limit := 50
+	req := &CreateClientRequest{
+		Name:         "Rate limit test",
+		RedirectURIs: []string{"https://example.com/callback"},
+		Scopes:       []string{"read"},
+		GrantTypes:   []string{"client_credentials"},
+		RateLimitRPS: &limit,
+	}
+	created, err := service.CreateClient(context.Background(), userID, req)
+	if err != nil {
+		t.Fatalf("CreateClient: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM oauth_clients WHERE id = $1`, created.ID) })
+
+	updated, err := service.UpdateClient(context.Background(), created.ID, &UpdateClientRequest{
+		Name:         "Renamed",
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		GrantTypes:   req.GrantTypes,
+	})
+	if err != nil {
+		t.Fatalf("UpdateClient: %v", err)
+	}
+	if updated.RateLimitRPS == nil || *updated.RateLimitRPS != limit {
+		t.Errorf("RateLimitRPS = %v, want %d", updated.RateLimitRPS, limit)
+	}
+}
@@ -0,0 +1,67 @@
+package apiclient
+
+import (
+	"fmt"
+	"net/url"
+
+	"gogin/internal/response"
+)
+
+// allowedGrantTypes lists the OAuth 2.0 grant types a client may be registered for
+var allowedGrantTypes = map[string]bool{
+	"authorization_code": true,
+	"client_credentials": true,
+	"refresh_token":      true,
+}
+
+// validateClientConfig checks redirect URIs, scopes and grant types, returning one error per invalid field value
+func (m *APIClientModule) validateClientConfig(redirectURIs, scopes, grantTypes []string) []response.ResponseError {
+	var errors []response.ResponseError
+
+	for i, redirectURI := range redirectURIs {
+		if err := m.validateRedirectURI(redirectURI); err != "" {
+			errors = append(errors, response.NewError("INVALID_REDIRECT_URI", err, fmt.Sprintf("redirect_uris[%d]", i)))
+		}
+	}
+
+	allowedScopes := make(map[string]bool, len(m.config.OAuth.AllowedScopes))
+	for _, scope := range m.config.OAuth.AllowedScopes {
+		allowedScopes[scope] = true
+	}
+	for i, scope := range scopes {
+		if !allowedScopes[scope] {
+			errors = append(errors, response.NewError("INVALID_SCOPE", fmt.Sprintf("Unknown scope: %q", scope), fmt.Sprintf("scopes[%d]", i)))
+		}
+	}
+
+	for i, grantType := range grantTypes {
+		if !allowedGrantTypes[grantType] {
+			errors = append(errors, response.NewError("INVALID_GRANT_TYPE", fmt.Sprintf("Unsupported grant type: %q", grantType), fmt.Sprintf("grant_types[%d]", i)))
+		}
+	}
+
+	return errors
+}
+
+// validateRedirectURI requires absolute HTTPS URLs, allowing plain HTTP to localhost in development
+func (m *APIClientModule) validateRedirectURI(redirectURI string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return "Redirect URI must be an absolute URL"
+	}
+	if u.Fragment != "" {
+		return "Redirect URI must not contain a fragment"
+	}
+
+	switch u.Scheme {
+	case "https":
+		return ""
+	case "http":
+		host := u.Hostname()
+		if m.config.IsDevelopment() && (host == "localhost" || host == "127.0.0.1" || host == "::1") {
+			return ""
+		}
+	}
+
+	return "Redirect URI must use HTTPS"
+}
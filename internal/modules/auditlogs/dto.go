@@ -0,0 +1,40 @@
+package auditlogs
+
+import (
+	"time"
+
+	"gogin/internal/response"
+)
+
+// AuditLogFilter holds the optional filters for listing audit logs
+type AuditLogFilter struct {
+	UserID       string
+	ClientID     string
+	ActionPrefix string
+	Status       string
+	From         *time.Time // inclusive
+	To           *time.Time // exclusive
+}
+
+// AuditLogResponse represents a single audit log entry
+type AuditLogResponse struct {
+	ID         string                 `json:"id"`
+	UserID     *string                `json:"user_id,omitempty"`
+	ClientID   *string                `json:"client_id,omitempty"`
+	Action     string                 `json:"action"`
+	Resource   string                 `json:"resource"`
+	ResourceID *string                `json:"resource_id,omitempty"`
+	IPAddress  string                 `json:"ip_address"`
+	UserAgent  *string                `json:"user_agent,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Status     string                 `json:"status"`
+	StatusCode *int                   `json:"status_code,omitempty"`
+	ErrorMsg   *string                `json:"error_msg,omitempty"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// AuditLogsListResponse represents a paginated list of audit logs
+type AuditLogsListResponse struct {
+	AuditLogs []*AuditLogResponse `json:"audit_logs"`
+	response.PaginationMeta
+}
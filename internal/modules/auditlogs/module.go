@@ -0,0 +1,39 @@
+package auditlogs
+
+import (
+	"gogin/internal/clients"
+	"gogin/internal/config"
+	"gogin/internal/middleware"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditLogsModule struct {
+	service        *AuditLogsService
+	authMiddleware *middleware.AuthMiddleware
+	config         *config.Config
+}
+
+// NewAuditLogsModule creates a new instance of the audit logs module
+func NewAuditLogsModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *AuditLogsModule {
+	redisHelper := redishelper.NewRedisHelper(redis)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
+
+	return &AuditLogsModule{
+		service:        NewAuditLogsService(db),
+		authMiddleware: middleware.NewAuthMiddleware(jwtUtil, redisHelper),
+		config:         cfg,
+	}
+}
+
+// RegisterRoutes registers audit log routes (admin only)
+func (m *AuditLogsModule) RegisterRoutes(router *gin.RouterGroup) {
+	auditLogs := router.Group("/audit-logs")
+	auditLogs.Use(m.authMiddleware.RequireAuth(), middleware.RequireAdmin())
+	{
+		auditLogs.GET("", m.listAuditLogs)
+		auditLogs.GET("/export", m.exportAuditLogs)
+	}
+}
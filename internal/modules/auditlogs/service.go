@@ -0,0 +1,303 @@
+package auditlogs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gogin/internal/clients"
+	"gogin/internal/models"
+	"gogin/internal/response"
+)
+
+type AuditLogsService struct {
+	db *clients.Database
+}
+
+func NewAuditLogsService(db *clients.Database) *AuditLogsService {
+	return &AuditLogsService{db: db}
+}
+
+// toAuditLogResponse converts a models.AuditLog to AuditLogResponse
+func (s *AuditLogsService) toAuditLogResponse(log *models.AuditLog) *AuditLogResponse {
+	response := &AuditLogResponse{
+		ID:        log.ID,
+		Action:    log.Action,
+		Resource:  log.Resource,
+		IPAddress: log.IPAddress,
+		Status:    log.Status,
+		CreatedAt: log.CreatedAt,
+	}
+
+	if log.UserID.Valid {
+		userID := log.UserID.String
+		response.UserID = &userID
+	}
+
+	if log.ClientID.Valid {
+		clientID := log.ClientID.String
+		response.ClientID = &clientID
+	}
+
+	if log.ResourceID.Valid {
+		resourceID := log.ResourceID.String
+		response.ResourceID = &resourceID
+	}
+
+	if log.UserAgent.Valid && log.UserAgent.String != "" {
+		userAgent := log.UserAgent.String
+		response.UserAgent = &userAgent
+	}
+
+	if log.Metadata.Valid && log.Metadata.String != "" {
+		var metadata map[string]interface{}
+		if err := json.Unmarshal([]byte(log.Metadata.String), &metadata); err == nil {
+			response.Metadata = metadata
+		}
+	}
+
+	if log.StatusCode.Valid {
+		statusCode := int(log.StatusCode.Int64)
+		response.StatusCode = &statusCode
+	}
+
+	if log.ErrorMsg.Valid {
+		errorMsg := log.ErrorMsg.String
+		response.ErrorMsg = &errorMsg
+	}
+
+	return response
+}
+
+// ListAuditLogs lists audit logs matching the filter, newest first
+func (s *AuditLogsService) ListAuditLogs(ctx context.Context, filter *AuditLogFilter, pagination response.Pagination) (*AuditLogsListResponse, error) {
+	conditions, args := filterConditions(filter)
+	countQuery := `SELECT COUNT(*) FROM audit_logs WHERE 1=1` + conditions
+	query := `
+		SELECT id, user_id, client_id, action, resource, resource_id, ip_address, user_agent, metadata, status, status_code, error_msg, created_at
+		FROM audit_logs
+		WHERE 1=1` + conditions
+	argCount := len(args)
+
+	// Count total
+	var total int
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	// Query audit logs
+	argCount++
+	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, argCount, argCount+1)
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	var auditLogs []*AuditLogResponse
+	for rows.Next() {
+		var log models.AuditLog
+		if err := rows.Scan(
+			&log.ID,
+			&log.UserID,
+			&log.ClientID,
+			&log.Action,
+			&log.Resource,
+			&log.ResourceID,
+			&log.IPAddress,
+			&log.UserAgent,
+			&log.Metadata,
+			&log.Status,
+			&log.StatusCode,
+			&log.ErrorMsg,
+			&log.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		auditLogs = append(auditLogs, s.toAuditLogResponse(&log))
+	}
+
+	if auditLogs == nil {
+		auditLogs = []*AuditLogResponse{}
+	}
+
+	return &AuditLogsListResponse{
+		AuditLogs:      auditLogs,
+		PaginationMeta: pagination.Meta(total),
+	}, nil
+}
+
+// filterConditions renders the filter as " AND ..." conditions and their arguments.
+// Every filter is a plain column comparison so the audit_logs indexes apply.
+func filterConditions(filter *AuditLogFilter) (string, []interface{}) {
+	var conditions strings.Builder
+	args := []interface{}{}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		fmt.Fprintf(&conditions, condition, len(args))
+	}
+
+	if filter.UserID != "" {
+		add(` AND user_id = $%d`, filter.UserID)
+	}
+	if filter.ClientID != "" {
+		add(` AND client_id = $%d`, filter.ClientID)
+	}
+	if filter.ActionPrefix != "" {
+		// Anchored prefix match can use the pattern-ops index on action
+		add(` AND action LIKE $%d`, escapeLikePattern(filter.ActionPrefix)+"%")
+	}
+	if filter.Status != "" {
+		add(` AND status = $%d`, filter.Status)
+	}
+	if filter.From != nil {
+		add(` AND created_at >= $%d`, *filter.From)
+	}
+	if filter.To != nil {
+		add(` AND created_at < $%d`, *filter.To)
+	}
+
+	return conditions.String(), args
+}
+
+// escapeLikePattern escapes LIKE wildcards so user input is matched literally
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
+// exportBatchSize is how many rows each FETCH pulls from the export cursor
+const exportBatchSize = 1000
+
+// exportColumns is the CSV header row of an audit log export
+var exportColumns = []string{
+	"id", "created_at", "user_id", "client_id", "action", "resource", "resource_id",
+	"ip_address", "user_agent", "status", "status_code", "error_msg", "metadata",
+}
+
+// ExportAuditLogs writes the audit logs matching the filter to out as CSV, newest
+// first. Rows are read in batches through a server-side cursor and flushed after
+// each batch, so memory use doesn't grow with the size of the export. Nothing is
+// written until the first batch has been read, so an error returned before then
+// leaves out untouched.
+func (s *AuditLogsService) ExportAuditLogs(ctx context.Context, filter *AuditLogFilter, out io.Writer) error {
+	// Cursors only live inside a transaction
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	conditions, args := filterConditions(filter)
+	query := `
+		DECLARE audit_export NO SCROLL CURSOR FOR
+		SELECT id, user_id, client_id, action, resource, resource_id, ip_address, user_agent, metadata, status, status_code, error_msg, created_at
+		FROM audit_logs
+		WHERE 1=1` + conditions + `
+		ORDER BY created_at DESC, id DESC
+	`
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to open export cursor: %w", err)
+	}
+
+	writer := csv.NewWriter(out)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	fetch := fmt.Sprintf(`FETCH %d FROM audit_export`, exportBatchSize)
+	for {
+		fetched, err := s.writeExportBatch(ctx, tx, fetch, writer)
+		if err != nil {
+			return err
+		}
+
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return fmt.Errorf("failed to write export: %w", err)
+		}
+		if flusher, ok := out.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		if fetched < exportBatchSize {
+			return nil
+		}
+	}
+}
+
+// writeExportBatch fetches the next batch from the export cursor and writes it as CSV rows
+func (s *AuditLogsService) writeExportBatch(ctx context.Context, tx *sql.Tx, fetch string, writer *csv.Writer) (int, error) {
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		var log models.AuditLog
+		if err := rows.Scan(
+			&log.ID,
+			&log.UserID,
+			&log.ClientID,
+			&log.Action,
+			&log.Resource,
+			&log.ResourceID,
+			&log.IPAddress,
+			&log.UserAgent,
+			&log.Metadata,
+			&log.Status,
+			&log.StatusCode,
+			&log.ErrorMsg,
+			&log.CreatedAt,
+		); err != nil {
+			return 0, fmt.Errorf("failed to scan audit log: %w", err)
+		}
+		fetched++
+
+		statusCode := ""
+		if log.StatusCode.Valid {
+			statusCode = strconv.FormatInt(log.StatusCode.Int64, 10)
+		}
+
+		record := []string{
+			log.ID,
+			log.CreatedAt.UTC().Format(time.RFC3339),
+			log.UserID.String,
+			csvSafe(log.ClientID.String),
+			csvSafe(log.Action),
+			csvSafe(log.Resource),
+			csvSafe(log.ResourceID.String),
+			csvSafe(log.IPAddress),
+			csvSafe(log.UserAgent.String),
+			log.Status,
+			statusCode,
+			csvSafe(log.ErrorMsg.String),
+			csvSafe(log.Metadata.String),
+		}
+		if err := writer.Write(record); err != nil {
+			return 0, fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+
+	return fetched, rows.Err()
+}
+
+// csvSafe stops spreadsheet applications from evaluating a client-controlled cell as a formula
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gogin/internal/response"
+	"gogin/internal/workers"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,65 +24,96 @@ func (m *CoreModule) healthCheck(c *gin.Context) {
 	})
 }
 
+// liveness reports that the process is running, without checking dependencies
+// @Summary Liveness probe
+// @Description Always succeeds while the process is running
+// @Tags Core
+// @Produce json
+// @Success 200 {object} response.Response{data=object{status=string}}
+// @Router /healthz [get]
+func (m *CoreModule) liveness(c *gin.Context) {
+	response.Success(c, http.StatusOK, "OK", gin.H{
+		"status": "alive",
+	})
+}
+
+// readiness reports whether the database, Redis and NATS are reachable
+// @Summary Readiness probe
+// @Description Succeeds only when the database, Redis and NATS are healthy; slow but reachable dependencies still count as ready
+// @Tags Core
+// @Produce json
+// @Success 200 {object} response.Response{data=object{status=string,services=object}}
+// @Failure 503 {object} response.Response{data=object{status=string,services=object}}
+// @Router /readyz [get]
+func (m *CoreModule) readiness(c *gin.Context) {
+	_, dbErr := m.db.HealthCheck()
+	_, redisErr := m.redis.HealthCheck()
+	_, natsErr := m.nats.HealthCheck()
+
+	statusCode := http.StatusOK
+	status := "ready"
+	if dbErr != nil || redisErr != nil || natsErr != nil {
+		statusCode = http.StatusServiceUnavailable
+		status = "not_ready"
+	}
+
+	response.Success(c, statusCode, "Readiness", gin.H{
+		"status": status,
+		"services": gin.H{
+			"database": dbErr == nil,
+			"redis":    redisErr == nil,
+			"nats":     natsErr == nil,
+		},
+	})
+}
+
 // status returns detailed system status
 // @Summary System status
-// @Description Get detailed system status including database, Redis, and NATS health
+// @Description Get detailed system status including database, Redis, and NATS health and latency
 // @Tags Core
 // @Produce json
 // @Success 200 {object} response.Response{data=object{status=string,timestamp=string,services=object,app=object}}
 // @Failure 503 {object} response.Response{data=object{status=string,timestamp=string,services=object,app=object}}
 // @Router /status [get]
 func (m *CoreModule) status(c *gin.Context) {
-	// Check database health
-	dbHealthy := true
-	if err := m.db.HealthCheck(); err != nil {
-		dbHealthy = false
-	}
-
-	// Check Redis health
-	redisHealthy := true
-	if err := m.redis.HealthCheck(); err != nil {
-		redisHealthy = false
-	}
-
-	// Check NATS health
-	natsHealthy := true
-	if err := m.nats.HealthCheck(); err != nil {
-		natsHealthy = false
-	}
+	// Check each dependency, timing the round trip
+	dbStatus := m.serviceStatus(m.db.HealthCheck())
+	redisStatus := m.serviceStatus(m.redis.HealthCheck())
+	natsStatus := m.serviceStatus(m.nats.HealthCheck())
 
 	// Get database stats
 	dbStats := m.db.Stats()
+	dbStatus["stats"] = gin.H{
+		"open_connections": dbStats.OpenConnections,
+		"in_use":           dbStats.InUse,
+		"idle":             dbStats.Idle,
+	}
 
-	// Overall status
-	overallStatus := "healthy"
-	if !dbHealthy || !redisHealthy || !natsHealthy {
-		overallStatus = "degraded"
+	// Overall status is the worst of the individual services
+	overallStatus := statusHealthy
+	for _, service := range []gin.H{dbStatus, redisStatus, natsStatus} {
+		switch service["status"] {
+		case statusUnhealthy:
+			overallStatus = statusUnhealthy
+		case statusDegraded:
+			if overallStatus == statusHealthy {
+				overallStatus = statusDegraded
+			}
+		}
 	}
 
 	statusCode := http.StatusOK
-	if overallStatus == "degraded" {
+	if overallStatus == statusUnhealthy {
 		statusCode = http.StatusServiceUnavailable
 	}
 
 	response.Success(c, statusCode, "System status", gin.H{
-		"status": overallStatus,
+		"status":    overallStatus,
 		"timestamp": time.Now().UTC().Format(time.RFC3339),
 		"services": gin.H{
-			"database": gin.H{
-				"healthy": dbHealthy,
-				"stats": gin.H{
-					"open_connections": dbStats.OpenConnections,
-					"in_use":          dbStats.InUse,
-					"idle":            dbStats.Idle,
-				},
-			},
-			"redis": gin.H{
-				"healthy": redisHealthy,
-			},
-			"nats": gin.H{
-				"healthy": natsHealthy,
-			},
+			"database": dbStatus,
+			"redis":    redisStatus,
+			"nats":     natsStatus,
 		},
 		"app": gin.H{
 			"name":    m.config.App.Name,
@@ -90,3 +122,48 @@ func (m *CoreModule) status(c *gin.Context) {
 		},
 	})
 }
+
+// Service health states reported by /status
+const (
+	statusHealthy   = "healthy"
+	statusDegraded  = "degraded"
+	statusUnhealthy = "unhealthy"
+)
+
+// serviceStatus describes a single dependency from its health check result
+func (m *CoreModule) serviceStatus(latency time.Duration, err error) gin.H {
+	status := statusHealthy
+	if err != nil {
+		status = statusUnhealthy
+	} else if threshold := m.config.App.HealthDegradedThreshold; threshold > 0 && latency > threshold {
+		// Slow but up: warn before it becomes an outage
+		status = statusDegraded
+	}
+
+	return gin.H{
+		"healthy":    err == nil,
+		"status":     status,
+		"latency_ms": float64(latency.Microseconds()) / 1000,
+	}
+}
+
+// workerStatus reports the state of the background workers
+// @Summary Background worker status
+// @Description Get each background worker's running state, processed and failed counts, and last error (admin only). Counts are per API instance since startup.
+// @Tags Core
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=object{workers=[]workers.WorkerStatus}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /workers/status [get]
+func (m *CoreModule) workerStatus(c *gin.Context) {
+	statuses := []workers.WorkerStatus{}
+	if m.workers != nil {
+		statuses = m.workers.Status()
+	}
+
+	response.Success(c, http.StatusOK, "Worker status", gin.H{
+		"workers": statuses,
+	})
+}
@@ -3,6 +3,10 @@ package core
 import (
 	"gogin/internal/clients"
 	"gogin/internal/config"
+	"gogin/internal/middleware"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/utils"
+	"gogin/internal/workers"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,21 +17,41 @@ type CoreModule struct {
 	redis  *clients.RedisClient
 	nats   *clients.NATSClient
 	config *config.Config
+	// workers is optional; without it /workers/status reports no workers
+	workers        *workers.WorkerManager
+	authMiddleware *middleware.AuthMiddleware
 }
 
 // NewCoreModule creates a new core module
 func NewCoreModule(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, cfg *config.Config) *CoreModule {
 	return &CoreModule{
-		db:     db,
-		redis:  redis,
-		nats:   nats,
-		config: cfg,
+		db:             db,
+		redis:          redis,
+		nats:           nats,
+		config:         cfg,
+		authMiddleware: middleware.NewAuthMiddleware(utils.NewJWTUtilFromConfig(cfg.OAuth), redishelper.NewRedisHelper(redis)),
 	}
 }
 
+// WithWorkers reports the status of the given background workers at /workers/status
+func (m *CoreModule) WithWorkers(manager *workers.WorkerManager) *CoreModule {
+	m.workers = manager
+	return m
+}
+
 // RegisterRoutes registers core routes
 func (m *CoreModule) RegisterRoutes(router *gin.RouterGroup) {
 	// Health check endpoints
 	router.GET("/health", m.healthCheck)
 	router.GET("/status", m.status)
+
+	// Background worker status (admin only)
+	router.GET("/workers/status", m.authMiddleware.RequireAuth(), middleware.RequireAdmin(), m.workerStatus)
+}
+
+// RegisterProbeRoutes registers the Kubernetes liveness and readiness probes at the
+// site root, outside the rate-limited API group
+func (m *CoreModule) RegisterProbeRoutes(router *gin.Engine) {
+	router.GET("/healthz", m.liveness)
+	router.GET("/readyz", m.readiness)
 }
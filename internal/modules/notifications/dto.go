@@ -1,6 +1,10 @@
 package notifications
 
-import "time"
+import (
+	"time"
+
+	"gogin/internal/response"
+)
 
 // NotificationResponse represents a notification response
 type NotificationResponse struct {
@@ -13,6 +17,7 @@ type NotificationResponse struct {
 	IsRead    bool      `json:"is_read"`
 	ReadAt    *time.Time `json:"read_at,omitempty"`
 	Status    string    `json:"status"`
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -20,11 +25,27 @@ type NotificationResponse struct {
 // NotificationsListResponse represents a paginated list of notifications
 type NotificationsListResponse struct {
 	Notifications []*NotificationResponse `json:"notifications"`
-	Total         int                     `json:"total"`
 	Unread        int                     `json:"unread"`
-	Page          int                     `json:"page"`
-	Limit         int                     `json:"limit"`
-	TotalPages    int                     `json:"total_pages"`
+	// TypeCounts is only included when requested with counts=true
+	TypeCounts map[string]NotificationTypeCount `json:"type_counts,omitempty"`
+	response.PaginationMeta
+}
+
+// NotificationTypeCount counts a user's notifications of one type
+type NotificationTypeCount struct {
+	Total  int `json:"total"`
+	Unread int `json:"unread"`
+}
+
+// UnreadCountResponse reports how many of a user's notifications are unread
+type UnreadCountResponse struct {
+	Unread int `json:"unread"`
+}
+
+// DeadLetterStatsResponse reports the size of the notification dead-letter queue
+type DeadLetterStatsResponse struct {
+	Subject  string `json:"subject"`
+	Messages uint64 `json:"messages"`
 }
 
 // TestEmailRequest represents a test email request
@@ -42,9 +63,129 @@ type TestSMSRequest struct {
 
 // SendNotificationRequest represents a notification send request
 type SendNotificationRequest struct {
+	ID      string `json:"id,omitempty"` // Persisted notification ID, set when queued for delivery
+	// RequestID traces a queued job back to the API request that sent it
+	RequestID string `json:"request_id,omitempty"`
 	UserID  string `json:"user_id" binding:"required"`
 	Type    string `json:"type" binding:"required"`
-	Channel string `json:"channel" binding:"required,oneof=email sms push"`
+	Channel string `json:"channel" binding:"required,oneof=email sms push in_app webhook"`
 	Title   string `json:"title" binding:"required"`
 	Content string `json:"content" binding:"required"`
+	// HTMLContent is the rendered HTML body for email, when sent from a template
+	HTMLContent string `json:"html_content,omitempty"`
+	// ScheduledAt delays delivery until the given time when it is in the future
+	ScheduledAt *time.Time `json:"scheduled_at,omitempty"`
+}
+
+// NotificationPreferenceRequest represents a single preference update
+type NotificationPreferenceRequest struct {
+	Type    string `json:"type" binding:"required"`
+	Channel string `json:"channel" binding:"required,oneof=email sms push in_app webhook"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
+
+// UpdatePreferencesRequest represents a batch of preference updates
+type UpdatePreferencesRequest struct {
+	Preferences []NotificationPreferenceRequest `json:"preferences" binding:"required,min=1,dive"`
+}
+
+// NotificationPreferenceResponse represents a stored notification preference
+type NotificationPreferenceResponse struct {
+	Type      string    `json:"type"`
+	Channel   string    `json:"channel"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateTemplateRequest represents a request to create a notification template
+type CreateTemplateRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Channel     string `json:"channel" binding:"required,oneof=email sms push in_app webhook"`
+	Title       string `json:"title" binding:"required"`
+	Content     string `json:"content" binding:"required"`
+	HTMLContent string `json:"html_content"`
+	Description string `json:"description"`
+}
+
+// UpdateTemplateRequest represents a request to update a notification template
+type UpdateTemplateRequest struct {
+	Title       string  `json:"title"`
+	Content     string  `json:"content"`
+	HTMLContent *string `json:"html_content"`
+	Description *string `json:"description"`
+}
+
+// TemplateResponse represents a notification template
+type TemplateResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Channel     string    `json:"channel"`
+	Title       string    `json:"title"`
+	Content     string    `json:"content"`
+	HTMLContent *string   `json:"html_content,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// SendTemplateRequest represents a request to send a template to a user. Variables
+// override the user fields (first_name, last_name, email) of the same name.
+type SendTemplateRequest struct {
+	UserID    string            `json:"user_id" binding:"required,uuid"`
+	Template  string            `json:"template" binding:"required,max=100"`
+	Variables map[string]string `json:"variables"`
+}
+
+// TemplatesListResponse represents a paginated list of templates
+type TemplatesListResponse struct {
+	Templates  []*TemplateResponse `json:"templates"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	TotalPages int                 `json:"total_pages"`
+}
+
+// BroadcastNotificationRequest represents an admin broadcast. It targets the given
+// users, every active user with the given role, or all active users when neither is set.
+type BroadcastNotificationRequest struct {
+	UserIDs []string `json:"user_ids" binding:"omitempty,max=1000,dive,uuid"`
+	Role    string   `json:"role" binding:"omitempty,oneof=admin user"`
+	Type    string   `json:"type" binding:"required"`
+	Channel string   `json:"channel" binding:"required,oneof=email sms push in_app webhook"`
+	Title   string   `json:"title" binding:"required"`
+	Content string   `json:"content" binding:"required"`
+}
+
+// BulkSendResponse reports the outcome of sending one notification to many users
+type BulkSendResponse struct {
+	Recipients int `json:"recipients"`
+	Queued     int `json:"queued"`
+	Skipped    int `json:"skipped"`
+	Unknown    int `json:"unknown"` // IDs with no active user
+	Failed     int `json:"failed"`
+}
+
+// SetWebhookRequest represents a request to register the user's notification webhook.
+// The URL must use https and point at a public address.
+type SetWebhookRequest struct {
+	URL string `json:"url" binding:"required,url,max=2048"`
+}
+
+// WebhookResponse represents a user's notification webhook. Secret is only returned
+// when the webhook is registered, since it cannot be read back afterwards.
+type WebhookResponse struct {
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookPayload is the JSON body posted to a user's webhook for each notification
+type WebhookPayload struct {
+	ID      string    `json:"id"`
+	UserID  string    `json:"user_id"`
+	Type    string    `json:"type"`
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+	SentAt  time.Time `json:"sent_at"`
 }
@@ -1,8 +1,11 @@
 package notifications
 
 import (
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"gogin/internal/response"
 
@@ -15,34 +18,234 @@ import (
 // @Tags Notifications
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Opaque cursor from a previous next_cursor; preferred over page for large result sets"
+// @Param page query int false "Page number, ignored when cursor is set" default(1)
 // @Param limit query int false "Items per page" default(20)
+// @Param counts query bool false "Include total and unread counts per notification type"
 // @Success 200 {object} response.Response{data=NotificationsListResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Router /notifications [get]
 func (m *NotificationsModule) listNotifications(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	pagination := response.ParsePagination(c)
+
+	cursor, err := response.ParseCursor(c)
+	if err != nil {
+		response.BadRequest(c, "Invalid cursor")
+		return
+	}
+	pagination.Cursor = cursor
 
-	notifications, total, unread, err := m.service.ListNotifications(userID.(string), page, limit)
+	notifications, err := m.service.ListNotifications(c.Request.Context(), userID.(string), pagination, c.Query("counts") == "true")
 	if err != nil {
 		response.InternalError(c, "Failed to list notifications")
 		return
 	}
 
-	totalPages := (total + limit - 1) / limit
+	response.Success(c, http.StatusOK, "Notifications retrieved successfully", notifications)
+}
 
-	response.Success(c, http.StatusOK, "Notifications retrieved successfully", gin.H{
-		"notifications": notifications,
-		"total":         total,
-		"unread":        unread,
-		"page":          page,
-		"limit":         limit,
-		"total_pages":   totalPages,
+// getPreferences lists notification preferences
+// @Summary Get Notification Preferences
+// @Description Get the user's notification preferences. Type/channel pairs without a stored preference are enabled.
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=object{preferences=[]NotificationPreferenceResponse}}
+// @Failure 401 {object} response.Response
+// @Router /notifications/preferences [get]
+func (m *NotificationsModule) getPreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	preferences, err := m.service.GetPreferences(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.InternalError(c, "Failed to get notification preferences")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification preferences retrieved successfully", gin.H{
+		"preferences": preferences,
+	})
+}
+
+// updatePreferences updates notification preferences
+// @Summary Update Notification Preferences
+// @Description Enable or disable notification types per channel. Critical and security notifications are always delivered.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdatePreferencesRequest true "Preferences"
+// @Success 200 {object} response.Response{data=object{preferences=[]NotificationPreferenceResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/preferences [put]
+func (m *NotificationsModule) updatePreferences(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	preferences, err := m.service.UpdatePreferences(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		response.InternalError(c, "Failed to update notification preferences")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification preferences updated successfully", gin.H{
+		"preferences": preferences,
+	})
+}
+
+// getWebhook retrieves the user's notification webhook
+// @Summary Get Notification Webhook
+// @Description Get the URL webhook notifications are posted to. The signing secret is not returned.
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=WebhookResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /notifications/webhook [get]
+func (m *NotificationsModule) getWebhook(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	webhook, err := m.service.GetWebhook(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook retrieved successfully", webhook)
+}
+
+// setWebhook registers the user's notification webhook
+// @Summary Set Notification Webhook
+// @Description Register the https URL webhook notifications are posted to. Internal addresses are rejected and redirects are not followed. Each delivery carries an X-Webhook-Signature header, the hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" keyed with the returned secret. A new secret is issued on every call.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SetWebhookRequest true "Webhook URL"
+// @Success 200 {object} response.Response{data=WebhookResponse}
+// @Failure 401 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/webhook [put]
+func (m *NotificationsModule) setWebhook(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var req SetWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	webhook, err := m.service.SetWebhook(c.Request.Context(), userID.(string), req.URL)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to set webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook saved successfully", webhook)
+}
+
+// deleteWebhook removes the user's notification webhook
+// @Summary Delete Notification Webhook
+// @Description Remove the user's notification webhook
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /notifications/webhook [delete]
+func (m *NotificationsModule) deleteWebhook(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	if err := m.service.DeleteWebhook(c.Request.Context(), userID.(string)); err != nil {
+		response.HandleServiceError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// streamNotifications streams live in-app notifications using Server-Sent Events
+// @Summary Stream Notifications
+// @Description Stream new in-app notifications as Server-Sent Events ("notification" events carry a NotificationResponse; "ping" events are heartbeats)
+// @Tags Notifications
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {string} string "Event stream"
+// @Failure 401 {object} response.Response
+// @Router /notifications/stream [get]
+func (m *NotificationsModule) streamNotifications(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	// Drop events rather than block the NATS dispatcher if the client is slow
+	events := make(chan []byte, 16)
+	sub, err := m.service.SubscribeUserStream(userID.(string), func(data []byte) {
+		select {
+		case events <- data:
+		default:
+		}
+	})
+	if err != nil {
+		response.InternalError(c, "Failed to subscribe to notifications")
+		return
+	}
+	defer sub.Unsubscribe()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case data := <-events:
+			c.SSEvent("notification", string(data))
+			return true
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().UTC().Unix())
+			return true
+		case <-c.Request.Context().Done():
+			return false
+		case <-m.closing:
+			// Server is shutting down; clients reconnect to another instance
+			return false
+		}
 	})
 }
 
+// unreadCount returns the number of unread notifications
+// @Summary Get Unread Notification Count
+// @Description Get the number of unread notifications of the user, cheap enough to poll for a badge
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=UnreadCountResponse}
+// @Failure 401 {object} response.Response
+// @Router /notifications/unread-count [get]
+func (m *NotificationsModule) unreadCount(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	unread, err := m.service.UnreadCount(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.InternalError(c, "Failed to count unread notifications")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Unread count retrieved successfully", UnreadCountResponse{Unread: unread})
+}
+
 // getNotification retrieves a notification by ID
 // @Summary Get Notification
 // @Description Get a notification by ID
@@ -58,9 +261,9 @@ func (m *NotificationsModule) getNotification(c *gin.Context) {
 	id := c.Param("id")
 	userID, _ := c.Get("user_id")
 
-	notif, err := m.service.GetNotification(id, userID.(string))
+	notif, err := m.service.GetNotification(c.Request.Context(), id, userID.(string))
 	if err != nil {
-		response.NotFound(c, "Notification not found")
+		response.HandleServiceError(c, err, "Failed to get notification")
 		return
 	}
 
@@ -82,18 +285,76 @@ func (m *NotificationsModule) markAsRead(c *gin.Context) {
 	id := c.Param("id")
 	userID, _ := c.Get("user_id")
 
-	err := m.service.MarkAsRead(id, userID.(string))
+	err := m.service.MarkAsRead(c.Request.Context(), id, userID.(string))
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to mark notification as read")
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Notification marked as read", nil)
 }
 
+// markAllAsRead marks all notifications as read
+// @Summary Mark All Notifications as Read
+// @Description Mark all unread notifications of the user as read
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=object{updated=int}}
+// @Failure 401 {object} response.Response
+// @Router /notifications/read-all [put]
+func (m *NotificationsModule) markAllAsRead(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	updated, err := m.service.MarkAllAsRead(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.InternalError(c, "Failed to mark notifications as read")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "All notifications marked as read", gin.H{
+		"updated": updated,
+	})
+}
+
+// deleteNotifications bulk deletes notifications
+// @Summary Delete Notifications
+// @Description Delete all of the user's notifications, or only those created before the given time
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param before query string false "Only delete notifications created before this time (RFC3339)"
+// @Success 200 {object} response.Response{data=object{deleted=int}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /notifications [delete]
+func (m *NotificationsModule) deleteNotifications(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var before *time.Time
+	if value := c.Query("before"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			response.BadRequest(c, "Invalid before timestamp, expected RFC3339")
+			return
+		}
+		before = &parsed
+	}
+
+	deleted, err := m.service.DeleteNotifications(c.Request.Context(), userID.(string), before)
+	if err != nil {
+		response.InternalError(c, "Failed to delete notifications")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notifications deleted successfully", gin.H{
+		"deleted": deleted,
+	})
+}
+
 // deleteNotification deletes a notification
 // @Summary Delete Notification
-// @Description Delete a notification
+// @Description Delete a notification. It can be restored within the restore window.
 // @Tags Notifications
 // @Produce json
 // @Security BearerAuth
@@ -106,15 +367,278 @@ func (m *NotificationsModule) deleteNotification(c *gin.Context) {
 	id := c.Param("id")
 	userID, _ := c.Get("user_id")
 
-	err := m.service.DeleteNotification(id, userID.(string))
+	err := m.service.DeleteNotification(c.Request.Context(), id, userID.(string))
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to delete notification")
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Notification deleted successfully", nil)
 }
 
+// restoreNotification restores a deleted notification
+// @Summary Restore Notification
+// @Description Restore a deleted notification within the restore window
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Notification ID"
+// @Success 200 {object} response.Response{data=NotificationResponse}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /notifications/{id}/restore [post]
+func (m *NotificationsModule) restoreNotification(c *gin.Context) {
+	id := c.Param("id")
+	userID, _ := c.Get("user_id")
+
+	notification, err := m.service.RestoreNotification(c.Request.Context(), id, userID.(string), m.config.Notifications.RestoreWindow)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to restore notification")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification restored successfully", notification)
+}
+
+// listTemplates lists notification templates
+// @Summary List Notification Templates
+// @Description Get a paginated list of notification templates (admin only)
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=TemplatesListResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /notifications/templates [get]
+func (m *NotificationsModule) listTemplates(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	templates, err := m.service.ListTemplates(c.Request.Context(), page, limit)
+	if err != nil {
+		response.InternalError(c, "Failed to list templates")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Templates retrieved successfully", templates)
+}
+
+// deadLetterStats reports how many messages are parked on the dead-letter queue
+// @Summary Get Dead-Letter Queue Stats
+// @Description Get the number of messages workers gave up on (admin only)
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=DeadLetterStatsResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /notifications/dead-letters [get]
+func (m *NotificationsModule) deadLetterStats(c *gin.Context) {
+	stats, err := m.service.DeadLetterStats()
+	if err != nil {
+		response.InternalError(c, "Failed to get dead-letter stats")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Dead-letter stats retrieved successfully", stats)
+}
+
+// getTemplate retrieves a notification template
+// @Summary Get Notification Template
+// @Description Get a notification template by ID (admin only)
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param templateId path string true "Template ID"
+// @Success 200 {object} response.Response{data=object{template=TemplateResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /notifications/templates/{templateId} [get]
+func (m *NotificationsModule) getTemplate(c *gin.Context) {
+	template, err := m.service.GetTemplate(c.Request.Context(), c.Param("templateId"))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get template")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template retrieved successfully", gin.H{
+		"template": template,
+	})
+}
+
+// createTemplate creates a notification template
+// @Summary Create Notification Template
+// @Description Create a notification template for a channel. Title and content may use {{variable}} placeholders (admin only)
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateTemplateRequest true "Template details"
+// @Success 201 {object} response.Response{data=object{template=TemplateResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/templates [post]
+func (m *NotificationsModule) createTemplate(c *gin.Context) {
+	var req CreateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	template, err := m.service.CreateTemplate(c.Request.Context(), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to create template")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Template created successfully", gin.H{
+		"template": template,
+	})
+}
+
+// updateTemplate updates a notification template
+// @Summary Update Notification Template
+// @Description Update a notification template (admin only)
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param templateId path string true "Template ID"
+// @Param request body UpdateTemplateRequest true "Template updates"
+// @Success 200 {object} response.Response{data=object{template=TemplateResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/templates/{templateId} [put]
+func (m *NotificationsModule) updateTemplate(c *gin.Context) {
+	var req UpdateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	template, err := m.service.UpdateTemplate(c.Request.Context(), c.Param("templateId"), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update template")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template updated successfully", gin.H{
+		"template": template,
+	})
+}
+
+// deleteTemplate deletes a notification template
+// @Summary Delete Notification Template
+// @Description Delete a notification template (admin only)
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param templateId path string true "Template ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /notifications/templates/{templateId} [delete]
+func (m *NotificationsModule) deleteTemplate(c *gin.Context) {
+	if err := m.service.DeleteTemplate(c.Request.Context(), c.Param("templateId")); err != nil {
+		response.HandleServiceError(c, err, "Failed to delete template")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Template deleted successfully", nil)
+}
+
+// sendTemplate renders a template for a user and sends it on every channel it is defined on
+// @Summary Send Notification Template
+// @Description Render a template by name with the given variables and send it to a user on each of its channels (admin only)
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body SendTemplateRequest true "Template and recipient"
+// @Success 201 {object} response.Response{data=object{notifications=[]NotificationResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/templates/send [post]
+func (m *NotificationsModule) sendTemplate(c *gin.Context) {
+	var req SendTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	notifications, err := m.service.SendTemplatedNotification(c.Request.Context(), req.UserID, req.Template, req.Variables)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to send template")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Template sent successfully", gin.H{
+		"notifications": notifications,
+	})
+}
+
+// twilioCallback receives SMS delivery status updates from Twilio
+// @Summary Twilio Status Callback
+// @Description Webhook called by Twilio with SMS delivery status updates. Requests must carry a valid X-Twilio-Signature header.
+// @Tags Notifications
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param MessageSid formData string true "Twilio message SID"
+// @Param MessageStatus formData string true "Twilio message status"
+// @Param ErrorCode formData string false "Twilio error code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /notifications/twilio-callback [post]
+func (m *NotificationsModule) twilioCallback(c *gin.Context) {
+	if err := c.Request.ParseForm(); err != nil {
+		response.BadRequest(c, "Invalid form data")
+		return
+	}
+
+	// Twilio signs the exact public URL it called
+	callbackURL := m.service.TwilioCallbackURL()
+	if callbackURL == "" {
+		scheme := "http"
+		if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		callbackURL = fmt.Sprintf("%s://%s%s", scheme, c.Request.Host, c.Request.URL.RequestURI())
+	}
+
+	if !m.service.ValidateTwilioSignature(callbackURL, c.Request.PostForm, c.GetHeader("X-Twilio-Signature")) {
+		response.Forbidden(c, "Invalid Twilio signature")
+		return
+	}
+
+	messageSID := c.Request.PostForm.Get("MessageSid")
+	messageStatus := c.Request.PostForm.Get("MessageStatus")
+	if messageSID == "" || messageStatus == "" {
+		response.BadRequest(c, "MessageSid and MessageStatus are required")
+		return
+	}
+
+	err := m.service.UpdateSMSDeliveryStatus(c.Request.Context(), messageSID, messageStatus, c.Request.PostForm.Get("ErrorCode"))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update notification status")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Status updated", nil)
+}
+
 // testEmail sends a test email
 // @Summary Test Email
 // @Description Send a test email via SendGrid
@@ -130,14 +654,11 @@ func (m *NotificationsModule) deleteNotification(c *gin.Context) {
 func (m *NotificationsModule) testEmail(c *gin.Context) {
 	var req TestEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	err := m.service.SendEmail([]string{req.To}, req.Subject, req.Body)
+	err := m.service.SendEmail(c.Request.Context(), []string{req.To}, req.Subject, req.Body)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -161,14 +682,11 @@ func (m *NotificationsModule) testEmail(c *gin.Context) {
 func (m *NotificationsModule) testSMS(c *gin.Context) {
 	var req TestSMSRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	err := m.service.SendSMS(req.To, req.Body)
+	err := m.service.SendSMS(c.Request.Context(), req.To, req.Body)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -176,3 +694,47 @@ func (m *NotificationsModule) testSMS(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Test SMS sent successfully", nil)
 }
+
+// broadcastNotification sends one notification to many users
+// @Summary Broadcast Notification
+// @Description Send a notification to the listed users, every active user with a role, or all active users (admin only)
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body BroadcastNotificationRequest true "Broadcast details"
+// @Success 202 {object} response.Response{data=BulkSendResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /notifications/broadcast [post]
+func (m *NotificationsModule) broadcastNotification(c *gin.Context) {
+	var req BroadcastNotificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	userIDs := req.UserIDs
+	if len(userIDs) == 0 {
+		var err error
+		userIDs, err = m.service.ActiveUserIDs(c.Request.Context(), req.Role)
+		if err != nil {
+			response.InternalError(c, "Failed to load recipients")
+			return
+		}
+	}
+
+	result, err := m.service.SendBulkNotification(c.Request.Context(), userIDs, &SendNotificationRequest{
+		Type:    req.Type,
+		Channel: req.Channel,
+		Title:   req.Title,
+		Content: req.Content,
+	})
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to broadcast notification")
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Notification broadcast queued", result)
+}
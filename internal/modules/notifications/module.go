@@ -1,6 +1,8 @@
 package notifications
 
 import (
+	"sync"
+
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/middleware"
@@ -23,15 +25,21 @@ type NotificationsModule struct {
 	twilio       *twilio.TwilioClient
 	redisHelper  *redishelper.RedisHelper
 	jwtUtil      *utils.JWTUtil
+	// closing is closed on server shutdown so open event streams end instead of holding the drain
+	closing      chan struct{}
+	closeOnce    sync.Once
 }
 
 // NewNotificationsModule creates a new notifications module
 func NewNotificationsModule(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, cfg *config.Config) *NotificationsModule {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	sendgridClient := sendgrid.NewSendGridClient(cfg.SMTP)
 	twilioClient := twilio.NewTwilioClient(cfg.Twilio)
-	service := NewNotificationsService(db, nats, sendgridClient, twilioClient)
+	service := NewNotificationsService(db, nats, sendgridClient, twilioClient).WithSecretKey(cfg.App.EncryptionKey)
+	if cfg.Notifications.AsyncPublish {
+		service.WithAsyncPublish()
+	}
 
 	return &NotificationsModule{
 		db:          db,
@@ -43,21 +51,65 @@ func NewNotificationsModule(db *clients.Database, redis *clients.RedisClient, na
 		twilio:      twilioClient,
 		redisHelper: redisHelper,
 		jwtUtil:     jwtUtil,
+		closing:     make(chan struct{}),
 	}
 }
 
+// CloseStreams ends every open notification stream. Register it with
+// http.Server.RegisterOnShutdown, since Shutdown doesn't cancel active requests.
+func (m *NotificationsModule) CloseStreams() {
+	m.closeOnce.Do(func() { close(m.closing) })
+}
+
+// Service returns the module's notifications service so other modules send through
+// the same configuration (encryption key, async publishing) instead of building their own
+func (m *NotificationsModule) Service() *NotificationsService {
+	return m.service
+}
+
 // RegisterRoutes registers notification routes
 func (m *NotificationsModule) RegisterRoutes(router *gin.RouterGroup) {
 	authMiddleware := middleware.NewAuthMiddleware(m.jwtUtil, m.redisHelper)
 
+	// Twilio status webhook - public, authenticated by request signature
+	router.POST("/notifications/twilio-callback", m.twilioCallback)
+
 	notifications := router.Group("/notifications")
 	notifications.Use(authMiddleware.RequireAuth())
 	{
 		notifications.GET("", m.listNotifications)
+		notifications.GET("/stream", m.streamNotifications)
+		notifications.GET("/unread-count", m.unreadCount)
+		notifications.GET("/preferences", m.getPreferences)
+		notifications.PUT("/preferences", m.updatePreferences)
+		notifications.GET("/webhook", m.getWebhook)
+		notifications.PUT("/webhook", m.setWebhook)
+		notifications.DELETE("/webhook", m.deleteWebhook)
 		notifications.GET("/:id", m.getNotification)
+		notifications.PUT("/read-all", m.markAllAsRead)
 		notifications.PUT("/:id/read", m.markAsRead)
+		notifications.DELETE("", m.deleteNotifications)
 		notifications.DELETE("/:id", m.deleteNotification)
+		notifications.POST("/:id/restore", m.restoreNotification)
 		notifications.POST("/test-email", m.testEmail)
 		notifications.POST("/test-sms", m.testSMS)
 	}
+
+	// Template management (admin only)
+	templates := notifications.Group("/templates")
+	templates.Use(middleware.RequireAdmin())
+	{
+		templates.GET("", m.listTemplates)
+		templates.POST("", m.createTemplate)
+		templates.POST("/send", m.sendTemplate)
+		templates.GET("/:templateId", m.getTemplate)
+		templates.PUT("/:templateId", m.updateTemplate)
+		templates.DELETE("/:templateId", m.deleteTemplate)
+	}
+
+	// Dead-letter queue inspection (admin only)
+	notifications.GET("/dead-letters", middleware.RequireAdmin(), m.deadLetterStats)
+
+	// Bulk sends (admin only)
+	notifications.POST("/broadcast", middleware.RequireAdmin(), m.broadcastNotification)
 }
@@ -1,24 +1,58 @@
 package notifications
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"html"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
 	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/models"
 	"gogin/internal/modules/sendgrid"
 	"gogin/internal/modules/twilio"
+	"gogin/internal/response"
+	"gogin/internal/utils"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nats-io/nats.go"
 )
 
+// criticalNotificationTypes are always delivered regardless of user preferences
+var criticalNotificationTypes = map[string]bool{
+	"critical": true,
+	"security": true,
+}
+
+// Errors returned by the notifications service; match them with errors.Is
+var (
+	ErrNotificationNotFound   = response.NotFoundError("notification not found")
+	ErrTemplateNotFound       = response.NotFoundError("template not found")
+	ErrTemplateExists         = response.ConflictError("template already exists for this channel")
+	ErrUserNotFound           = response.NotFoundError("user not found")
+	ErrWebhookNotFound        = response.NotFoundError("webhook not found")
+	ErrWebhookURLNotAllowed   = response.InvalidError("webhook URL must use https and point at a public address")
+	ErrNotificationNotDeleted = response.ConflictError("notification is not deleted")
+	ErrRestoreWindowExpired   = response.ConflictError("notification was deleted too long ago to be restored")
+)
+
+// publishAckTimeout bounds how long queuing a notification waits for JetStream
+const publishAckTimeout = 5 * time.Second
+
 // NotificationsService handles notifications business logic
 type NotificationsService struct {
-	db       *clients.Database
-	nats     *clients.NATSClient
-	sendgrid *sendgrid.SendGridClient
-	twilio   *twilio.TwilioClient
+	db           *clients.Database
+	nats         *clients.NATSClient
+	sendgrid     *sendgrid.SendGridClient
+	twilio       *twilio.TwilioClient
+	asyncPublish bool
+	secretKey    string
 }
 
 // NewNotificationsService creates a new notifications service
@@ -31,36 +65,67 @@ func NewNotificationsService(db *clients.Database, nats *clients.NATSClient, sg
 	}
 }
 
+// WithAsyncPublish queues notifications without waiting for the JetStream ack;
+// publish failures are still recorded on the notification once the ack fails
+func (s *NotificationsService) WithAsyncPublish() *NotificationsService {
+	s.asyncPublish = true
+	return s
+}
+
+// WithSecretKey sets the key webhook signing secrets are encrypted with at rest
+func (s *NotificationsService) WithSecretKey(key string) *NotificationsService {
+	s.secretKey = key
+	return s
+}
+
 // SendNotification creates and queues a notification
-func (s *NotificationsService) SendNotification(req *SendNotificationRequest) (*NotificationResponse, error) {
+func (s *NotificationsService) SendNotification(ctx context.Context, req *SendNotificationRequest) (*NotificationResponse, error) {
+	// Respect user opt-outs unless the notification is critical
+	status := "pending"
+	if !criticalNotificationTypes[req.Type] {
+		enabled, err := s.isChannelEnabled(ctx, req.UserID, req.Type, req.Channel)
+		if err != nil {
+			return nil, err
+		}
+		if !enabled {
+			status = "skipped"
+		}
+	}
+
+	// Hold notifications scheduled for the future until the scheduler picks them up
+	var scheduledAt sql.NullTime
+	if status == "pending" && req.ScheduledAt != nil && req.ScheduledAt.After(time.Now()) {
+		status = "scheduled"
+		scheduledAt = sql.NullTime{Time: req.ScheduledAt.UTC(), Valid: true}
+	}
+
 	id := uuid.New().String()
 	query := `
-		INSERT INTO notifications (id, user_id, type, channel, title, content, is_read, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		INSERT INTO notifications (id, user_id, type, channel, title, content, html_content, is_read, status, scheduled_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $11)
 		RETURNING created_at, updated_at
 	`
 
 	var createdAt, updatedAt time.Time
-	err := s.db.QueryRow(query,
+	err := s.db.QueryRowContext(ctx, query,
 		id,
 		req.UserID,
 		req.Type,
 		req.Channel,
 		req.Title,
 		req.Content,
+		sql.NullString{String: req.HTMLContent, Valid: req.HTMLContent != ""},
 		false,
-		"pending",
+		status,
+		scheduledAt,
+		clients.Now(),
 	).Scan(&createdAt, &updatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create notification: %w", err)
 	}
 
-	// Queue for async delivery
-	notifData, _ := json.Marshal(req)
-	go s.nats.Publish("notification.send", notifData)
-
-	return &NotificationResponse{
+	notification := &NotificationResponse{
 		ID:        id,
 		UserID:    req.UserID,
 		Type:      req.Type,
@@ -68,39 +133,607 @@ func (s *NotificationsService) SendNotification(req *SendNotificationRequest) (*
 		Title:     req.Title,
 		Content:   req.Content,
 		IsRead:    false,
-		Status:    "pending",
+		Status:    status,
 		CreatedAt: createdAt,
 		UpdatedAt: updatedAt,
+	}
+	if scheduledAt.Valid {
+		notification.ScheduledAt = &scheduledAt.Time
+	}
+
+	if status != "pending" {
+		return notification, nil
+	}
+
+	if err := s.dispatch(ctx, notification, req); err != nil {
+		return nil, err
+	}
+
+	return notification, nil
+}
+
+// dispatch delivers a pending notification: in-app notifications are completed
+// immediately, all other channels are queued for the notification worker
+func (s *NotificationsService) dispatch(ctx context.Context, notification *NotificationResponse, req *SendNotificationRequest) error {
+	id := notification.ID
+
+	// In-app notifications are delivered by persisting them; push them live to connected clients
+	if req.Channel == "in_app" {
+		if _, err := s.db.ExecContext(ctx, `UPDATE notifications SET status = 'sent', sent_at = $1 WHERE id = $2`, clients.Now(), id); err != nil {
+			return fmt.Errorf("failed to update notification: %w", err)
+		}
+		notification.Status = "sent"
+
+		liveData, _ := json.Marshal(notification)
+		if err := s.nats.PublishCore(UserStreamSubject(req.UserID), liveData); err != nil {
+			log.Printf("Failed to push live notification %s: %v", id, err)
+		}
+
+		return nil
+	}
+
+	// Queue for async delivery, carrying the persisted ID so the worker updates this exact notification
+	if s.asyncPublish {
+		job := *req
+		job.ID = id
+		job.RequestID = utils.RequestIDFromContext(ctx)
+		notifData, _ := json.Marshal(&job)
+
+		future, err := s.nats.PublishAsync("notification.send", notifData)
+		if err != nil {
+			notification.Status = "failed"
+			s.markPublishFailed(id, err)
+			return nil
+		}
+		// The caller serializes notification once we return, so the ack watcher
+		// only touches the stored row
+		go func() {
+			select {
+			case <-future.Ok():
+			case err := <-future.Err():
+				s.markPublishFailed(id, err)
+			}
+		}()
+		return nil
+	}
+
+	if err := s.publishJob(ctx, id, req); err != nil {
+		notification.Status = "failed"
+		s.markPublishFailed(id, err)
+	}
+
+	return nil
+}
+
+// publishJob queues a notification for the worker and waits for the stream to ack it
+func (s *NotificationsService) publishJob(ctx context.Context, id string, req *SendNotificationRequest) error {
+	job := *req
+	job.ID = id
+	job.RequestID = utils.RequestIDFromContext(ctx)
+	notifData, _ := json.Marshal(&job)
+
+	_, err := s.nats.PublishWithAck("notification.send", notifData, publishAckTimeout)
+	return err
+}
+
+// markPublishFailed records that a notification could not be queued for delivery
+func (s *NotificationsService) markPublishFailed(id string, publishErr error) {
+	log.Printf("Failed to queue notification %s: %v", id, publishErr)
+
+	// Runs from the ack watcher after the request has returned, so it isn't bound to its context
+	_, err := s.db.ExecContext(context.Background(), `UPDATE notifications SET status = 'failed', error_msg = $1, updated_at = $2 WHERE id = $3`, publishErr.Error(), clients.Now(), id)
+	if err != nil {
+		log.Printf("Failed to update notification status: %v", err)
+	}
+}
+
+// bulkInsertBatchSize caps how many notification rows a single INSERT writes
+const bulkInsertBatchSize = 500
+
+// SendBulkNotification sends the same notification to every user in userIDs. Rows are
+// written with one INSERT per batch, users who opted out are recorded as skipped, IDs
+// with no active user are counted as unknown and queued jobs are published without
+// waiting on each ack in turn. When a later batch fails the counts so far are returned
+// with the rest of the recipients reported as failed.
+func (s *NotificationsService) SendBulkNotification(ctx context.Context, userIDs []string, req *SendNotificationRequest) (*BulkSendResponse, error) {
+	seen := make(map[string]bool, len(userIDs))
+	recipients := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+
+	result := &BulkSendResponse{Recipients: len(recipients)}
+	for start := 0; start < len(recipients); start += bulkInsertBatchSize {
+		end := min(start+bulkInsertBatchSize, len(recipients))
+		if err := s.sendNotificationBatch(ctx, recipients[start:end], req, result); err != nil {
+			if start == 0 {
+				return nil, err
+			}
+			log.Printf("Bulk notification stopped after %d of %d recipients: %v", start, len(recipients), err)
+			result.Failed += len(recipients) - start
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// sendNotificationBatch inserts and dispatches one batch of recipients, adding its
+// outcome to result only once the whole batch went through
+func (s *NotificationsService) sendNotificationBatch(ctx context.Context, userIDs []string, req *SendNotificationRequest, result *BulkSendResponse) error {
+	userIDs, unknown, err := s.existingUsers(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+
+	batch, err := s.insertNotificationBatch(ctx, userIDs, req)
+	if err != nil {
+		return err
+	}
+
+	var pending []*NotificationResponse
+	skipped, queued := 0, 0
+	for _, notification := range batch {
+		switch notification.Status {
+		case "skipped":
+			skipped++
+		case "pending":
+			pending = append(pending, notification)
+		default:
+			queued++
+		}
+	}
+
+	failed, err := s.dispatchBatch(ctx, pending, req)
+	if err != nil {
+		return err
+	}
+
+	result.Unknown += unknown
+	result.Skipped += skipped
+	result.Queued += queued + len(pending) - failed
+	result.Failed += failed
+	return nil
+}
+
+// existingUsers keeps the userIDs that belong to active users and counts the rest
+func (s *NotificationsService) existingUsers(ctx context.Context, userIDs []string) ([]string, int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM users WHERE id = ANY($1) AND deleted_at IS NULL`, pq.Array(userIDs))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to look up recipients: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make([]string, 0, len(userIDs))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, 0, err
+		}
+		existing = append(existing, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return existing, len(userIDs) - len(existing), nil
+}
+
+// insertNotificationBatch writes one notification row per user in a single INSERT
+func (s *NotificationsService) insertNotificationBatch(ctx context.Context, userIDs []string, req *SendNotificationRequest) ([]*NotificationResponse, error) {
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	optedOut := map[string]bool{}
+	if !criticalNotificationTypes[req.Type] {
+		var err error
+		optedOut, err = s.optedOutUsers(ctx, userIDs, req.Type, req.Channel)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var scheduledAt sql.NullTime
+	if req.ScheduledAt != nil && req.ScheduledAt.After(time.Now()) {
+		scheduledAt = sql.NullTime{Time: req.ScheduledAt.UTC(), Valid: true}
+	}
+
+	now := clients.Now()
+	// Values shared by every row come first; each row adds its id, user and status
+	args := []interface{}{req.Type, req.Channel, req.Title, req.Content, scheduledAt, now,
+		sql.NullString{String: req.HTMLContent, Valid: req.HTMLContent != ""}}
+	values := make([]string, 0, len(userIDs))
+	notifications := make([]*NotificationResponse, 0, len(userIDs))
+	for _, userID := range userIDs {
+		status := "pending"
+		switch {
+		case optedOut[userID]:
+			status = "skipped"
+		case scheduledAt.Valid:
+			status = "scheduled"
+		}
+
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $1, $2, $3, $4, $7, false, $%d, $5, $6, $6)", n+1, n+2, n+3))
+
+		notification := &NotificationResponse{
+			ID:        uuid.New().String(),
+			UserID:    userID,
+			Type:      req.Type,
+			Channel:   req.Channel,
+			Title:     req.Title,
+			Content:   req.Content,
+			Status:    status,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if status == "scheduled" {
+			notification.ScheduledAt = &scheduledAt.Time
+		}
+		args = append(args, notification.ID, userID, status)
+		notifications = append(notifications, notification)
+	}
+
+	query := `
+		INSERT INTO notifications (id, user_id, type, channel, title, content, html_content, is_read, status, scheduled_at, created_at, updated_at)
+		VALUES ` + strings.Join(values, ", ")
+
+	if _, err := s.db.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to create notifications: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// optedOutUsers returns which of userIDs disabled the notification type on the channel
+func (s *NotificationsService) optedOutUsers(ctx context.Context, userIDs []string, notifType, channel string) (map[string]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT user_id FROM notification_preferences
+		WHERE user_id = ANY($1) AND type = $2 AND channel = $3 AND enabled = false
+	`, pq.Array(userIDs), notifType, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	optedOut := map[string]bool{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		optedOut[userID] = true
+	}
+
+	return optedOut, rows.Err()
+}
+
+// dispatchBatch delivers pending notifications that share one request and returns how
+// many could not be queued. In-app notifications are completed with a single UPDATE;
+// other channels are published asynchronously and their acks awaited together.
+func (s *NotificationsService) dispatchBatch(ctx context.Context, notifications []*NotificationResponse, req *SendNotificationRequest) (int, error) {
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	if req.Channel == "in_app" {
+		ids := make([]string, len(notifications))
+		for i, notification := range notifications {
+			ids[i] = notification.ID
+		}
+		if _, err := s.db.ExecContext(ctx, `UPDATE notifications SET status = 'sent', sent_at = $1 WHERE id = ANY($2)`, clients.Now(), pq.Array(ids)); err != nil {
+			return 0, fmt.Errorf("failed to update notifications: %w", err)
+		}
+
+		for _, notification := range notifications {
+			notification.Status = "sent"
+			liveData, _ := json.Marshal(notification)
+			if err := s.nats.PublishCore(UserStreamSubject(notification.UserID), liveData); err != nil {
+				log.Printf("Failed to push live notification %s: %v", notification.ID, err)
+			}
+		}
+		return 0, nil
+	}
+
+	futures := make([]nats.PubAckFuture, len(notifications))
+	failed := 0
+	for i, notification := range notifications {
+		job := *req
+		job.ID = notification.ID
+		job.UserID = notification.UserID
+		job.RequestID = utils.RequestIDFromContext(ctx)
+		notifData, _ := json.Marshal(&job)
+
+		future, err := s.nats.PublishAsync("notification.send", notifData)
+		if err != nil {
+			s.markPublishFailed(notification.ID, err)
+			failed++
+			continue
+		}
+		futures[i] = future
+	}
+
+	ackCtx, cancel := context.WithTimeout(context.Background(), publishAckTimeout)
+	defer cancel()
+	for i, future := range futures {
+		if future == nil {
+			continue
+		}
+		select {
+		case <-future.Ok():
+		case err := <-future.Err():
+			s.markPublishFailed(notifications[i].ID, err)
+			failed++
+		case <-ackCtx.Done():
+			s.markPublishFailed(notifications[i].ID, nats.ErrTimeout)
+			failed++
+		}
+	}
+
+	return failed, nil
+}
+
+// ActiveUserIDs lists active users, limited to role when it is set
+func (s *NotificationsService) ActiveUserIDs(ctx context.Context, role string) ([]string, error) {
+	query := `SELECT id FROM users WHERE deleted_at IS NULL AND status = 'active'`
+	args := []interface{}{}
+	if role != "" {
+		query += ` AND role = $1`
+		args = append(args, role)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// DispatchScheduledNotifications releases up to limit due scheduled notifications for delivery
+func (s *NotificationsService) DispatchScheduledNotifications(ctx context.Context, limit int) (int, error) {
+	// Claim due notifications by moving them to pending so they are dispatched exactly once
+	query := `
+		UPDATE notifications
+		SET status = 'pending', updated_at = $1
+		WHERE id IN (
+			SELECT id FROM notifications
+			WHERE status = 'scheduled' AND scheduled_at <= $1 AND deleted_at IS NULL
+			ORDER BY scheduled_at
+			LIMIT $2
+		)
+		RETURNING id, user_id, type, channel, title, content, html_content, is_read, status, scheduled_at, created_at, updated_at
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, clients.Now(), limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim scheduled notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var due []*models.Notification
+	for rows.Next() {
+		var notif models.Notification
+		err := rows.Scan(
+			&notif.ID,
+			&notif.UserID,
+			&notif.Type,
+			&notif.Channel,
+			&notif.Title,
+			&notif.Content,
+			&notif.HTMLContent,
+			&notif.IsRead,
+			&notif.Status,
+			&notif.ScheduledAt,
+			&notif.CreatedAt,
+			&notif.UpdatedAt,
+		)
+		if err != nil {
+			return 0, err
+		}
+		due = append(due, &notif)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, notif := range due {
+		req := &SendNotificationRequest{
+			UserID:      notif.UserID,
+			Type:        notif.Type,
+			Channel:     notif.Channel,
+			Title:       notif.Title,
+			Content:     notif.Content,
+			HTMLContent: notif.HTMLContent.String,
+		}
+		if req.Channel == "in_app" {
+			if err := s.dispatch(ctx, s.toNotificationResponse(notif), req); err != nil {
+				log.Printf("Failed to dispatch scheduled notification %s: %v", notif.ID, err)
+				continue
+			}
+			dispatched++
+			continue
+		}
+
+		// Wait for the ack so a failed publish can go back on the schedule
+		if err := s.publishJob(ctx, notif.ID, req); err != nil {
+			s.rescheduleUnpublished(ctx, notif.ID, err)
+			continue
+		}
+		dispatched++
+	}
+
+	return dispatched, nil
+}
+
+// rescheduleUnpublished returns a claimed notification that could not be queued to the
+// scheduled state so the next scheduler run retries it
+func (s *NotificationsService) rescheduleUnpublished(ctx context.Context, id string, publishErr error) {
+	log.Printf("Failed to queue scheduled notification %s, will retry: %v", id, publishErr)
+
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET status = 'scheduled', error_msg = $1, attempts = attempts + 1, updated_at = $2
+		WHERE id = $3 AND status = 'pending'
+	`, publishErr.Error(), clients.Now(), id)
+	if err != nil {
+		log.Printf("Failed to update notification status: %v", err)
+	}
+}
+
+// DeadLetterStats reports how many messages are parked on the dead-letter subject
+func (s *NotificationsService) DeadLetterStats() (*DeadLetterStatsResponse, error) {
+	count, err := s.nats.DeadLetterCount()
+	if err != nil {
+		return nil, err
+	}
+
+	return &DeadLetterStatsResponse{
+		Subject:  s.nats.StreamName() + "." + clients.DeadLetterSubject,
+		Messages: count,
 	}, nil
 }
 
-// ListNotifications lists user notifications
-func (s *NotificationsService) ListNotifications(userID string, page, limit int) ([]*NotificationResponse, int, int, error) {
-	offset := (page - 1) * limit
+// UserStreamSubject returns the core NATS subject carrying live notifications for a user
+func UserStreamSubject(userID string) string {
+	return "notifications.live." + userID
+}
+
+// SubscribeUserStream subscribes to a user's live notifications.
+// The caller must unsubscribe when the client disconnects.
+func (s *NotificationsService) SubscribeUserStream(userID string, handler func(data []byte)) (*nats.Subscription, error) {
+	return s.nats.SubscribeCore(UserStreamSubject(userID), func(msg *nats.Msg) {
+		handler(msg.Data)
+	})
+}
+
+// isChannelEnabled checks a user's preference for a notification type and channel.
+// Channels are enabled by default when no preference has been stored.
+func (s *NotificationsService) isChannelEnabled(ctx context.Context, userID, notifType, channel string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT enabled FROM notification_preferences WHERE user_id = $1 AND type = $2 AND channel = $3`,
+		userID, notifType, channel,
+	).Scan(&enabled)
+
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get notification preference: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// GetPreferences lists a user's stored notification preferences
+func (s *NotificationsService) GetPreferences(ctx context.Context, userID string) ([]*NotificationPreferenceResponse, error) {
+	query := `
+		SELECT type, channel, enabled, updated_at
+		FROM notification_preferences
+		WHERE user_id = $1
+		ORDER BY type, channel
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	defer rows.Close()
+
+	preferences := []*NotificationPreferenceResponse{}
+	for rows.Next() {
+		var pref models.NotificationPreference
+		if err := rows.Scan(&pref.Type, &pref.Channel, &pref.Enabled, &pref.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %w", err)
+		}
+		preferences = append(preferences, &NotificationPreferenceResponse{
+			Type:      pref.Type,
+			Channel:   pref.Channel,
+			Enabled:   pref.Enabled,
+			UpdatedAt: pref.UpdatedAt,
+		})
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences upserts a user's notification preferences
+func (s *NotificationsService) UpdatePreferences(ctx context.Context, userID string, req *UpdatePreferencesRequest) ([]*NotificationPreferenceResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO notification_preferences (user_id, type, channel, enabled, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (user_id, type, channel)
+		DO UPDATE SET enabled = EXCLUDED.enabled, updated_at = EXCLUDED.updated_at
+	`
+
+	now := clients.Now()
+	for _, pref := range req.Preferences {
+		if _, err := tx.ExecContext(ctx, query, userID, pref.Type, pref.Channel, *pref.Enabled, now); err != nil {
+			return nil, fmt.Errorf("failed to update notification preference: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return s.GetPreferences(ctx, userID)
+}
 
+// ListNotifications lists user notifications
+func (s *NotificationsService) ListNotifications(ctx context.Context, userID string, pagination response.Pagination, withTypeCounts bool) (*NotificationsListResponse, error) {
 	// Get total count
 	var total, unread int
-	err := s.db.QueryRow(`
+	err := s.db.QueryRowContext(ctx, `
 		SELECT COUNT(*), COALESCE(SUM(CASE WHEN is_read = FALSE THEN 1 ELSE 0 END), 0)
 		FROM notifications
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 	`, userID).Scan(&total, &unread)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, err
 	}
 
-	// Get notifications
+	// Get notifications, by keyset after the cursor when given, otherwise by offset.
+	// One extra row is fetched to tell whether another page follows.
 	query := `
-		SELECT id, user_id, type, channel, title, content, is_read, read_at, status, created_at, updated_at
+		SELECT id, user_id, type, channel, title, content, is_read, read_at, status, scheduled_at, created_at, updated_at
 		FROM notifications
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
+		WHERE user_id = $1 AND deleted_at IS NULL
 	`
+	args := []interface{}{userID}
+	if pagination.Cursor != nil {
+		query += ` AND (created_at, id) < ($2::timestamp, $3) ORDER BY created_at DESC, id DESC LIMIT $4`
+		args = append(args, pagination.Cursor.CreatedAtParam(), pagination.Cursor.ID, pagination.Limit+1)
+	} else {
+		query += ` ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
+		args = append(args, pagination.Limit+1, pagination.Offset)
+	}
 
-	rows, err := s.db.Query(query, userID, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, 0, 0, err
+		return nil, err
 	}
 	defer rows.Close()
 
@@ -117,28 +750,86 @@ func (s *NotificationsService) ListNotifications(userID string, page, limit int)
 			&notif.IsRead,
 			&notif.ReadAt,
 			&notif.Status,
+			&notif.ScheduledAt,
 			&notif.CreatedAt,
 			&notif.UpdatedAt,
 		)
 		if err != nil {
-			return nil, 0, 0, err
+			return nil, err
 		}
 		notifications = append(notifications, s.toNotificationResponse(&notif))
 	}
 
-	return notifications, total, unread, nil
+	meta := pagination.Meta(total)
+	if len(notifications) > pagination.Limit {
+		notifications = notifications[:pagination.Limit]
+		last := notifications[len(notifications)-1]
+		meta.NextCursor = response.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	result := &NotificationsListResponse{
+		Notifications:  notifications,
+		Unread:         unread,
+		PaginationMeta: meta,
+	}
+
+	if withTypeCounts {
+		result.TypeCounts, err = s.countByType(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// countByType counts a user's notifications and unread notifications per type
+func (s *NotificationsService) countByType(ctx context.Context, userID string) (map[string]NotificationTypeCount, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT type, COUNT(*), COUNT(*) FILTER (WHERE is_read = FALSE)
+		FROM notifications
+		WHERE user_id = $1 AND deleted_at IS NULL
+		GROUP BY type
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count notifications by type: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]NotificationTypeCount{}
+	for rows.Next() {
+		var notifType string
+		var count NotificationTypeCount
+		if err := rows.Scan(&notifType, &count.Total, &count.Unread); err != nil {
+			return nil, err
+		}
+		counts[notifType] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// UnreadCount counts a user's unread notifications
+func (s *NotificationsService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	var unread int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND is_read = FALSE AND deleted_at IS NULL`, userID).Scan(&unread)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+
+	return unread, nil
 }
 
 // GetNotification retrieves a notification by ID
-func (s *NotificationsService) GetNotification(id, userID string) (*NotificationResponse, error) {
+func (s *NotificationsService) GetNotification(ctx context.Context, id, userID string) (*NotificationResponse, error) {
 	var notif models.Notification
 	query := `
-		SELECT id, user_id, type, channel, title, content, is_read, read_at, status, created_at, updated_at
+		SELECT id, user_id, type, channel, title, content, is_read, read_at, status, scheduled_at, created_at, updated_at
 		FROM notifications
-		WHERE id = $1 AND user_id = $2
+		WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL
 	`
 
-	err := s.db.QueryRow(query, id, userID).Scan(
+	err := s.db.QueryRowContext(ctx, query, id, userID).Scan(
 		&notif.ID,
 		&notif.UserID,
 		&notif.Type,
@@ -148,71 +839,576 @@ func (s *NotificationsService) GetNotification(id, userID string) (*Notification
 		&notif.IsRead,
 		&notif.ReadAt,
 		&notif.Status,
+		&notif.ScheduledAt,
 		&notif.CreatedAt,
 		&notif.UpdatedAt,
 	)
 
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationNotFound
+	}
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get notification: %w", err)
 	}
 
 	return s.toNotificationResponse(&notif), nil
 }
 
 // MarkAsRead marks a notification as read
-func (s *NotificationsService) MarkAsRead(id, userID string) error {
-	query := `UPDATE notifications SET is_read = TRUE, read_at = NOW(), updated_at = NOW() WHERE id = $1 AND user_id = $2`
-	result, err := s.db.Exec(query, id, userID)
+func (s *NotificationsService) MarkAsRead(ctx context.Context, id, userID string) error {
+	query := `UPDATE notifications SET is_read = TRUE, read_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), id, userID)
 	if err != nil {
 		return err
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("notification not found")
+		return ErrNotificationNotFound
 	}
 
 	return nil
 }
 
-// DeleteNotification deletes a notification
-func (s *NotificationsService) DeleteNotification(id, userID string) error {
-	query := `DELETE FROM notifications WHERE id = $1 AND user_id = $2`
-	result, err := s.db.Exec(query, id, userID)
+// MarkAllAsRead marks all unread notifications of a user as read
+func (s *NotificationsService) MarkAllAsRead(ctx context.Context, userID string) (int64, error) {
+	query := `UPDATE notifications SET is_read = TRUE, read_at = $1, updated_at = $1 WHERE user_id = $2 AND is_read = FALSE AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+// DeleteNotifications bulk soft deletes a user's notifications, optionally only those created before a time
+func (s *NotificationsService) DeleteNotifications(ctx context.Context, userID string, before *time.Time) (int64, error) {
+	query := `UPDATE notifications SET deleted_at = $1, updated_at = $1 WHERE user_id = $2 AND deleted_at IS NULL`
+	args := []interface{}{clients.Now(), userID}
+
+	if before != nil {
+		query += ` AND created_at < $3`
+		args = append(args, *before)
+	}
+
+	result, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete notifications: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows, nil
+}
+
+// DeleteNotification soft deletes a notification so it can be restored until it is purged
+func (s *NotificationsService) DeleteNotification(ctx context.Context, id, userID string) error {
+	query := `UPDATE notifications SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), id, userID)
 	if err != nil {
 		return err
 	}
 
 	rows, _ := result.RowsAffected()
 	if rows == 0 {
-		return fmt.Errorf("notification not found")
+		return ErrNotificationNotFound
 	}
 
 	return nil
 }
 
-// SendEmail sends an email via SendGrid
-func (s *NotificationsService) SendEmail(to []string, subject, body string) error {
+// RestoreNotification undoes a soft delete made within the restore window
+func (s *NotificationsService) RestoreNotification(ctx context.Context, id, userID string, window time.Duration) (*NotificationResponse, error) {
+	var deletedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT deleted_at FROM notifications WHERE id = $1 AND user_id = $2`, id, userID).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification: %w", err)
+	}
+
+	if !deletedAt.Valid {
+		return nil, ErrNotificationNotDeleted
+	}
+	if time.Since(deletedAt.Time) > window {
+		return nil, fmt.Errorf("%w (deleted at %s)", ErrRestoreWindowExpired, deletedAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	query := `
+		UPDATE notifications
+		SET deleted_at = NULL, updated_at = $1
+		WHERE id = $2 AND user_id = $3 AND deleted_at IS NOT NULL
+		RETURNING id, user_id, type, channel, title, content, is_read, read_at, status, scheduled_at, created_at, updated_at
+	`
+
+	var notif models.Notification
+	err = s.db.QueryRowContext(ctx, query, clients.Now(), id, userID).Scan(
+		&notif.ID,
+		&notif.UserID,
+		&notif.Type,
+		&notif.Channel,
+		&notif.Title,
+		&notif.Content,
+		&notif.IsRead,
+		&notif.ReadAt,
+		&notif.Status,
+		&notif.ScheduledAt,
+		&notif.CreatedAt,
+		&notif.UpdatedAt,
+	)
+
+	// Another request restored the notification between the check and the update
+	if err == sql.ErrNoRows {
+		return nil, ErrNotificationNotDeleted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore notification: %w", err)
+	}
+
+	return s.toNotificationResponse(&notif), nil
+}
+
+// PurgeDeletedNotifications permanently removes notifications soft-deleted before the cutoff
+func (s *NotificationsService) PurgeDeletedNotifications(ctx context.Context, cutoff time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM notifications WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge deleted notifications: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
+
+// SendEmail sends an email via SendGrid, tagged with the request ID carried by ctx
+func (s *NotificationsService) SendEmail(ctx context.Context, to []string, subject, body string) error {
 	msg := &sendgrid.EmailMessage{
 		To:          to,
 		Subject:     subject,
 		TextContent: body,
 		HTMLContent: fmt.Sprintf("<p>%s</p>", body),
+		RequestID:   utils.RequestIDFromContext(ctx),
 	}
 	return s.sendgrid.SendEmail(msg)
 }
 
-// SendSMS sends an SMS via Twilio
-func (s *NotificationsService) SendSMS(to, body string) error {
+// SendSMS sends an SMS via Twilio, tagged with the request ID carried by ctx
+func (s *NotificationsService) SendSMS(ctx context.Context, to, body string) error {
 	msg := &twilio.SMSMessage{
-		To:   to,
-		Body: body,
+		To:        to,
+		Body:      body,
+		RequestID: utils.RequestIDFromContext(ctx),
 	}
-	return s.twilio.SendSMS(msg)
+	_, err := s.twilio.SendSMS(msg)
+	return err
+}
+
+// UpdateSMSDeliveryStatus applies a Twilio status callback to the matching notification
+func (s *NotificationsService) UpdateSMSDeliveryStatus(ctx context.Context, messageSID, providerStatus, errorCode string) error {
+	// Map Twilio message statuses onto notification statuses
+	var status string
+	switch providerStatus {
+	case "delivered":
+		status = "delivered"
+	case "failed", "undelivered":
+		status = "failed"
+	default: // accepted, queued, sending, sent
+		status = "sent"
+	}
+
+	var errorMsg sql.NullString
+	if errorCode != "" {
+		errorMsg = sql.NullString{String: fmt.Sprintf("Twilio error %s", errorCode), Valid: true}
+	}
+
+	// Callbacks can arrive out of order; never move a final status back to sent
+	query := `
+		UPDATE notifications
+		SET provider_status = $1,
+			status = CASE WHEN $2 = 'sent' AND status IN ('delivered', 'failed') THEN status ELSE $2 END,
+			error_msg = COALESCE($3, error_msg),
+			updated_at = $4
+		WHERE provider = 'twilio' AND provider_id = $5
+	`
+	result, err := s.db.ExecContext(ctx, query, providerStatus, status, errorMsg, clients.Now(), messageSID)
+	if err != nil {
+		return fmt.Errorf("failed to update notification status: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// ValidateTwilioSignature verifies that a webhook request came from Twilio
+func (s *NotificationsService) ValidateTwilioSignature(requestURL string, params url.Values, signature string) bool {
+	return s.twilio.ValidateSignature(requestURL, params, signature)
+}
+
+// TwilioCallbackURL returns the configured public Twilio status callback URL
+func (s *NotificationsService) TwilioCallbackURL() string {
+	return s.twilio.StatusCallbackURL()
+}
+
+// templateVariablePattern matches {{variable}} placeholders
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// renderTemplate substitutes {{variable}} placeholders; unknown placeholders are left as-is
+func renderTemplate(text string, vars map[string]string, escapeHTML bool) string {
+	return templateVariablePattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := templateVariablePattern.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			return match
+		}
+		if escapeHTML {
+			return html.EscapeString(value)
+		}
+		return value
+	})
+}
+
+// SendTemplatedNotification renders a template for every channel it is defined on and sends it.
+// User fields (first_name, last_name, email) are available as variables and can be overridden by vars.
+func (s *NotificationsService) SendTemplatedNotification(ctx context.Context, userID, templateName string, vars map[string]string) ([]*NotificationResponse, error) {
+	templates, err := s.getTemplatesByName(ctx, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if len(templates) == 0 {
+		return nil, ErrTemplateNotFound
+	}
+
+	var firstName, lastName, email string
+	err = s.db.QueryRowContext(ctx,
+		`SELECT first_name, last_name, email FROM users WHERE id = $1 AND deleted_at IS NULL`,
+		userID,
+	).Scan(&firstName, &lastName, &email)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	values := map[string]string{
+		"first_name": firstName,
+		"last_name":  lastName,
+		"email":      email,
+	}
+	for key, value := range vars {
+		values[key] = value
+	}
+
+	var sent []*NotificationResponse
+	for _, tmpl := range templates {
+		req := &SendNotificationRequest{
+			UserID:  userID,
+			Type:    tmpl.Name,
+			Channel: tmpl.Channel,
+			Title:   renderTemplate(tmpl.Title, values, false),
+			Content: renderTemplate(tmpl.Content, values, false),
+		}
+		if tmpl.HTMLContent.Valid {
+			req.HTMLContent = renderTemplate(tmpl.HTMLContent.String, values, true)
+		}
+
+		notification, err := s.SendNotification(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		sent = append(sent, notification)
+	}
+
+	return sent, nil
+}
+
+// getTemplatesByName loads all channel variants of a template
+func (s *NotificationsService) getTemplatesByName(ctx context.Context, name string) ([]*models.NotificationTemplate, error) {
+	query := `
+		SELECT id, name, channel, title, content, html_content, description, created_at, updated_at
+		FROM notification_templates
+		WHERE name = $1
+		ORDER BY channel
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*models.NotificationTemplate
+	for rows.Next() {
+		var tmpl models.NotificationTemplate
+		if err := rows.Scan(
+			&tmpl.ID,
+			&tmpl.Name,
+			&tmpl.Channel,
+			&tmpl.Title,
+			&tmpl.Content,
+			&tmpl.HTMLContent,
+			&tmpl.Description,
+			&tmpl.CreatedAt,
+			&tmpl.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, &tmpl)
+	}
+
+	return templates, nil
+}
+
+// CreateTemplate creates a notification template (admin only)
+func (s *NotificationsService) CreateTemplate(ctx context.Context, req *CreateTemplateRequest) (*TemplateResponse, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM notification_templates WHERE name = $1 AND channel = $2)`,
+		req.Name, req.Channel,
+	).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check template: %w", err)
+	}
+	if exists {
+		return nil, ErrTemplateExists
+	}
+
+	query := `
+		INSERT INTO notification_templates (name, channel, title, content, html_content, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, name, channel, title, content, html_content, description, created_at, updated_at
+	`
+
+	var tmpl models.NotificationTemplate
+	err = s.db.QueryRowContext(ctx, query,
+		req.Name,
+		req.Channel,
+		req.Title,
+		req.Content,
+		sql.NullString{String: req.HTMLContent, Valid: req.HTMLContent != ""},
+		sql.NullString{String: req.Description, Valid: req.Description != ""},
+		clients.Now(),
+	).Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Channel,
+		&tmpl.Title,
+		&tmpl.Content,
+		&tmpl.HTMLContent,
+		&tmpl.Description,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return s.toTemplateResponse(&tmpl), nil
+}
+
+// GetTemplate retrieves a notification template by ID (admin only)
+func (s *NotificationsService) GetTemplate(ctx context.Context, id string) (*TemplateResponse, error) {
+	query := `
+		SELECT id, name, channel, title, content, html_content, description, created_at, updated_at
+		FROM notification_templates
+		WHERE id = $1
+	`
+
+	var tmpl models.NotificationTemplate
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Channel,
+		&tmpl.Title,
+		&tmpl.Content,
+		&tmpl.HTMLContent,
+		&tmpl.Description,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	return s.toTemplateResponse(&tmpl), nil
+}
+
+// ListTemplates lists notification templates (admin only)
+func (s *NotificationsService) ListTemplates(ctx context.Context, page, limit int) (*TemplatesListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notification_templates`).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	query := `
+		SELECT id, name, channel, title, content, html_content, description, created_at, updated_at
+		FROM notification_templates
+		ORDER BY name, channel
+		LIMIT $1 OFFSET $2
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []*TemplateResponse{}
+	for rows.Next() {
+		var tmpl models.NotificationTemplate
+		if err := rows.Scan(
+			&tmpl.ID,
+			&tmpl.Name,
+			&tmpl.Channel,
+			&tmpl.Title,
+			&tmpl.Content,
+			&tmpl.HTMLContent,
+			&tmpl.Description,
+			&tmpl.CreatedAt,
+			&tmpl.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, s.toTemplateResponse(&tmpl))
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	return &TemplatesListResponse{
+		Templates:  templates,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// UpdateTemplate updates a notification template (admin only)
+func (s *NotificationsService) UpdateTemplate(ctx context.Context, id string, req *UpdateTemplateRequest) (*TemplateResponse, error) {
+	query := `UPDATE notification_templates SET updated_at = $1`
+	args := []interface{}{clients.Now()}
+	argCount := 1
+
+	if req.Title != "" {
+		argCount++
+		query += fmt.Sprintf(`, title = $%d`, argCount)
+		args = append(args, req.Title)
+	}
+
+	if req.Content != "" {
+		argCount++
+		query += fmt.Sprintf(`, content = $%d`, argCount)
+		args = append(args, req.Content)
+	}
+
+	if req.HTMLContent != nil {
+		argCount++
+		query += fmt.Sprintf(`, html_content = $%d`, argCount)
+		args = append(args, sql.NullString{String: *req.HTMLContent, Valid: *req.HTMLContent != ""})
+	}
+
+	if req.Description != nil {
+		argCount++
+		query += fmt.Sprintf(`, description = $%d`, argCount)
+		args = append(args, sql.NullString{String: *req.Description, Valid: *req.Description != ""})
+	}
+
+	argCount++
+	query += fmt.Sprintf(` WHERE id = $%d`, argCount)
+	query += ` RETURNING id, name, channel, title, content, html_content, description, created_at, updated_at`
+	args = append(args, id)
+
+	var tmpl models.NotificationTemplate
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
+		&tmpl.ID,
+		&tmpl.Name,
+		&tmpl.Channel,
+		&tmpl.Title,
+		&tmpl.Content,
+		&tmpl.HTMLContent,
+		&tmpl.Description,
+		&tmpl.CreatedAt,
+		&tmpl.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update template: %w", err)
+	}
+
+	return s.toTemplateResponse(&tmpl), nil
+}
+
+// DeleteTemplate deletes a notification template (admin only)
+func (s *NotificationsService) DeleteTemplate(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM notification_templates WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrTemplateNotFound
+	}
+
+	return nil
 }
 
 // Helper functions
 
+func (s *NotificationsService) toTemplateResponse(tmpl *models.NotificationTemplate) *TemplateResponse {
+	resp := &TemplateResponse{
+		ID:        tmpl.ID,
+		Name:      tmpl.Name,
+		Channel:   tmpl.Channel,
+		Title:     tmpl.Title,
+		Content:   tmpl.Content,
+		CreatedAt: tmpl.CreatedAt,
+		UpdatedAt: tmpl.UpdatedAt,
+	}
+
+	if tmpl.HTMLContent.Valid {
+		htmlContent := tmpl.HTMLContent.String
+		resp.HTMLContent = &htmlContent
+	}
+
+	if tmpl.Description.Valid {
+		description := tmpl.Description.String
+		resp.Description = &description
+	}
+
+	return resp
+}
+
 func (s *NotificationsService) toNotificationResponse(notif *models.Notification) *NotificationResponse {
 	resp := &NotificationResponse{
 		ID:        notif.ID,
@@ -232,5 +1428,10 @@ func (s *NotificationsService) toNotificationResponse(notif *models.Notification
 		resp.ReadAt = &readAt
 	}
 
+	if notif.ScheduledAt.Valid {
+		scheduledAt := notif.ScheduledAt.Time
+		resp.ScheduledAt = &scheduledAt
+	}
+
 	return resp
 }
@@ -0,0 +1,123 @@
+package notifications
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"gogin/internal/clients"
+	"gogin/internal/models"
+	"gogin/internal/utils"
+)
+
+// Headers sent with every webhook delivery. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the webhook secret, prefixed with "sha256=".
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+)
+
+// SignWebhookPayload computes the signature header value for a webhook body
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL rejects webhook URLs that aren't https or that name an internal
+// host directly. Hostnames are checked again when the worker connects, after DNS
+// resolution, since their addresses can change.
+func validateWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return ErrWebhookURLNotAllowed
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookURLNotAllowed
+	}
+	if ip := net.ParseIP(host); ip != nil && !utils.IsPublicIP(ip) {
+		return ErrWebhookURLNotAllowed
+	}
+
+	return nil
+}
+
+// SetWebhook registers the URL a user's webhook notifications are posted to. A new
+// signing secret is generated every time and returned only in this response.
+func (s *NotificationsService) SetWebhook(ctx context.Context, userID, url string) (*WebhookResponse, error) {
+	if err := validateWebhookURL(url); err != nil {
+		return nil, err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	secret := "whsec_" + base64.RawURLEncoding.EncodeToString(raw)
+
+	encrypted, err := utils.EncryptString(s.secretKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_webhooks (user_id, url, secret, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id) DO UPDATE SET url = EXCLUDED.url, secret = EXCLUDED.secret, updated_at = EXCLUDED.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	webhook := &WebhookResponse{URL: url, Secret: secret}
+	if err := s.db.QueryRowContext(ctx, query, userID, url, encrypted, clients.Now()).Scan(&webhook.CreatedAt, &webhook.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+// GetWebhook returns a user's registered webhook without its secret
+func (s *NotificationsService) GetWebhook(ctx context.Context, userID string) (*WebhookResponse, error) {
+	var webhook models.UserWebhook
+	err := s.db.QueryRowContext(ctx,
+		`SELECT url, created_at, updated_at FROM user_webhooks WHERE user_id = $1`,
+		userID,
+	).Scan(&webhook.URL, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &WebhookResponse{
+		URL:       webhook.URL,
+		CreatedAt: webhook.CreatedAt,
+		UpdatedAt: webhook.UpdatedAt,
+	}, nil
+}
+
+// DeleteWebhook removes a user's webhook; later webhook notifications fail until a new one is set
+func (s *NotificationsService) DeleteWebhook(ctx context.Context, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM user_webhooks WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
@@ -1,5 +1,11 @@
 package oauth2
 
+import (
+	"time"
+
+	"gogin/internal/response"
+)
+
 // AuthorizeRequest represents an authorization request
 type AuthorizeRequest struct {
 	ClientID            string `json:"client_id" binding:"required"`
@@ -60,3 +66,45 @@ type IntrospectResponse struct {
 	ExpiresAt int64  `json:"exp,omitempty"`
 	IssuedAt  int64  `json:"iat,omitempty"`
 }
+
+// RevokeAllTokensRequest selects whose tokens to revoke; only admins may name another user
+type RevokeAllTokensRequest struct {
+	UserID string `json:"user_id" binding:"omitempty,uuid"`
+}
+
+// TokenInfoResponse describes an issued token without exposing the token itself
+type TokenInfoResponse struct {
+	ID               string     `json:"id"`
+	ClientID         string     `json:"client_id"`
+	ClientName       string     `json:"client_name,omitempty"`
+	UserID           string     `json:"user_id"`
+	Scopes           []string   `json:"scopes"`
+	TokenType        string     `json:"token_type"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// TokensListResponse represents a paginated list of tokens
+type TokensListResponse struct {
+	Tokens []*TokenInfoResponse `json:"tokens"`
+	response.PaginationMeta
+}
+
+// RevokeAllTokensResponse reports how many tokens were revoked
+type RevokeAllTokensResponse struct {
+	Revoked int `json:"revoked"`
+}
+
+// UserInfoResponse holds the OpenID Connect claims about the token's user. Email
+// claims need the email scope and name claims the profile scope.
+type UserInfoResponse struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+	GivenName     string `json:"given_name,omitempty"`
+	FamilyName    string `json:"family_name,omitempty"`
+	Picture       string `json:"picture,omitempty"`
+	UpdatedAt     int64  `json:"updated_at,omitempty"`
+}
@@ -1,11 +1,14 @@
 package oauth2
 
 import (
+	"errors"
+	"io"
 	"net/http"
 
 	"gogin/internal/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // authorize handles authorization requests
@@ -23,10 +26,7 @@ import (
 func (m *OAuth2Module) authorize(c *gin.Context) {
 	var req AuthorizeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
@@ -38,7 +38,7 @@ func (m *OAuth2Module) authorize(c *gin.Context) {
 	}
 
 	// Create authorization code
-	authCode, err := m.service.CreateAuthorizationCode(userID.(string), &req)
+	authCode, err := m.service.CreateAuthorizationCode(c.Request.Context(), userID.(string), &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -64,10 +64,7 @@ func (m *OAuth2Module) authorize(c *gin.Context) {
 func (m *OAuth2Module) token(c *gin.Context) {
 	var req TokenRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
@@ -76,11 +73,11 @@ func (m *OAuth2Module) token(c *gin.Context) {
 
 	switch req.GrantType {
 	case "authorization_code":
-		tokenResp, err = m.service.ExchangeCodeForToken(&req)
+		tokenResp, err = m.service.ExchangeCodeForToken(c.Request.Context(), &req)
 	case "client_credentials":
-		tokenResp, err = m.service.ClientCredentialsGrant(&req)
+		tokenResp, err = m.service.ClientCredentialsGrant(c.Request.Context(), &req)
 	case "refresh_token":
-		tokenResp, err = m.service.RefreshTokenGrant(&req)
+		tokenResp, err = m.service.RefreshTokenGrant(c.Request.Context(), &req)
 	default:
 		response.BadRequest(c, "Unsupported grant type")
 		return
@@ -109,14 +106,11 @@ func (m *OAuth2Module) token(c *gin.Context) {
 func (m *OAuth2Module) revoke(c *gin.Context) {
 	var req RevokeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	err := m.service.RevokeToken(req.Token)
+	err := m.service.RevokeToken(c.Request.Context(), req.Token)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -140,10 +134,7 @@ func (m *OAuth2Module) revoke(c *gin.Context) {
 func (m *OAuth2Module) introspect(c *gin.Context) {
 	var req IntrospectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
@@ -155,3 +146,135 @@ func (m *OAuth2Module) introspect(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Token introspected successfully", result)
 }
+
+// jwks publishes the public token signing keys
+// @Summary JSON Web Key Set
+// @Description Public keys for verifying RS256 access tokens, matched by the kid token header
+// @Tags OAuth2
+// @Produce json
+// @Success 200 {object} utils.JWKSet
+// @Router /.well-known/jwks.json [get]
+func (m *OAuth2Module) jwks(c *gin.Context) {
+	// Served as a bare key set, the format resource servers expect
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, m.jwtUtil.JWKS())
+}
+
+// userInfo returns OpenID Connect claims about the token's user
+// @Summary OpenID Connect UserInfo
+// @Description Claims about the user the access token was issued for. sub is always returned; email and email_verified need the email scope, and name, given_name, family_name, picture and updated_at need the profile scope.
+// @Tags OAuth2
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} UserInfoResponse
+// @Failure 401 {object} response.Response
+// @Router /oauth/userinfo [get]
+func (m *OAuth2Module) userInfo(c *gin.Context) {
+	userID, ok := c.Get("user_id")
+	if !ok {
+		response.Unauthorized(c, "Token is not issued for a user")
+		return
+	}
+	scopes, _ := c.Get("scopes")
+	scopeList, _ := scopes.([]string)
+
+	info, err := m.service.UserInfo(c.Request.Context(), userID.(string), scopeList)
+	if errors.Is(err, ErrUserNotFound) {
+		response.Unauthorized(c, "Token user no longer exists")
+		return
+	}
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get user info")
+		return
+	}
+
+	// Served as bare claims, the format OpenID Connect clients expect
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, info)
+}
+
+// listTokens lists active tokens
+// @Summary List Tokens
+// @Description List the caller's active tokens with their client, scopes and expiry. Admins may pass user_id to list another user's tokens.
+// @Tags OAuth2
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "User ID (admin only)"
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Param cursor query string false "Cursor from the previous page's next_cursor"
+// @Success 200 {object} response.Response{data=TokensListResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /oauth/tokens [get]
+func (m *OAuth2Module) listTokens(c *gin.Context) {
+	userID, ok := m.tokenOwner(c, c.Query("user_id"))
+	if !ok {
+		return
+	}
+
+	tokens, err := m.service.ListTokens(c.Request.Context(), userID, response.ParsePagination(c))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to list tokens")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tokens retrieved successfully", tokens)
+}
+
+// revokeAllTokens revokes every active token of a user
+// @Summary Revoke All Tokens
+// @Description Revoke all of the caller's active tokens, e.g. after a suspected credential compromise. Admins may pass user_id to revoke another user's tokens.
+// @Tags OAuth2
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body RevokeAllTokensRequest false "Revoke all request"
+// @Success 200 {object} response.Response{data=RevokeAllTokensResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /oauth/tokens/revoke-all [post]
+func (m *OAuth2Module) revokeAllTokens(c *gin.Context) {
+	// The body is optional; an empty one targets the caller
+	var req RevokeAllTokensRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		response.BindError(c, err)
+		return
+	}
+
+	userID, ok := m.tokenOwner(c, req.UserID)
+	if !ok {
+		return
+	}
+
+	revoked, err := m.service.RevokeAllUserTokens(c.Request.Context(), userID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to revoke tokens")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tokens revoked successfully", RevokeAllTokensResponse{Revoked: revoked})
+}
+
+// tokenOwner resolves whose tokens a request targets: the caller by default, or the
+// requested user for admins. It writes the error response and returns false otherwise.
+func (m *OAuth2Module) tokenOwner(c *gin.Context, requested string) (string, bool) {
+	userID, _ := c.Get("user_id")
+	role, _ := c.Get("role")
+
+	if requested == "" || requested == userID {
+		return userID.(string), true
+	}
+	if role != "admin" {
+		response.Forbidden(c, "Only admins can manage other users' tokens")
+		return "", false
+	}
+	if _, err := uuid.Parse(requested); err != nil {
+		response.BadRequest(c, "Invalid user_id")
+		return "", false
+	}
+
+	return requested, true
+}
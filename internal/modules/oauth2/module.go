@@ -1,6 +1,8 @@
 package oauth2
 
 import (
+	"time"
+
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/middleware"
@@ -23,7 +25,7 @@ type OAuth2Module struct {
 // NewOAuth2Module creates a new OAuth2 module
 func NewOAuth2Module(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *OAuth2Module {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	service := NewOAuth2Service(db, redisHelper, jwtUtil, cfg)
 
 	return &OAuth2Module{
@@ -45,8 +47,17 @@ func (m *OAuth2Module) RegisterRoutes(router *gin.RouterGroup) {
 		oauth.POST("/authorize", authMiddleware.RequireAuth(), m.authorize)
 		oauth.POST("/revoke", authMiddleware.RequireAuth(), m.revoke)
 		oauth.POST("/introspect", authMiddleware.RequireAuth(), m.introspect)
+		oauth.GET("/userinfo", authMiddleware.RequireAuth(), m.userInfo)
+		oauth.GET("/tokens", authMiddleware.RequireAuth(), m.listTokens)
+		oauth.POST("/tokens/revoke-all", authMiddleware.RequireAuth(), m.revokeAllTokens)
 
-		// Public endpoint (no authentication required)
-		oauth.POST("/token", m.token)
+		// Public endpoint (no authentication required), limited more tightly to deter secret guessing
+		rateLimiter := middleware.NewRateLimiter(m.redis, m.config.App.RateLimitRPS, time.Minute)
+		oauth.POST("/token", rateLimiter.LimitRoute(m.config.App.AuthRateLimit, time.Minute), m.token)
 	}
 }
+
+// RegisterWellKnownRoutes registers discovery routes served from the site root
+func (m *OAuth2Module) RegisterWellKnownRoutes(router *gin.Engine) {
+	router.GET("/.well-known/jwks.json", m.jwks)
+}
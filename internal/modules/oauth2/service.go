@@ -1,10 +1,12 @@
 package oauth2
 
 import (
+	"context"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/base64"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -12,9 +14,16 @@ import (
 	"gogin/internal/config"
 	"gogin/internal/models"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/response"
 	"gogin/internal/utils"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Errors returned by the OAuth2 service; match them with errors.Is
+var (
+	ErrUserNotFound = response.NotFoundError("user not found")
 )
 
 // OAuth2Service handles OAuth2 business logic
@@ -36,9 +45,9 @@ func NewOAuth2Service(db *clients.Database, redisHelper *redishelper.RedisHelper
 }
 
 // CreateAuthorizationCode creates an authorization code
-func (s *OAuth2Service) CreateAuthorizationCode(userID string, req *AuthorizeRequest) (*models.OAuthAuthorizationCode, error) {
+func (s *OAuth2Service) CreateAuthorizationCode(ctx context.Context, userID string, req *AuthorizeRequest) (*models.OAuthAuthorizationCode, error) {
 	// Verify client
-	client, err := s.GetClientByClientID(req.ClientID)
+	client, err := s.GetClientByClientID(ctx, req.ClientID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid client")
 	}
@@ -54,7 +63,8 @@ func (s *OAuth2Service) CreateAuthorizationCode(userID string, req *AuthorizeReq
 
 	// Generate authorization code
 	code := uuid.New().String()
-	expiresAt := time.Now().Add(10 * time.Minute) // 10 minute expiry
+	now := clients.Now()
+	expiresAt := now.Add(10 * time.Minute) // 10 minute expiry
 
 	authCode := &models.OAuthAuthorizationCode{
 		ID:          uuid.New().String(),
@@ -75,10 +85,10 @@ func (s *OAuth2Service) CreateAuthorizationCode(userID string, req *AuthorizeReq
 	query := `
 		INSERT INTO oauth_authorization_codes
 		(id, code, client_id, user_id, redirect_uri, scopes, code_challenge, code_challenge_method, expires_at, is_used, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 	`
 
-	_, err = s.db.Exec(query,
+	_, err = s.db.ExecContext(ctx, query,
 		authCode.ID,
 		authCode.Code,
 		authCode.ClientID,
@@ -89,6 +99,7 @@ func (s *OAuth2Service) CreateAuthorizationCode(userID string, req *AuthorizeReq
 		authCode.CodeChallengeMethod,
 		authCode.ExpiresAt,
 		authCode.IsUsed,
+		now,
 	)
 
 	if err != nil {
@@ -99,17 +110,20 @@ func (s *OAuth2Service) CreateAuthorizationCode(userID string, req *AuthorizeReq
 }
 
 // ExchangeCodeForToken exchanges authorization code for access token
-func (s *OAuth2Service) ExchangeCodeForToken(req *TokenRequest) (*TokenResponse, error) {
-	// Get authorization code
+func (s *OAuth2Service) ExchangeCodeForToken(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
+	// Claim the code atomically so concurrent exchanges of the same code can't both
+	// succeed. The code is spent even if a check below fails, so a leaked code can't
+	// be retried with guessed verifiers or secrets.
 	var authCode models.OAuthAuthorizationCode
 	query := `
-		SELECT id, code, client_id, user_id, redirect_uri, scopes,
-		       code_challenge, code_challenge_method, expires_at, is_used, created_at
-		FROM oauth_authorization_codes
+		UPDATE oauth_authorization_codes
+		SET is_used = TRUE
 		WHERE code = $1 AND is_used = FALSE
+		RETURNING id, code, client_id, user_id, redirect_uri, scopes,
+		          code_challenge, code_challenge_method, expires_at, is_used, created_at
 	`
 
-	err := s.db.QueryRow(query, req.Code).Scan(
+	err := s.db.QueryRowContext(ctx, query, req.Code).Scan(
 		&authCode.ID,
 		&authCode.Code,
 		&authCode.ClientID,
@@ -149,14 +163,8 @@ func (s *OAuth2Service) ExchangeCodeForToken(req *TokenRequest) (*TokenResponse,
 		}
 	}
 
-	// Mark code as used
-	_, err = s.db.Exec("UPDATE oauth_authorization_codes SET is_used = TRUE WHERE id = $1", authCode.ID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Get client for scope validation
-	client, err := s.GetClientByClientID(req.ClientID)
+	client, err := s.GetClientByClientID(ctx, req.ClientID)
 	if err != nil {
 		return nil, err
 	}
@@ -170,13 +178,13 @@ func (s *OAuth2Service) ExchangeCodeForToken(req *TokenRequest) (*TokenResponse,
 
 	// Generate tokens
 	scopes := strings.Split(authCode.Scopes, " ")
-	return s.generateTokens(authCode.UserID, req.ClientID, scopes)
+	return s.generateTokens(ctx, authCode.UserID, req.ClientID, "", scopes)
 }
 
 // ClientCredentialsGrant handles client credentials grant
-func (s *OAuth2Service) ClientCredentialsGrant(req *TokenRequest) (*TokenResponse, error) {
+func (s *OAuth2Service) ClientCredentialsGrant(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
 	// Get and verify client
-	client, err := s.GetClientByClientID(req.ClientID)
+	client, err := s.GetClientByClientID(ctx, req.ClientID)
 	if err != nil {
 		return nil, fmt.Errorf("invalid client")
 	}
@@ -212,18 +220,21 @@ func (s *OAuth2Service) ClientCredentialsGrant(req *TokenRequest) (*TokenRespons
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
-	expiresAt := time.Now().Add(s.config.OAuth.AccessTokenExpiry)
+	now := clients.Now()
+	expiresAt := now.Add(s.config.OAuth.AccessTokenExpiry)
 
 	// Store token
-	_, err = s.db.Exec(`
+	_, err = s.db.ExecContext(ctx, `
 		INSERT INTO oauth_tokens (id, access_token, token_type, expires_at, scopes, client_id, is_revoked, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
-	`, uuid.New().String(), accessToken, "Bearer", expiresAt, scope, req.ClientID, false)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+	`, uuid.New().String(), accessToken, "Bearer", expiresAt, scope, req.ClientID, false, now)
 
 	if err != nil {
 		return nil, err
 	}
 
+	s.touchClient(ctx, req.ClientID)
+
 	return &TokenResponse{
 		AccessToken: accessToken,
 		TokenType:   "Bearer",
@@ -233,10 +244,10 @@ func (s *OAuth2Service) ClientCredentialsGrant(req *TokenRequest) (*TokenRespons
 }
 
 // RefreshTokenGrant handles refresh token grant
-func (s *OAuth2Service) RefreshTokenGrant(req *TokenRequest) (*TokenResponse, error) {
+func (s *OAuth2Service) RefreshTokenGrant(ctx context.Context, req *TokenRequest) (*TokenResponse, error) {
 	// Validate refresh token
 	claims, err := s.jwtUtil.ValidateToken(req.RefreshToken)
-	if err != nil {
+	if err != nil || !s.jwtUtil.AcceptsRefreshToken(claims) {
 		return nil, fmt.Errorf("invalid refresh token")
 	}
 
@@ -251,12 +262,20 @@ func (s *OAuth2Service) RefreshTokenGrant(req *TokenRequest) (*TokenResponse, er
 		return nil, fmt.Errorf("client mismatch")
 	}
 
-	// Generate new tokens
-	return s.generateTokens(claims.UserID, req.ClientID, claims.Scopes)
+	// Refresh tokens from a revoked login session can't be used
+	if claims.SessionID != "" {
+		active, err := s.redisHelper.SessionExists(claims.SessionID)
+		if err == nil && !active {
+			return nil, fmt.Errorf("session has been revoked")
+		}
+	}
+
+	// Generate new tokens, keeping them in the same session
+	return s.generateTokens(ctx, claims.UserID, req.ClientID, claims.SessionID, claims.Scopes)
 }
 
 // RevokeToken revokes an access or refresh token
-func (s *OAuth2Service) RevokeToken(token string) error {
+func (s *OAuth2Service) RevokeToken(ctx context.Context, token string) error {
 	// Validate token to get claims
 	claims, err := s.jwtUtil.ValidateToken(token)
 	if err != nil {
@@ -265,7 +284,150 @@ func (s *OAuth2Service) RevokeToken(token string) error {
 
 	// Add to revocation list
 	expiresAt := claims.ExpiresAt.Time
-	return s.redisHelper.RevokeToken(claims.TokenID, expiresAt)
+	if err := s.redisHelper.RevokeToken(claims.TokenID, expiresAt); err != nil {
+		return err
+	}
+
+	// Keep the stored token in step so it no longer shows up as active
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE oauth_tokens SET is_revoked = TRUE, updated_at = $1 WHERE (access_token = $2 OR refresh_token = $2) AND is_revoked = FALSE`,
+		clients.Now(), token,
+	)
+	if err != nil {
+		log.Printf("Failed to mark token %s revoked: %v", claims.TokenID, err)
+	}
+
+	return nil
+}
+
+// activeTokensCondition matches a user's tokens that have not been revoked and can
+// still be used, either directly or through their refresh token
+const activeTokensCondition = `
+	t.user_id = $1 AND t.is_revoked = FALSE
+	AND (t.expires_at > $2 OR (t.refresh_token IS NOT NULL AND t.created_at > $3))
+`
+
+// activeTokenArgs binds the placeholders of activeTokensCondition
+func (s *OAuth2Service) activeTokenArgs(userID string) []interface{} {
+	now := clients.Now()
+	return []interface{}{userID, now, now.Add(-s.config.OAuth.RefreshTokenExpiry)}
+}
+
+// ListTokens lists a user's active tokens, newest first
+func (s *OAuth2Service) ListTokens(ctx context.Context, userID string, pagination response.Pagination) (*TokensListResponse, error) {
+	args := s.activeTokenArgs(userID)
+
+	var total int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM oauth_tokens t WHERE`+activeTokensCondition, args...).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tokens: %w", err)
+	}
+
+	// Page by keyset after the cursor when given, otherwise by offset. One extra row
+	// is fetched to tell whether another page follows.
+	query := `
+		SELECT t.id, t.client_id, COALESCE(c.name, ''), t.scopes, t.token_type, t.expires_at,
+		       t.refresh_token IS NOT NULL, t.created_at
+		FROM oauth_tokens t
+		LEFT JOIN oauth_clients c ON c.client_id = t.client_id
+		WHERE` + activeTokensCondition
+	if pagination.Cursor != nil {
+		query += ` AND (t.created_at, t.id) < ($4::timestamp, $5) ORDER BY t.created_at DESC, t.id DESC LIMIT $6`
+		args = append(args, pagination.Cursor.CreatedAtParam(), pagination.Cursor.ID, pagination.Limit+1)
+	} else {
+		query += ` ORDER BY t.created_at DESC, t.id DESC LIMIT $4 OFFSET $5`
+		args = append(args, pagination.Limit+1, pagination.Offset)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*TokenInfoResponse{}
+	for rows.Next() {
+		token := &TokenInfoResponse{UserID: userID}
+		var scopes string
+		var hasRefresh bool
+		err := rows.Scan(&token.ID, &token.ClientID, &token.ClientName, &scopes, &token.TokenType, &token.ExpiresAt, &hasRefresh, &token.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan token: %w", err)
+		}
+		token.Scopes = strings.Fields(scopes)
+		if hasRefresh {
+			refreshExpiresAt := token.CreatedAt.Add(s.config.OAuth.RefreshTokenExpiry)
+			token.RefreshExpiresAt = &refreshExpiresAt
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	meta := pagination.Meta(total)
+	if len(tokens) > pagination.Limit {
+		tokens = tokens[:pagination.Limit]
+		last := tokens[len(tokens)-1]
+		meta.NextCursor = response.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	return &TokensListResponse{Tokens: tokens, PaginationMeta: meta}, nil
+}
+
+// RevokeAllUserTokens revokes every active token of a user. The token IDs are added
+// to the revocation list before the rows are marked revoked, so a Redis failure
+// leaves the tokens listed as active and the call can be retried.
+func (s *OAuth2Service) RevokeAllUserTokens(ctx context.Context, userID string) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT t.id, t.access_token, t.refresh_token FROM oauth_tokens t WHERE`+activeTokensCondition, s.activeTokenArgs(userID)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list tokens: %w", err)
+	}
+
+	var ids, tokenIDs []string
+	for rows.Next() {
+		var id, accessToken string
+		var refreshToken sql.NullString
+		if err := rows.Scan(&id, &accessToken, &refreshToken); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan token: %w", err)
+		}
+		ids = append(ids, id)
+
+		for _, token := range []string{accessToken, refreshToken.String} {
+			if token == "" {
+				continue
+			}
+			tokenID, err := s.jwtUtil.GetTokenID(token)
+			if err != nil {
+				log.Printf("Failed to read token ID of stored token %s: %v", id, err)
+				continue
+			}
+			tokenIDs = append(tokenIDs, tokenID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return 0, fmt.Errorf("failed to list tokens: %w", err)
+	}
+	rows.Close()
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// No token outlives a refresh token, so that bounds how long the entries are needed
+	expiresAt := clients.Now().Add(s.config.OAuth.RefreshTokenExpiry)
+	if err := s.redisHelper.RevokeAllUserTokens(userID, tokenIDs, expiresAt); err != nil {
+		return 0, fmt.Errorf("failed to revoke tokens: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `UPDATE oauth_tokens SET is_revoked = TRUE, updated_at = $1 WHERE id = ANY($2)`, clients.Now(), pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark tokens revoked: %w", err)
+	}
+
+	return len(ids), nil
 }
 
 // IntrospectToken introspects a token
@@ -293,8 +455,41 @@ func (s *OAuth2Service) IntrospectToken(token string) (*IntrospectResponse, erro
 	}, nil
 }
 
+// UserInfo returns the OpenID Connect claims of a user that the token's scopes allow
+func (s *OAuth2Service) UserInfo(ctx context.Context, userID string, scopes []string) (*UserInfoResponse, error) {
+	var user models.User
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, email, first_name, last_name, avatar, email_verified, updated_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+	`, userID).Scan(&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Avatar, &user.EmailVerified, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	info := &UserInfoResponse{Subject: user.ID}
+	for _, scope := range scopes {
+		switch scope {
+		case "email":
+			info.Email = user.Email
+			info.EmailVerified = &user.EmailVerified
+		case "profile":
+			info.Name = strings.TrimSpace(user.FirstName + " " + user.LastName)
+			info.GivenName = user.FirstName
+			info.FamilyName = user.LastName
+			info.Picture = user.Avatar.String
+			info.UpdatedAt = user.UpdatedAt.Unix()
+		}
+	}
+
+	return info, nil
+}
+
 // GetClientByClientID retrieves a client by client ID
-func (s *OAuth2Service) GetClientByClientID(clientID string) (*models.OAuthClient, error) {
+func (s *OAuth2Service) GetClientByClientID(ctx context.Context, clientID string) (*models.OAuthClient, error) {
 	var client models.OAuthClient
 	query := `
 		SELECT id, client_id, client_secret, name, description, redirect_uris,
@@ -303,7 +498,7 @@ func (s *OAuth2Service) GetClientByClientID(clientID string) (*models.OAuthClien
 		WHERE client_id = $1 AND deleted_at IS NULL
 	`
 
-	err := s.db.QueryRow(query, clientID).Scan(
+	err := s.db.QueryRowContext(ctx, query, clientID).Scan(
 		&client.ID,
 		&client.ClientID,
 		&client.ClientSecret,
@@ -329,9 +524,10 @@ func (s *OAuth2Service) GetClientByClientID(clientID string) (*models.OAuthClien
 
 // Helper functions
 
-func (s *OAuth2Service) generateTokens(userID, clientID string, scopes []string) (*TokenResponse, error) {
+func (s *OAuth2Service) generateTokens(ctx context.Context, userID, clientID, sessionID string, scopes []string) (*TokenResponse, error) {
 	// Generate access token
-	accessToken, _, err := s.jwtUtil.GenerateAccessToken(
+	accessToken, _, err := s.jwtUtil.GenerateSessionAccessToken(
+		sessionID,
 		userID,
 		clientID,
 		"",
@@ -343,7 +539,8 @@ func (s *OAuth2Service) generateTokens(userID, clientID string, scopes []string)
 	}
 
 	// Generate refresh token
-	refreshToken, _, err := s.jwtUtil.GenerateRefreshToken(
+	refreshToken, _, err := s.jwtUtil.GenerateSessionRefreshToken(
+		sessionID,
 		userID,
 		clientID,
 		s.config.OAuth.RefreshTokenExpiry,
@@ -353,16 +550,19 @@ func (s *OAuth2Service) generateTokens(userID, clientID string, scopes []string)
 	}
 
 	// Store tokens
-	expiresAt := time.Now().Add(s.config.OAuth.AccessTokenExpiry)
-	_, err = s.db.Exec(`
+	now := clients.Now()
+	expiresAt := now.Add(s.config.OAuth.AccessTokenExpiry)
+	_, err = s.db.ExecContext(ctx, `
 		INSERT INTO oauth_tokens (id, access_token, refresh_token, token_type, expires_at, scopes, client_id, user_id, is_revoked, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
-	`, uuid.New().String(), accessToken, refreshToken, "Bearer", expiresAt, strings.Join(scopes, " "), clientID, userID, false)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10)
+	`, uuid.New().String(), accessToken, refreshToken, "Bearer", expiresAt, strings.Join(scopes, " "), clientID, userID, false, now)
 
 	if err != nil {
 		return nil, err
 	}
 
+	s.touchClient(ctx, clientID)
+
 	return &TokenResponse{
 		AccessToken:  accessToken,
 		TokenType:    "Bearer",
@@ -372,6 +572,13 @@ func (s *OAuth2Service) generateTokens(userID, clientID string, scopes []string)
 	}, nil
 }
 
+// touchClient records that a token was just issued for the client
+func (s *OAuth2Service) touchClient(ctx context.Context, clientID string) {
+	if _, err := s.db.ExecContext(ctx, `UPDATE oauth_clients SET last_used_at = $1 WHERE client_id = $2`, clients.Now(), clientID); err != nil {
+		log.Printf("Failed to update last_used_at for client %s: %v", clientID, err)
+	}
+}
+
 func (s *OAuth2Service) validateRedirectURI(client *models.OAuthClient, redirectURI string) bool {
 	// Simple validation - should be in client's allowed redirect URIs
 	return strings.Contains(client.RedirectURIs, redirectURI)
@@ -0,0 +1,81 @@
+package oauth2
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"gogin/internal/config"
+	"gogin/internal/testutil"
+	"gogin/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+func TestExchangeCodeForTokenSingleUse(t *testing.T) {
+	db := testutil.Database(t)
+
+	userID := testutil.CreateUser(t, db, "user")
+	clientID := "test-client-" + uuid.New().String()
+	code := "test-code-" + uuid.New().String()
+	redirectURI := "https://example.com/callback"
+
+	// Registered after the user's cleanup so it runs first; the client holds a reference to the user
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM oauth_tokens WHERE client_id = $1`, clientID)
+		db.Exec(`DELETE FROM oauth_authorization_codes WHERE client_id = $1`, clientID)
+		db.Exec(`DELETE FROM oauth_clients WHERE client_id = $1`, clientID)
+	})
+
+	if _, err := db.Exec(`
+		INSERT INTO oauth_clients (client_id, client_secret, name, redirect_uris, scopes, grant_types, is_public, created_by)
+		VALUES ($1, '', 'Test client', $2, 'read', 'authorization_code', TRUE, $3)
+	`, clientID, `["`+redirectURI+`"]`, userID); err != nil {
+		t.Fatalf("insert client: %v", err)
+	}
+	if _, err := db.Exec(`
+		INSERT INTO oauth_authorization_codes (code, client_id, user_id, redirect_uri, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, 'read', $5)
+	`, code, clientID, userID, redirectURI, time.Now().UTC().Add(time.Minute)); err != nil {
+		t.Fatalf("insert authorization code: %v", err)
+	}
+
+	cfg := &config.Config{OAuth: config.OAuthConfig{
+		AccessTokenExpiry:  time.Hour,
+		RefreshTokenExpiry: time.Hour,
+	}}
+	service := NewOAuth2Service(db, nil, utils.NewJWTUtil("test-secret-with-at-least-32-characters", "test"), cfg)
+
+	const exchanges = 2
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		errs      = make([]error, exchanges)
+		succeeded = 0
+	)
+	for i := 0; i < exchanges; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = service.ExchangeCodeForToken(context.Background(), &TokenRequest{
+				GrantType:   "authorization_code",
+				Code:        code,
+				RedirectURI: redirectURI,
+				ClientID:    clientID,
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+		}
+	}
+	if succeeded != 1 {
+		t.Fatalf("%d of %d exchanges succeeded, want exactly 1 (errors: %v)", succeeded, exchanges, errs)
+	}
+}
@@ -7,8 +7,15 @@ import (
 	"time"
 
 	"gogin/internal/clients"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
+// cacheLoads coalesces concurrent GetOrSet loads of the same key within this process
+var cacheLoads singleflight.Group
+
 // RedisHelper provides utility functions for Redis operations
 type RedisHelper struct {
 	redis *clients.RedisClient
@@ -71,6 +78,40 @@ func (r *RedisHelper) GetSession(sessionID string) (map[string]interface{}, erro
 	return data, nil
 }
 
+// SessionExists reports whether a session is still active
+func (r *RedisHelper) SessionExists(sessionID string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.redis.Exists(ctx, fmt.Sprintf("session:%s", sessionID))
+}
+
+// ListUserSessions returns the data of each active session of a user, with its ID
+// under "id". Expired sessions are pruned from the user's session list.
+func (r *RedisHelper) ListUserSessions(userID string) ([]map[string]interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userSessionsKey := fmt.Sprintf("user_sessions:%s", userID)
+	sessionIDs, err := r.redis.GetClient().SMembers(ctx, userSessionsKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user sessions: %w", err)
+	}
+
+	sessions := make([]map[string]interface{}, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, err := r.GetSession(sessionID)
+		if err != nil {
+			r.redis.SRem(ctx, userSessionsKey, sessionID)
+			continue
+		}
+		session["id"] = sessionID
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
 // DeleteSession removes a user session
 func (r *RedisHelper) DeleteSession(sessionID string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -192,6 +233,36 @@ func (r *RedisHelper) CacheGet(key string, dest interface{}) error {
 	return nil
 }
 
+// GetOrSet reads key from cache into dest, calling loader and caching its result on a miss.
+// Concurrent misses for the same key share a single loader call. Loader errors are not cached.
+func (r *RedisHelper) GetOrSet(key string, ttl time.Duration, dest interface{}, loader func() (interface{}, error)) error {
+	if err := r.CacheGet(key, dest); err == nil {
+		return nil
+	}
+
+	data, err, _ := cacheLoads.Do(key, func() (interface{}, error) {
+		value, err := loader()
+		if err != nil {
+			return nil, err
+		}
+
+		// Best-effort: a failed cache write still returns the loaded value
+		r.CacheSet(key, value, ttl)
+
+		return json.Marshal(value)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Each caller decodes its own copy so results are never shared between goroutines
+	if err := json.Unmarshal(data.([]byte), dest); err != nil {
+		return fmt.Errorf("failed to unmarshal loaded data: %w", err)
+	}
+
+	return nil
+}
+
 // CacheDelete removes data from cache
 func (r *RedisHelper) CacheDelete(key string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -271,21 +342,54 @@ func (r *RedisHelper) GetCounter(key string) (int64, error) {
 
 // Lock Operations (distributed locking)
 
-// AcquireLock acquires a distributed lock
-func (r *RedisHelper) AcquireLock(key string, ttl time.Duration) (bool, error) {
+// releaseLockScript deletes the lock only while it is still held by the given owner token
+var releaseLockScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// extendLockScript refreshes the lock TTL only while it is still held by the given owner token
+var extendLockScript = goredis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// AcquireLock acquires a distributed lock, returning the owner token needed to release or extend it
+func (r *RedisHelper) AcquireLock(key string, ttl time.Duration) (string, bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	lockKey := fmt.Sprintf("lock:%s", key)
-	result, err := r.redis.GetClient().SetNX(ctx, lockKey, "locked", ttl).Result()
-	return result, err
+	token := uuid.New().String()
+	acquired, err := r.redis.GetClient().SetNX(ctx, lockKey, token, ttl).Result()
+	if err != nil || !acquired {
+		return "", false, err
+	}
+	return token, true, nil
 }
 
-// ReleaseLock releases a distributed lock
-func (r *RedisHelper) ReleaseLock(key string) error {
+// ReleaseLock releases a distributed lock if it is still held by token
+func (r *RedisHelper) ReleaseLock(key string, token string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	lockKey := fmt.Sprintf("lock:%s", key)
-	return r.redis.Del(ctx, lockKey)
+	return releaseLockScript.Run(ctx, r.redis.GetClient(), []string{lockKey}, token).Err()
+}
+
+// ExtendLock resets the TTL of a lock still held by token, reporting false if it was lost
+func (r *RedisHelper) ExtendLock(key string, token string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lockKey := fmt.Sprintf("lock:%s", key)
+	extended, err := extendLockScript.Run(ctx, r.redis.GetClient(), []string{lockKey}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return extended == 1, nil
 }
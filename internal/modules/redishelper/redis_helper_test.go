@@ -0,0 +1,56 @@
+package redishelper
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gogin/internal/testutil"
+
+	"github.com/google/uuid"
+)
+
+func TestGetOrSetLoadsOnceForConcurrentMisses(t *testing.T) {
+	helper := NewRedisHelper(testutil.Redis(t))
+	key := "test:get_or_set:" + uuid.New().String()
+	t.Cleanup(func() { helper.CacheDelete(key) })
+
+	var loads atomic.Int32
+	loader := func() (interface{}, error) {
+		loads.Add(1)
+		// Keep the load in flight long enough for every caller to miss
+		time.Sleep(100 * time.Millisecond)
+		return map[string]string{"value": "loaded"}, nil
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			var got map[string]string
+			if err := helper.GetOrSet(key, time.Minute, &got, loader); err != nil {
+				errs <- err
+				return
+			}
+			if got["value"] != "loaded" {
+				t.Errorf("GetOrSet value = %v, want loaded", got)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("GetOrSet: %v", err)
+	}
+	if n := loads.Load(); n != 1 {
+		t.Errorf("loader called %d times, want 1", n)
+	}
+}
@@ -1,6 +1,10 @@
 package reviews
 
-import "time"
+import (
+	"time"
+
+	"gogin/internal/response"
+)
 
 // CreateReviewRequest represents a review creation request
 type CreateReviewRequest struct {
@@ -18,26 +22,48 @@ type UpdateReviewRequest struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// UpdateReviewStatusRequest represents a moderation status change
+type UpdateReviewStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=published pending rejected"`
+	Reason string `json:"reason"`
+}
+
+// ReviewReplyRequest represents a resource owner's response to a review
+type ReviewReplyRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// ReviewReplyResponse represents a resource owner's response to a review
+type ReviewReplyResponse struct {
+	ID          string    `json:"id"`
+	ResponderID *string   `json:"responder_id,omitempty"`
+	Content     string    `json:"content"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
 // ReviewResponse represents a review response
 type ReviewResponse struct {
-	ID           string    `json:"id"`
-	ResourceType string    `json:"resource_type"`
-	ResourceID   string    `json:"resource_id"`
-	UserID       string    `json:"user_id"`
-	Rating       int       `json:"rating"`
-	Title        string    `json:"title"`
-	Content      string    `json:"content"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID               string               `json:"id"`
+	ResourceType     string               `json:"resource_type"`
+	ResourceID       string               `json:"resource_id"`
+	UserID           string               `json:"user_id"`
+	Rating           int                  `json:"rating"`
+	Title            string               `json:"title"`
+	Content          string               `json:"content"`
+	Status           string               `json:"status"`
+	ModeratedBy      *string              `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time           `json:"moderated_at,omitempty"`
+	ModerationReason *string              `json:"moderation_reason,omitempty"`
+	HelpfulCount     int                  `json:"helpful_count"`
+	Response         *ReviewReplyResponse `json:"response,omitempty"`
+	CreatedAt        time.Time            `json:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at"`
 }
 
 // ReviewsListResponse represents a paginated list of reviews
 type ReviewsListResponse struct {
-	Reviews      []*ReviewResponse `json:"reviews"`
-	Total        int               `json:"total"`
-	AverageRating float64          `json:"average_rating"`
-	Page         int               `json:"page"`
-	Limit        int               `json:"limit"`
-	TotalPages   int               `json:"total_pages"`
+	Reviews       []*ReviewResponse `json:"reviews"`
+	AverageRating *float64          `json:"average_rating,omitempty"` // only set for a single resource's reviews
+	response.PaginationMeta
 }
@@ -2,7 +2,6 @@ package reviews
 
 import (
 	"net/http"
-	"strconv"
 
 	"gogin/internal/response"
 
@@ -20,13 +19,13 @@ import (
 func (m *ReviewsModule) createReview(c *gin.Context) {
 	var req CreateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, []response.ResponseError{response.NewError("VALIDATION_ERROR", err.Error(), "")})
+		response.BindError(c, err)
 		return
 	}
 	userID, _ := c.Get("user_id")
-	review, err := m.service.CreateReview(userID.(string), &req)
+	review, err := m.service.CreateReview(c.Request.Context(), userID.(string), &req)
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to create review")
 		return
 	}
 	response.Success(c, http.StatusCreated, "Review created successfully", review)
@@ -37,6 +36,7 @@ func (m *ReviewsModule) createReview(c *gin.Context) {
 // @Produce json
 // @Param resource_type query string true "Resource type"
 // @Param resource_id query string true "Resource ID"
+// @Param sort query string false "Sort order" Enums(newest, oldest, highest, lowest, most_helpful) default(newest)
 // @Param page query int false "Page" default(1)
 // @Param limit query int false "Limit" default(20)
 // @Success 200 {object} response.Response{data=ReviewsListResponse}
@@ -44,22 +44,62 @@ func (m *ReviewsModule) createReview(c *gin.Context) {
 func (m *ReviewsModule) listReviews(c *gin.Context) {
 	resourceType := c.Query("resource_type")
 	resourceID := c.Query("resource_id")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	sort := c.DefaultQuery("sort", DefaultReviewSort)
+	if !IsValidReviewSort(sort) {
+		response.BadRequest(c, "Invalid sort option")
+		return
+	}
+	pagination := response.ParsePagination(c)
+
+	reviews, total, avgRating, err := m.service.ListReviews(c.Request.Context(), resourceType, resourceID, sort, pagination)
+	if err != nil {
+		response.InternalError(c, "Failed to list reviews")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reviews retrieved", ReviewsListResponse{
+		Reviews:        reviews,
+		AverageRating:  &avgRating,
+		PaginationMeta: pagination.Meta(total),
+	})
+}
+
+// @Summary List All Reviews (Admin)
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by status" Enums(published, pending, rejected)
+// @Param resource_type query string false "Resource type"
+// @Param resource_id query string false "Resource ID"
+// @Param filter[status] query string false "Comma-separated statuses; filter[resource_type], filter[resource_id], filter[user_id] and filter[rating] work the same way"
+// @Param sort query string false "Sort field, prefixed with - for descending: created_at, updated_at or rating" default(-created_at)
+// @Param page query int false "Page" default(1)
+// @Param limit query int false "Limit" default(20)
+// @Success 200 {object} response.Response{data=ReviewsListResponse}
+// @Router /reviews/admin [get]
+func (m *ReviewsModule) listAllReviews(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != "published" && status != "pending" && status != "rejected" {
+		response.BadRequest(c, "Invalid status filter")
+		return
+	}
+	pagination := response.ParsePagination(c)
+
+	listQuery, err := response.ParseListQuery(c, adminReviewListSpec)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
 
-	reviews, total, avgRating, err := m.service.ListReviews(resourceType, resourceID, page, limit)
+	reviews, total, err := m.service.ListAllReviews(c.Request.Context(), status, c.Query("resource_type"), c.Query("resource_id"), listQuery, pagination)
 	if err != nil {
 		response.InternalError(c, "Failed to list reviews")
 		return
 	}
 
-	response.Success(c, http.StatusOK, "Reviews retrieved", gin.H{
-		"reviews":        reviews,
-		"total":          total,
-		"average_rating": avgRating,
-		"page":           page,
-		"limit":          limit,
-		"total_pages":    (total + limit - 1) / limit,
+	response.Success(c, http.StatusOK, "Reviews retrieved", ReviewsListResponse{
+		Reviews:        reviews,
+		PaginationMeta: pagination.Meta(total),
 	})
 }
 
@@ -70,8 +110,13 @@ func (m *ReviewsModule) listReviews(c *gin.Context) {
 // @Success 200 {object} response.Response{data=ReviewResponse}
 // @Router /reviews/{id} [get]
 func (m *ReviewsModule) getReview(c *gin.Context) {
-	review, err := m.service.GetReview(c.Param("id"))
+	review, err := m.service.GetReview(c.Request.Context(), c.Param("id"))
 	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get review")
+		return
+	}
+	// Reviews hidden by moderation are not publicly visible
+	if review.Status != "published" {
 		response.NotFound(c, "Review not found")
 		return
 	}
@@ -90,19 +135,20 @@ func (m *ReviewsModule) getReview(c *gin.Context) {
 func (m *ReviewsModule) updateReview(c *gin.Context) {
 	var req UpdateReviewRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, []response.ResponseError{response.NewError("VALIDATION_ERROR", err.Error(), "")})
+		response.BindError(c, err)
 		return
 	}
 	userID, _ := c.Get("user_id")
-	review, err := m.service.UpdateReview(c.Param("id"), userID.(string), &req)
+	review, err := m.service.UpdateReview(c.Request.Context(), c.Param("id"), userID.(string), &req)
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to update review")
 		return
 	}
 	response.Success(c, http.StatusOK, "Review updated", review)
 }
 
 // @Summary Delete Review
+// @Description Delete your own review; admins may delete any review
 // @Tags Reviews
 // @Produce json
 // @Security BearerAuth
@@ -111,9 +157,116 @@ func (m *ReviewsModule) updateReview(c *gin.Context) {
 // @Router /reviews/{id} [delete]
 func (m *ReviewsModule) deleteReview(c *gin.Context) {
 	userID, _ := c.Get("user_id")
-	if err := m.service.DeleteReview(c.Param("id"), userID.(string)); err != nil {
-		response.BadRequest(c, err.Error())
+	role, _ := c.Get("role")
+	if err := m.service.DeleteReview(c.Request.Context(), c.Param("id"), userID.(string), role == "admin"); err != nil {
+		response.HandleServiceError(c, err, "Failed to delete review")
 		return
 	}
 	response.Success(c, http.StatusOK, "Review deleted", nil)
 }
+
+// @Summary Update Review Status (Admin)
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Param request body UpdateReviewStatusRequest true "Moderation status"
+// @Success 200 {object} response.Response{data=ReviewResponse}
+// @Router /reviews/{id}/status [put]
+func (m *ReviewsModule) updateReviewStatus(c *gin.Context) {
+	var req UpdateReviewStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	userID, _ := c.Get("user_id")
+	review, err := m.service.UpdateReviewStatus(c.Request.Context(), c.Param("id"), userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update review status")
+		return
+	}
+	response.Success(c, http.StatusOK, "Review status updated", review)
+}
+
+// @Summary Mark Review Helpful
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Success 200 {object} response.Response{data=ReviewResponse}
+// @Router /reviews/{id}/helpful [post]
+func (m *ReviewsModule) markHelpful(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	review, err := m.service.MarkHelpful(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to mark review as helpful")
+		return
+	}
+	response.Success(c, http.StatusOK, "Review marked as helpful", review)
+}
+
+// @Summary Remove Helpful Vote
+// @Tags Reviews
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Success 200 {object} response.Response{data=ReviewResponse}
+// @Router /reviews/{id}/helpful [delete]
+func (m *ReviewsModule) unmarkHelpful(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	review, err := m.service.UnmarkHelpful(c.Request.Context(), c.Param("id"), userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to remove helpful vote")
+		return
+	}
+	response.Success(c, http.StatusOK, "Helpful vote removed", review)
+}
+
+// @Summary Respond to Review
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Param request body ReviewReplyRequest true "Response content"
+// @Success 201 {object} response.Response{data=ReviewResponse}
+// @Router /reviews/{id}/response [post]
+func (m *ReviewsModule) createReply(c *gin.Context) {
+	var req ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	userID, _ := c.Get("user_id")
+	review, err := m.service.CreateReply(c.Request.Context(), c.Param("id"), userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to create response")
+		return
+	}
+	response.Success(c, http.StatusCreated, "Response created", review)
+}
+
+// @Summary Update Review Response
+// @Tags Reviews
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Review ID"
+// @Param request body ReviewReplyRequest true "Response content"
+// @Success 200 {object} response.Response{data=ReviewResponse}
+// @Router /reviews/{id}/response [put]
+func (m *ReviewsModule) updateReply(c *gin.Context) {
+	var req ReviewReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+	userID, _ := c.Get("user_id")
+	review, err := m.service.UpdateReply(c.Request.Context(), c.Param("id"), userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update response")
+		return
+	}
+	response.Success(c, http.StatusOK, "Response updated", review)
+}
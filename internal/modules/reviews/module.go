@@ -10,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// ResourceOwnerRole is the user role allowed, alongside admins, to respond to reviews
+const ResourceOwnerRole = "resource_owner"
+
 // ReviewsModule handles reviews
 type ReviewsModule struct {
 	db          *clients.Database
@@ -23,7 +26,7 @@ type ReviewsModule struct {
 // NewReviewsModule creates a new reviews module
 func NewReviewsModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *ReviewsModule {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	service := NewReviewsService(db)
 
 	return &ReviewsModule{
@@ -52,5 +55,21 @@ func (m *ReviewsModule) RegisterRoutes(router *gin.RouterGroup) {
 		reviewsAuth.POST("", m.createReview)
 		reviewsAuth.PUT("/:id", m.updateReview)
 		reviewsAuth.DELETE("/:id", m.deleteReview)
+		reviewsAuth.POST("/:id/helpful", m.markHelpful)
+		reviewsAuth.DELETE("/:id/helpful", m.unmarkHelpful)
+	}
+
+	reviewsAdmin := router.Group("/reviews")
+	reviewsAdmin.Use(authMiddleware.RequireAuth(), middleware.RequireAdmin())
+	{
+		reviewsAdmin.GET("/admin", m.listAllReviews)
+		reviewsAdmin.PUT("/:id/status", m.updateReviewStatus)
+	}
+
+	reviewsOwner := router.Group("/reviews")
+	reviewsOwner.Use(authMiddleware.RequireAuth(), middleware.RequireRole("admin", "superadmin", ResourceOwnerRole))
+	{
+		reviewsOwner.POST("/:id/response", m.createReply)
+		reviewsOwner.PUT("/:id/response", m.updateReply)
 	}
 }
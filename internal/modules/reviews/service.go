@@ -1,15 +1,54 @@
 package reviews
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
+	"log"
 	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/models"
+	"gogin/internal/response"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// reviewColumns lists the columns scanned by scanReview, including the helpful vote count
+const reviewColumns = `id, resource_type, resource_id, user_id, rating, title, content, status, moderated_by, moderated_at, moderation_reason, created_at, updated_at,
+	(SELECT COUNT(*) FROM review_votes v WHERE v.review_id = reviews.id) AS helpful_count`
+
+// reviewSortOrders maps the public sort options to fixed ORDER BY clauses
+var reviewSortOrders = map[string]string{
+	"newest":       "created_at DESC",
+	"oldest":       "created_at ASC",
+	"highest":      "rating DESC, created_at DESC",
+	"lowest":       "rating ASC, created_at DESC",
+	"most_helpful": "helpful_count DESC, created_at DESC",
+}
+
+// DefaultReviewSort is used when no sort option is given
+const DefaultReviewSort = "newest"
+
+// IsValidReviewSort reports whether sort is a supported sort option
+func IsValidReviewSort(sort string) bool {
+	_, ok := reviewSortOrders[sort]
+	return ok
+}
+
+// Sentinel errors returned by the reviews service
+var (
+	ErrReviewNotFound         = response.NotFoundError("review not found")
+	ErrReviewResponseExists   = response.ConflictError("review already has a response")
+	ErrReviewResponseNotFound = response.NotFoundError("response not found")
+)
+
+// rowScanner is satisfied by both *clients.Row and *clients.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
 type ReviewsService struct {
 	db *clients.Database
 }
@@ -18,16 +57,16 @@ func NewReviewsService(db *clients.Database) *ReviewsService {
 	return &ReviewsService{db: db}
 }
 
-func (s *ReviewsService) CreateReview(userID string, req *CreateReviewRequest) (*ReviewResponse, error) {
+func (s *ReviewsService) CreateReview(ctx context.Context, userID string, req *CreateReviewRequest) (*ReviewResponse, error) {
 	id := uuid.New().String()
 	query := `
 		INSERT INTO reviews (id, resource_type, resource_id, user_id, rating, title, content, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $9)
 		RETURNING created_at, updated_at
 	`
 
 	var createdAt, updatedAt time.Time
-	err := s.db.QueryRow(query, id, req.ResourceType, req.ResourceID, userID, req.Rating, req.Title, req.Content, "published").Scan(&createdAt, &updatedAt)
+	err := s.db.QueryRowContext(ctx, query, id, req.ResourceType, req.ResourceID, userID, req.Rating, req.Title, req.Content, "published", clients.Now()).Scan(&createdAt, &updatedAt)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create review: %w", err)
 	}
@@ -46,18 +85,21 @@ func (s *ReviewsService) CreateReview(userID string, req *CreateReviewRequest) (
 	}, nil
 }
 
-func (s *ReviewsService) ListReviews(resourceType, resourceID string, page, limit int) ([]*ReviewResponse, int, float64, error) {
-	offset := (page - 1) * limit
+func (s *ReviewsService) ListReviews(ctx context.Context, resourceType, resourceID, sort string, pagination response.Pagination) ([]*ReviewResponse, int, float64, error) {
+	orderBy, ok := reviewSortOrders[sort]
+	if !ok {
+		orderBy = reviewSortOrders[DefaultReviewSort]
+	}
 
 	var total int
 	var avgRating float64
-	err := s.db.QueryRow(`SELECT COUNT(*), COALESCE(AVG(rating), 0) FROM reviews WHERE resource_type = $1 AND resource_id = $2 AND status = 'published'`, resourceType, resourceID).Scan(&total, &avgRating)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(AVG(rating), 0) FROM reviews WHERE resource_type = $1 AND resource_id = $2 AND status = 'published' AND deleted_at IS NULL`, resourceType, resourceID).Scan(&total, &avgRating)
 	if err != nil {
 		return nil, 0, 0, err
 	}
 
-	query := `SELECT id, resource_type, resource_id, user_id, rating, title, content, status, created_at, updated_at FROM reviews WHERE resource_type = $1 AND resource_id = $2 AND status = 'published' ORDER BY created_at DESC LIMIT $3 OFFSET $4`
-	rows, err := s.db.Query(query, resourceType, resourceID, limit, offset)
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE resource_type = $1 AND resource_id = $2 AND status = 'published' AND deleted_at IS NULL ORDER BY ` + orderBy + ` LIMIT $3 OFFSET $4`
+	rows, err := s.db.QueryContext(ctx, query, resourceType, resourceID, pagination.Limit, pagination.Offset)
 	if err != nil {
 		return nil, 0, 0, err
 	}
@@ -65,49 +107,317 @@ func (s *ReviewsService) ListReviews(resourceType, resourceID string, page, limi
 
 	var reviews []*ReviewResponse
 	for rows.Next() {
-		var r models.Review
-		var title string
-		rows.Scan(&r.ID, &r.ResourceType, &r.ResourceID, &r.UserID, &r.Rating, &r.Title, &r.Content, &r.Status, &r.CreatedAt, &r.UpdatedAt)
-		if r.Title.Valid {
-			title = r.Title.String
+		r, helpfulCount, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, 0, err
 		}
-		reviews = append(reviews, &ReviewResponse{r.ID, r.ResourceType, r.ResourceID, r.UserID, r.Rating, title, r.Content, r.Status, r.CreatedAt, r.UpdatedAt})
+		reviews = append(reviews, toReviewResponse(r, helpfulCount))
+	}
+
+	if err := s.attachReplies(ctx, reviews); err != nil {
+		return nil, 0, 0, err
 	}
 
 	return reviews, total, avgRating, nil
 }
 
-func (s *ReviewsService) GetReview(id string) (*ReviewResponse, error) {
-	var r models.Review
-	err := s.db.QueryRow(`SELECT id, resource_type, resource_id, user_id, rating, title, content, status, created_at, updated_at FROM reviews WHERE id = $1`, id).Scan(&r.ID, &r.ResourceType, &r.ResourceID, &r.UserID, &r.Rating, &r.Title, &r.Content, &r.Status, &r.CreatedAt, &r.UpdatedAt)
+// adminReviewListSpec whitelists the filter[...] and sort fields of the admin review list
+var adminReviewListSpec = response.ListSpec{
+	Filters: map[string]response.FilterField{
+		"status":        {Column: "status"},
+		"resource_type": {Column: "resource_type"},
+		"resource_id":   {Column: "resource_id"},
+		"user_id":       {Column: "user_id", Type: response.FilterUUID},
+		"rating":        {Column: "rating", Type: response.FilterInt},
+	},
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"rating":     "rating",
+	},
+	DefaultSort: "-created_at",
+}
+
+// ListAllReviews lists reviews in any moderation status for admins, optionally filtered
+func (s *ReviewsService) ListAllReviews(ctx context.Context, status, resourceType, resourceID string, listQuery response.ListQuery, pagination response.Pagination) ([]*ReviewResponse, int, error) {
+	where := "WHERE deleted_at IS NULL"
+	args := []interface{}{}
+	argCount := 1
+
+	if status != "" {
+		where += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+	if resourceType != "" {
+		where += fmt.Sprintf(" AND resource_type = $%d", argCount)
+		args = append(args, resourceType)
+		argCount++
+	}
+	if resourceID != "" {
+		where += fmt.Sprintf(" AND resource_id = $%d", argCount)
+		args = append(args, resourceID)
+		argCount++
+	}
+
+	filterClause, args := listQuery.Where(args)
+	where += filterClause
+	argCount = len(args) + 1
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM reviews "+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM reviews %s ORDER BY %s LIMIT $%d OFFSET $%d", reviewColumns, where, listQuery.OrderBy(), argCount, argCount+1)
+	args = append(args, pagination.Limit, pagination.Offset)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var reviews []*ReviewResponse
+	for rows.Next() {
+		r, helpfulCount, err := scanReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, toReviewResponse(r, helpfulCount))
+	}
+
+	if err := s.attachReplies(ctx, reviews); err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, total, nil
+}
+
+func (s *ReviewsService) GetReview(ctx context.Context, id string) (*ReviewResponse, error) {
+	r, helpfulCount, err := scanReview(s.db.QueryRowContext(ctx, `SELECT `+reviewColumns+` FROM reviews WHERE id = $1 AND deleted_at IS NULL`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrReviewNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	title := ""
-	if r.Title.Valid {
-		title = r.Title.String
+	review := toReviewResponse(r, helpfulCount)
+	if err := s.attachReplies(ctx, []*ReviewResponse{review}); err != nil {
+		return nil, err
 	}
-	return &ReviewResponse{r.ID, r.ResourceType, r.ResourceID, r.UserID, r.Rating, title, r.Content, r.Status, r.CreatedAt, r.UpdatedAt}, nil
+	return review, nil
 }
 
-func (s *ReviewsService) UpdateReview(id, userID string, req *UpdateReviewRequest) (*ReviewResponse, error) {
-	result, err := s.db.Exec(`UPDATE reviews SET rating = $1, title = $2, content = $3, updated_at = NOW() WHERE id = $4 AND user_id = $5`, req.Rating, req.Title, req.Content, id, userID)
+// UpdateReviewStatus moves a review between moderation statuses, recording the moderator and reason
+func (s *ReviewsService) UpdateReviewStatus(ctx context.Context, id, moderatorID string, req *UpdateReviewStatusRequest) (*ReviewResponse, error) {
+	var reason sql.NullString
+	if req.Reason != "" {
+		reason = sql.NullString{String: req.Reason, Valid: true}
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE reviews
+		SET status = $1, moderated_by = $2, moderated_at = $3, moderation_reason = $4, updated_at = $3
+		WHERE id = $5 AND deleted_at IS NULL
+	`, req.Status, moderatorID, clients.Now(), reason, id)
 	if err != nil {
 		return nil, err
 	}
 	if rows, _ := result.RowsAffected(); rows == 0 {
-		return nil, fmt.Errorf("review not found")
+		return nil, ErrReviewNotFound
 	}
-	return s.GetReview(id)
+	return s.GetReview(ctx, id)
 }
 
-func (s *ReviewsService) DeleteReview(id, userID string) error {
-	result, err := s.db.Exec(`DELETE FROM reviews WHERE id = $1 AND user_id = $2`, id, userID)
+func (s *ReviewsService) UpdateReview(ctx context.Context, id, userID string, req *UpdateReviewRequest) (*ReviewResponse, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE reviews SET rating = $1, title = $2, content = $3, updated_at = $4 WHERE id = $5 AND user_id = $6 AND deleted_at IS NULL`, req.Rating, req.Title, req.Content, clients.Now(), id, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if rows, _ := result.RowsAffected(); rows == 0 {
-		return fmt.Errorf("review not found")
+		return nil, ErrReviewNotFound
+	}
+	return s.GetReview(ctx, id)
+}
+
+// DeleteReview soft-deletes a review owned by userID. Admins may delete any review as
+// moderators; those deletions record the moderator and are logged with the review's author.
+func (s *ReviewsService) DeleteReview(ctx context.Context, id, userID string, isAdmin bool) error {
+	query := `UPDATE reviews SET deleted_at = $1, updated_at = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL RETURNING user_id`
+	args := []interface{}{clients.Now(), id, userID}
+	if isAdmin {
+		// Moderation is only recorded when the admin removes someone else's review
+		query = `
+			UPDATE reviews
+			SET deleted_at = $1, updated_at = $1,
+				moderated_by = CASE WHEN user_id = $3 THEN moderated_by ELSE $3 END,
+				moderated_at = CASE WHEN user_id = $3 THEN moderated_at ELSE $1 END
+			WHERE id = $2 AND deleted_at IS NULL
+			RETURNING user_id
+		`
+	}
+
+	var authorID string
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return ErrReviewNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if authorID != userID {
+		log.Printf("Review %s by user %s deleted by moderator %s", id, authorID, userID)
 	}
 	return nil
 }
+
+// MarkHelpful records a user's helpful vote on a published review; repeat votes are ignored
+func (s *ReviewsService) MarkHelpful(ctx context.Context, id, userID string) (*ReviewResponse, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM reviews WHERE id = $1 AND status = 'published' AND deleted_at IS NULL)`, id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrReviewNotFound
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO review_votes (review_id, user_id, created_at) VALUES ($1, $2, $3) ON CONFLICT (review_id, user_id) DO NOTHING`, id, userID, clients.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to record vote: %w", err)
+	}
+	return s.GetReview(ctx, id)
+}
+
+// UnmarkHelpful removes a user's helpful vote from a review
+func (s *ReviewsService) UnmarkHelpful(ctx context.Context, id, userID string) (*ReviewResponse, error) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2`, id, userID); err != nil {
+		return nil, fmt.Errorf("failed to remove vote: %w", err)
+	}
+
+	return s.GetReview(ctx, id)
+}
+
+// CreateReply adds the resource owner's response to a review; each review has at most one
+func (s *ReviewsService) CreateReply(ctx context.Context, reviewID, responderID string, req *ReviewReplyRequest) (*ReviewResponse, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM reviews WHERE id = $1 AND deleted_at IS NULL)`, reviewID).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrReviewNotFound
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		INSERT INTO review_responses (id, review_id, responder_id, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		ON CONFLICT (review_id) DO NOTHING
+	`, uuid.New().String(), reviewID, responderID, req.Content, clients.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create response: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrReviewResponseExists
+	}
+	return s.GetReview(ctx, reviewID)
+}
+
+// UpdateReply replaces the content of a review's existing response
+func (s *ReviewsService) UpdateReply(ctx context.Context, reviewID, responderID string, req *ReviewReplyRequest) (*ReviewResponse, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE review_responses SET content = $1, responder_id = $2, updated_at = $3
+		WHERE review_id = $4
+	`, req.Content, responderID, clients.Now(), reviewID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update response: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, ErrReviewResponseNotFound
+	}
+	return s.GetReview(ctx, reviewID)
+}
+
+// attachReplies loads owner responses for the given reviews in a single query
+func (s *ReviewsService) attachReplies(ctx context.Context, reviews []*ReviewResponse) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*ReviewResponse, len(reviews))
+	ids := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		byID[review.ID] = review
+		ids = append(ids, review.ID)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, review_id, responder_id, content, created_at, updated_at
+		FROM review_responses
+		WHERE review_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reply models.ReviewReply
+		if err := rows.Scan(&reply.ID, &reply.ReviewID, &reply.ResponderID, &reply.Content, &reply.CreatedAt, &reply.UpdatedAt); err != nil {
+			return err
+		}
+
+		resp := &ReviewReplyResponse{
+			ID:        reply.ID,
+			Content:   reply.Content,
+			CreatedAt: reply.CreatedAt,
+			UpdatedAt: reply.UpdatedAt,
+		}
+		if reply.ResponderID.Valid {
+			resp.ResponderID = &reply.ResponderID.String
+		}
+		byID[reply.ReviewID].Response = resp
+	}
+
+	return rows.Err()
+}
+
+func scanReview(row rowScanner) (*models.Review, int, error) {
+	var r models.Review
+	var helpfulCount int
+	err := row.Scan(&r.ID, &r.ResourceType, &r.ResourceID, &r.UserID, &r.Rating, &r.Title, &r.Content, &r.Status, &r.ModeratedBy, &r.ModeratedAt, &r.ModerationReason, &r.CreatedAt, &r.UpdatedAt, &helpfulCount)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &r, helpfulCount, nil
+}
+
+func toReviewResponse(r *models.Review, helpfulCount int) *ReviewResponse {
+	resp := &ReviewResponse{
+		ID:           r.ID,
+		ResourceType: r.ResourceType,
+		ResourceID:   r.ResourceID,
+		UserID:       r.UserID,
+		Rating:       r.Rating,
+		Content:      r.Content,
+		Status:       r.Status,
+		HelpfulCount: helpfulCount,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+	if r.Title.Valid {
+		resp.Title = r.Title.String
+	}
+	if r.ModeratedBy.Valid {
+		resp.ModeratedBy = &r.ModeratedBy.String
+	}
+	if r.ModeratedAt.Valid {
+		resp.ModeratedAt = &r.ModeratedAt.Time
+	}
+	if r.ModerationReason.Valid {
+		resp.ModerationReason = &r.ModerationReason.String
+	}
+	return resp
+}
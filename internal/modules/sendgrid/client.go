@@ -2,12 +2,14 @@ package sendgrid
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 
 	"gogin/internal/config"
+	"gogin/internal/utils"
 )
 
 // SendGridClient wraps SendGrid API
@@ -35,6 +37,21 @@ type EmailMessage struct {
 	TextContent string
 	HTMLContent string
 	ReplyTo     string
+	Attachments []Attachment
+	// RequestID is sent as X-Request-ID so the call can be traced back to the request that caused it
+	RequestID string
+
+	// TemplateID selects a SendGrid dynamic template; when set, TextContent and
+	// HTMLContent are ignored and DynamicTemplateData fills the template
+	TemplateID          string
+	DynamicTemplateData map[string]interface{}
+}
+
+// Attachment represents a file attached to an email
+type Attachment struct {
+	Filename string
+	Content  []byte
+	Type     string // MIME type
 }
 
 // SendEmail sends an email via SendGrid
@@ -48,10 +65,16 @@ func (c *SendGridClient) SendEmail(msg *EmailMessage) error {
 	for _, email := range msg.To {
 		toList = append(toList, map[string]string{"email": email})
 	}
-	personalizations = append(personalizations, map[string]interface{}{
-		"to":      toList,
-		"subject": msg.Subject,
-	})
+	personalization := map[string]interface{}{
+		"to": toList,
+	}
+	if msg.Subject != "" {
+		personalization["subject"] = msg.Subject
+	}
+	if msg.TemplateID != "" && len(msg.DynamicTemplateData) > 0 {
+		personalization["dynamic_template_data"] = msg.DynamicTemplateData
+	}
+	personalizations = append(personalizations, personalization)
 
 	content := make([]map[string]string, 0)
 	if msg.TextContent != "" {
@@ -73,7 +96,29 @@ func (c *SendGridClient) SendEmail(msg *EmailMessage) error {
 			"email": c.fromEmail,
 			"name":  c.fromName,
 		},
-		"content": content,
+	}
+
+	// Dynamic templates provide their own content, so SendGrid rejects a content array
+	if msg.TemplateID != "" {
+		payload["template_id"] = msg.TemplateID
+	} else {
+		payload["content"] = content
+	}
+
+	if len(msg.Attachments) > 0 {
+		attachments := make([]map[string]string, 0, len(msg.Attachments))
+		for _, attachment := range msg.Attachments {
+			item := map[string]string{
+				"content":     base64.StdEncoding.EncodeToString(attachment.Content),
+				"filename":    attachment.Filename,
+				"disposition": "attachment",
+			}
+			if attachment.Type != "" {
+				item["type"] = attachment.Type
+			}
+			attachments = append(attachments, item)
+		}
+		payload["attachments"] = attachments
 	}
 
 	if msg.ReplyTo != "" {
@@ -94,6 +139,9 @@ func (c *SendGridClient) SendEmail(msg *EmailMessage) error {
 
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
+	if msg.RequestID != "" {
+		req.Header.Set(utils.RequestIDHeader, msg.RequestID)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
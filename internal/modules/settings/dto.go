@@ -41,3 +41,25 @@ type SettingsListResponse struct {
 	Limit      int                `json:"limit"`
 	TotalPages int                `json:"total_pages"`
 }
+
+// SettingHistoryResponse represents a single change to a system setting
+type SettingHistoryResponse struct {
+	ID           string    `json:"id"`
+	Key          string    `json:"key"`
+	Action       string    `json:"action"`
+	OldValue     *string   `json:"old_value,omitempty"`
+	NewValue     *string   `json:"new_value,omitempty"`
+	ValueChanged bool      `json:"value_changed"`
+	IsEncrypted  bool      `json:"is_encrypted"`
+	ChangedBy    *string   `json:"changed_by,omitempty"`
+	ChangedAt    time.Time `json:"changed_at"`
+}
+
+// SettingHistoryListResponse represents a paginated change log for a setting
+type SettingHistoryListResponse struct {
+	History    []*SettingHistoryResponse `json:"history"`
+	Total      int                       `json:"total"`
+	Page       int                       `json:"page"`
+	Limit      int                       `json:"limit"`
+	TotalPages int                       `json:"total_pages"`
+}
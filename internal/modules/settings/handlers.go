@@ -3,61 +3,12 @@ package settings
 import (
 	"net/http"
 	"strconv"
-	"strings"
 
 	"gogin/internal/response"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
-// getValidationErrors extracts detailed validation error messages
-func getValidationErrors(err error) []response.ResponseError {
-	var errors []response.ResponseError
-
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		for _, e := range validationErrors {
-			var message string
-			field := e.Field()
-
-			switch e.Tag() {
-			case "required":
-				message = field + " is required"
-			case "email":
-				message = field + " must be a valid email address"
-			case "min":
-				message = field + " must be at least " + e.Param() + " characters"
-			case "max":
-				message = field + " must be at most " + e.Param() + " characters"
-			case "oneof":
-				// Special handling for type field
-				if field == "Type" {
-					message = "type must be one of: string, number, boolean, json"
-				} else {
-					validValues := strings.ReplaceAll(e.Param(), " ", ", ")
-					message = field + " must be one of: " + validValues
-				}
-			default:
-				message = field + " is invalid"
-			}
-
-			errors = append(errors, response.ResponseError{
-				Code:    "VALIDATION_ERROR",
-				Message: message,
-				Field:   strings.ToLower(field),
-			})
-		}
-	} else {
-		// Generic error
-		errors = append(errors, response.ResponseError{
-			Code:    "BAD_REQUEST",
-			Message: "Invalid request body",
-		})
-	}
-
-	return errors
-}
-
 // @Summary Create system setting
 // @Description Create a new system-wide setting (admin only)
 // @Tags Settings
@@ -74,13 +25,13 @@ func getValidationErrors(err error) []response.ResponseError {
 func (m *SettingsModule) createSystemSetting(c *gin.Context) {
 	var req CreateSettingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	setting, err := m.service.CreateSystemSetting(&req)
+	setting, err := m.service.CreateSystemSetting(c.Request.Context(), c.GetString("user_id"), &req)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to create system setting")
 		return
 	}
 
@@ -108,9 +59,9 @@ func (m *SettingsModule) getSystemSetting(c *gin.Context) {
 		return
 	}
 
-	setting, err := m.service.GetSystemSetting(key)
+	setting, err := m.service.GetSystemSetting(c.Request.Context(), key)
 	if err != nil {
-		response.NotFound(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to get system setting")
 		return
 	}
 
@@ -135,9 +86,9 @@ func (m *SettingsModule) listSystemSettings(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	settings, err := m.service.ListSystemSettings(page, limit)
+	settings, err := m.service.ListSystemSettings(c.Request.Context(), page, limit)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to list system settings")
 		return
 	}
 
@@ -168,17 +119,13 @@ func (m *SettingsModule) updateSystemSetting(c *gin.Context) {
 
 	var req UpdateSettingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	setting, err := m.service.UpdateSystemSetting(key, &req)
+	setting, err := m.service.UpdateSystemSetting(c.Request.Context(), key, c.GetString("user_id"), &req)
 	if err != nil {
-		if err.Error() == "system setting not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to update system setting")
 		return
 	}
 
@@ -206,19 +153,47 @@ func (m *SettingsModule) deleteSystemSetting(c *gin.Context) {
 		return
 	}
 
-	err := m.service.DeleteSystemSetting(key)
+	err := m.service.DeleteSystemSetting(c.Request.Context(), key, c.GetString("user_id"))
 	if err != nil {
-		if err.Error() == "system setting not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to delete system setting")
 		return
 	}
 
 	response.Success(c, http.StatusOK, "System setting deleted successfully", nil)
 }
 
+// @Summary Get system setting history
+// @Description Get the paginated change log for a system setting (admin only). Values of encrypted settings are redacted.
+// @Tags Settings
+// @Produce json
+// @Security BearerAuth
+// @Param key path string true "Setting key"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} response.Response{data=SettingHistoryListResponse}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /settings/system/{key}/history [get]
+func (m *SettingsModule) getSystemSettingHistory(c *gin.Context) {
+	key := c.Param("key")
+	if key == "" {
+		response.BadRequest(c, "Setting key is required")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	history, err := m.service.ListSystemSettingHistory(c.Request.Context(), key, page, limit)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get system setting history")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "System setting history retrieved successfully", history)
+}
+
 // @Summary Get user setting
 // @Description Get a specific user setting by key (authenticated users can only access their own settings)
 // @Tags Settings
@@ -243,9 +218,9 @@ func (m *SettingsModule) getUserSetting(c *gin.Context) {
 		return
 	}
 
-	setting, err := m.service.GetUserSetting(userID.(string), key)
+	setting, err := m.service.GetUserSetting(c.Request.Context(), userID.(string), key)
 	if err != nil {
-		response.NotFound(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to get user setting")
 		return
 	}
 
@@ -275,9 +250,9 @@ func (m *SettingsModule) listUserSettings(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
 
-	settings, err := m.service.ListUserSettings(userID.(string), page, limit)
+	settings, err := m.service.ListUserSettings(c.Request.Context(), userID.(string), page, limit)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to list user settings")
 		return
 	}
 
@@ -312,13 +287,13 @@ func (m *SettingsModule) createOrUpdateUserSetting(c *gin.Context) {
 
 	var req UpdateSettingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	setting, err := m.service.CreateOrUpdateUserSetting(userID.(string), key, &req)
+	setting, err := m.service.CreateOrUpdateUserSetting(c.Request.Context(), userID.(string), key, &req)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to save user setting")
 		return
 	}
 
@@ -351,13 +326,9 @@ func (m *SettingsModule) deleteUserSetting(c *gin.Context) {
 		return
 	}
 
-	err := m.service.DeleteUserSetting(userID.(string), key)
+	err := m.service.DeleteUserSetting(c.Request.Context(), userID.(string), key)
 	if err != nil {
-		if err.Error() == "user setting not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to delete user setting")
 		return
 	}
 
@@ -18,7 +18,7 @@ type SettingsModule struct {
 // NewSettingsModule creates a new instance of the settings module
 func NewSettingsModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *SettingsModule {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	service := NewSettingsService(db, redisHelper, cfg)
 
 	return &SettingsModule{
@@ -38,6 +38,7 @@ func (m *SettingsModule) RegisterRoutes(router *gin.RouterGroup) {
 		system.POST("", m.createSystemSetting)
 		system.GET("", m.listSystemSettings)
 		system.GET("/:key", m.getSystemSetting)
+		system.GET("/:key/history", m.getSystemSettingHistory)
 		system.PUT("/:key", m.updateSystemSetting)
 		system.DELETE("/:key", m.deleteSystemSetting)
 	}
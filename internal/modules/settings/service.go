@@ -1,14 +1,11 @@
 package settings
 
 import (
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/rand"
+	"context"
 	"database/sql"
-	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"regexp"
 	"time"
 
@@ -16,6 +13,20 @@ import (
 	"gogin/internal/config"
 	"gogin/internal/models"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/response"
+	"gogin/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+// Errors returned by the settings service; match them with errors.Is
+var (
+	ErrSystemSettingNotFound = response.NotFoundError("system setting not found")
+	ErrSystemSettingExists   = response.ConflictError("system setting already exists")
+	ErrUserSettingNotFound   = response.NotFoundError("user setting not found")
 )
 
 type SettingsService struct {
@@ -37,10 +48,10 @@ func (s *SettingsService) validateKey(key string) error {
 	// Key should contain only alphanumeric characters, underscores, and dots
 	validKey := regexp.MustCompile(`^[a-zA-Z0-9_.]+$`)
 	if !validKey.MatchString(key) {
-		return fmt.Errorf("invalid key format: only alphanumeric characters, underscores, and dots are allowed")
+		return response.InvalidError("invalid key format: only alphanumeric characters, underscores, and dots are allowed")
 	}
 	if len(key) > 255 {
-		return fmt.Errorf("key too long: maximum 255 characters")
+		return response.InvalidError("key too long: maximum 255 characters")
 	}
 	return nil
 }
@@ -53,96 +64,34 @@ func (s *SettingsService) validateValue(value, valueType string) error {
 	case "number":
 		var n float64
 		if err := json.Unmarshal([]byte(value), &n); err != nil {
-			return fmt.Errorf("value is not a valid number")
+			return response.InvalidError("value is not a valid number")
 		}
 	case "boolean":
 		var b bool
 		if err := json.Unmarshal([]byte(value), &b); err != nil {
-			return fmt.Errorf("value is not a valid boolean")
+			return response.InvalidError("value is not a valid boolean")
 		}
 	case "json":
 		var j interface{}
 		if err := json.Unmarshal([]byte(value), &j); err != nil {
-			return fmt.Errorf("value is not valid JSON")
+			return response.InvalidError("value is not valid JSON")
 		}
 	default:
-		return fmt.Errorf("invalid type: must be one of string, number, boolean, json")
+		return response.InvalidError("invalid type: must be one of string, number, boolean, json")
 	}
 	return nil
 }
 
-// encrypt encrypts a string value using AES
+// encrypt encrypts a string value using AES, keyed by the encryption key
 func (s *SettingsService) encrypt(plaintext string) (string, error) {
-	// Use JWT secret as encryption key (should be 32 bytes for AES-256)
-	key := []byte(s.config.OAuth.JWTSecret)
-	if len(key) < 32 {
-		// Pad the key if it's too short
-		paddedKey := make([]byte, 32)
-		copy(paddedKey, key)
-		key = paddedKey
-	} else if len(key) > 32 {
-		// Truncate if too long
-		key = key[:32]
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := make([]byte, gcm.NonceSize())
-	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
-		return "", err
-	}
-
-	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return utils.EncryptString(s.config.App.EncryptionKey, plaintext)
 }
 
-// decrypt decrypts an encrypted string value
+// decrypt decrypts an encrypted string value. Values saved before ENCRYPTION_KEY
+// existed were keyed by the JWT secret and are read with it until next updated.
 func (s *SettingsService) decrypt(ciphertext string) (string, error) {
-	// Use JWT secret as encryption key
-	key := []byte(s.config.OAuth.JWTSecret)
-	if len(key) < 32 {
-		paddedKey := make([]byte, 32)
-		copy(paddedKey, key)
-		key = paddedKey
-	} else if len(key) > 32 {
-		key = key[:32]
-	}
-
-	data, err := base64.StdEncoding.DecodeString(ciphertext)
-	if err != nil {
-		return "", err
-	}
-
-	block, err := aes.NewCipher(key)
-	if err != nil {
-		return "", err
-	}
-
-	gcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return "", fmt.Errorf("ciphertext too short")
-	}
-
-	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
-	if err != nil {
-		return "", err
-	}
-
-	return string(plaintext), nil
+	plaintext, _, err := utils.DecryptStringWithFallback(s.config.App.EncryptionKey, s.config.OAuth.JWTSecret, ciphertext)
+	return plaintext, err
 }
 
 // getCacheKey returns the Redis cache key for a setting
@@ -179,7 +128,7 @@ func (s *SettingsService) toResponse(setting *models.Setting) *SettingResponse {
 }
 
 // CreateSystemSetting creates a new system-wide setting
-func (s *SettingsService) CreateSystemSetting(req *CreateSettingRequest) (*SettingResponse, error) {
+func (s *SettingsService) CreateSystemSetting(ctx context.Context, actorID string, req *CreateSettingRequest) (*SettingResponse, error) {
 	// Validate key
 	if err := s.validateKey(req.Key); err != nil {
 		return nil, err
@@ -200,6 +149,12 @@ func (s *SettingsService) CreateSystemSetting(req *CreateSettingRequest) (*Setti
 		value = encrypted
 	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Insert into database
 	query := `
 		INSERT INTO settings (user_id, key, value, type, is_encrypted, description, created_at, updated_at)
@@ -207,10 +162,10 @@ func (s *SettingsService) CreateSystemSetting(req *CreateSettingRequest) (*Setti
 		RETURNING id, user_id, key, value, type, is_encrypted, description, created_at, updated_at
 	`
 
-	now := time.Now().UTC()
+	now := clients.Now()
 	var setting models.Setting
 
-	err := s.db.QueryRow(
+	err = tx.QueryRowContext(ctx,
 		query,
 		req.Key,
 		value,
@@ -231,10 +186,24 @@ func (s *SettingsService) CreateSystemSetting(req *CreateSettingRequest) (*Setti
 		&setting.UpdatedAt,
 	)
 
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return nil, ErrSystemSettingExists
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create system setting: %w", err)
 	}
 
+	// Record history
+	newValue := sql.NullString{String: req.Value, Valid: true}
+	if err := s.insertHistory(ctx, tx, setting.Key, "create", sql.NullString{}, newValue, true, req.IsEncrypted, actorID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Decrypt for response if needed
 	if setting.IsEncrypted {
 		decrypted, err := s.decrypt(setting.Value)
@@ -251,46 +220,41 @@ func (s *SettingsService) CreateSystemSetting(req *CreateSettingRequest) (*Setti
 }
 
 // GetSystemSetting retrieves a system setting by key
-func (s *SettingsService) GetSystemSetting(key string) (*SettingResponse, error) {
-	// Try cache first
+func (s *SettingsService) GetSystemSetting(ctx context.Context, key string) (*SettingResponse, error) {
+	// Cache-aside; the stored value stays encrypted in cache
 	cacheKey := s.getCacheKey(nil, key)
-	var cached models.Setting
-	if s.redisHelper.CacheGet(cacheKey, &cached) == nil {
-		// Decrypt if needed
-		if cached.IsEncrypted {
-			decrypted, err := s.decrypt(cached.Value)
-			if err == nil {
-				cached.Value = decrypted
-			}
-		}
-		return s.toResponse(&cached), nil
-	}
-
-	// Query database
-	query := `
-		SELECT id, user_id, key, value, type, is_encrypted, description, created_at, updated_at
-		FROM settings
-		WHERE user_id IS NULL AND key = $1
-	`
-
 	var setting models.Setting
-	err := s.db.QueryRow(query, key).Scan(
-		&setting.ID,
-		&setting.UserID,
-		&setting.Key,
-		&setting.Value,
-		&setting.Type,
-		&setting.IsEncrypted,
-		&setting.Description,
-		&setting.CreatedAt,
-		&setting.UpdatedAt,
-	)
+	err := s.redisHelper.GetOrSet(cacheKey, 24*time.Hour, &setting, func() (interface{}, error) {
+		query := `
+			SELECT id, user_id, key, value, type, is_encrypted, description, created_at, updated_at
+			FROM settings
+			WHERE user_id IS NULL AND key = $1
+		`
+
+		var loaded models.Setting
+		err := s.db.QueryRowContext(ctx, query, key).Scan(
+			&loaded.ID,
+			&loaded.UserID,
+			&loaded.Key,
+			&loaded.Value,
+			&loaded.Type,
+			&loaded.IsEncrypted,
+			&loaded.Description,
+			&loaded.CreatedAt,
+			&loaded.UpdatedAt,
+		)
+
+		if err == sql.ErrNoRows {
+			return nil, ErrSystemSettingNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get system setting: %w", err)
+		}
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("system setting not found")
-	}
+		return &loaded, nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get system setting: %w", err)
+		return nil, err
 	}
 
 	// Decrypt if needed
@@ -301,14 +265,11 @@ func (s *SettingsService) GetSystemSetting(key string) (*SettingResponse, error)
 		}
 	}
 
-	// Cache the setting
-	s.redisHelper.CacheSet(cacheKey, &setting, 24*time.Hour)
-
 	return s.toResponse(&setting), nil
 }
 
 // ListSystemSettings retrieves all system settings with pagination
-func (s *SettingsService) ListSystemSettings(page, limit int) (*SettingsListResponse, error) {
+func (s *SettingsService) ListSystemSettings(ctx context.Context, page, limit int) (*SettingsListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -321,7 +282,7 @@ func (s *SettingsService) ListSystemSettings(page, limit int) (*SettingsListResp
 	// Count total
 	var total int
 	countQuery := `SELECT COUNT(*) FROM settings WHERE user_id IS NULL`
-	if err := s.db.QueryRow(countQuery).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, countQuery).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count system settings: %w", err)
 	}
 
@@ -334,7 +295,7 @@ func (s *SettingsService) ListSystemSettings(page, limit int) (*SettingsListResp
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := s.db.Query(query, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list system settings: %w", err)
 	}
@@ -384,7 +345,7 @@ func (s *SettingsService) ListSystemSettings(page, limit int) (*SettingsListResp
 }
 
 // UpdateSystemSetting updates a system setting by key
-func (s *SettingsService) UpdateSystemSetting(key string, req *UpdateSettingRequest) (*SettingResponse, error) {
+func (s *SettingsService) UpdateSystemSetting(ctx context.Context, key, actorID string, req *UpdateSettingRequest) (*SettingResponse, error) {
 	// Validate value type
 	if err := s.validateValue(req.Value, req.Type); err != nil {
 		return nil, err
@@ -400,6 +361,34 @@ func (s *SettingsService) UpdateSystemSetting(key string, req *UpdateSettingRequ
 		value = encrypted
 	}
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the current row so the history reflects the value being replaced
+	var oldValue string
+	var oldEncrypted bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT value, is_encrypted FROM settings WHERE user_id IS NULL AND key = $1 FOR UPDATE`,
+		key,
+	).Scan(&oldValue, &oldEncrypted)
+	if err == sql.ErrNoRows {
+		return nil, ErrSystemSettingNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system setting: %w", err)
+	}
+
+	if oldEncrypted {
+		decrypted, err := s.decrypt(oldValue)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value: %w", err)
+		}
+		oldValue = decrypted
+	}
+
 	// Update in database
 	query := `
 		UPDATE settings
@@ -409,13 +398,13 @@ func (s *SettingsService) UpdateSystemSetting(key string, req *UpdateSettingRequ
 	`
 
 	var setting models.Setting
-	err := s.db.QueryRow(
+	err = tx.QueryRowContext(ctx,
 		query,
 		value,
 		req.Type,
 		req.IsEncrypted,
 		sql.NullString{String: req.Description, Valid: req.Description != ""},
-		time.Now().UTC(),
+		clients.Now(),
 		key,
 	).Scan(
 		&setting.ID,
@@ -430,12 +419,30 @@ func (s *SettingsService) UpdateSystemSetting(key string, req *UpdateSettingRequ
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("system setting not found")
+		return nil, ErrSystemSettingNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update system setting: %w", err)
 	}
 
+	// Record history
+	if err := s.insertHistory(ctx,
+		tx,
+		key,
+		"update",
+		sql.NullString{String: oldValue, Valid: true},
+		sql.NullString{String: req.Value, Valid: true},
+		oldValue != req.Value,
+		oldEncrypted || req.IsEncrypted,
+		actorID,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Decrypt for response if needed
 	if setting.IsEncrypted {
 		decrypted, err := s.decrypt(setting.Value)
@@ -452,21 +459,32 @@ func (s *SettingsService) UpdateSystemSetting(key string, req *UpdateSettingRequ
 }
 
 // DeleteSystemSetting deletes a system setting by key
-func (s *SettingsService) DeleteSystemSetting(key string) error {
-	query := `DELETE FROM settings WHERE user_id IS NULL AND key = $1`
+func (s *SettingsService) DeleteSystemSetting(ctx context.Context, key, actorID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM settings WHERE user_id IS NULL AND key = $1 RETURNING value, is_encrypted`
 
-	result, err := s.db.Exec(query, key)
+	var oldValue string
+	var oldEncrypted bool
+	err = tx.QueryRowContext(ctx, query, key).Scan(&oldValue, &oldEncrypted)
+	if err == sql.ErrNoRows {
+		return ErrSystemSettingNotFound
+	}
 	if err != nil {
 		return fmt.Errorf("failed to delete system setting: %w", err)
 	}
 
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+	// Record history
+	if err := s.insertHistory(ctx, tx, key, "delete", sql.NullString{String: oldValue, Valid: true}, sql.NullString{}, true, oldEncrypted, actorID); err != nil {
+		return err
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("system setting not found")
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	// Invalidate cache
@@ -476,8 +494,125 @@ func (s *SettingsService) DeleteSystemSetting(key string) error {
 	return nil
 }
 
+// insertHistory records a change to a system setting within the given transaction.
+// Values of encrypted settings are never stored; only the changed flag is kept.
+func (s *SettingsService) insertHistory(ctx context.Context, tx *sql.Tx, key, action string, oldValue, newValue sql.NullString, changed, encrypted bool, actorID string) error {
+	if encrypted {
+		oldValue = sql.NullString{}
+		newValue = sql.NullString{}
+	}
+
+	query := `
+		INSERT INTO settings_history (setting_key, action, old_value, new_value, value_changed, is_encrypted, changed_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::uuid, $8)
+	`
+
+	_, err := tx.ExecContext(ctx, query, key, action, oldValue, newValue, changed, encrypted, actorID, clients.Now())
+	if err != nil {
+		return fmt.Errorf("failed to record setting history: %w", err)
+	}
+
+	return nil
+}
+
+// toHistoryResponse converts a models.SettingHistory to SettingHistoryResponse
+func (s *SettingsService) toHistoryResponse(entry *models.SettingHistory) *SettingHistoryResponse {
+	response := &SettingHistoryResponse{
+		ID:           entry.ID,
+		Key:          entry.SettingKey,
+		Action:       entry.Action,
+		ValueChanged: entry.ValueChanged,
+		IsEncrypted:  entry.IsEncrypted,
+		ChangedAt:    entry.CreatedAt,
+	}
+
+	if entry.OldValue.Valid {
+		oldValue := entry.OldValue.String
+		response.OldValue = &oldValue
+	}
+
+	if entry.NewValue.Valid {
+		newValue := entry.NewValue.String
+		response.NewValue = &newValue
+	}
+
+	if entry.ChangedBy.Valid {
+		changedBy := entry.ChangedBy.String
+		response.ChangedBy = &changedBy
+	}
+
+	return response
+}
+
+// ListSystemSettingHistory retrieves the change log for a system setting with pagination
+func (s *SettingsService) ListSystemSettingHistory(ctx context.Context, key string, page, limit int) (*SettingHistoryListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	// Count total
+	var total int
+	countQuery := `SELECT COUNT(*) FROM settings_history WHERE setting_key = $1`
+	if err := s.db.QueryRowContext(ctx, countQuery, key).Scan(&total); err != nil {
+		return nil, fmt.Errorf("failed to count setting history: %w", err)
+	}
+
+	// Query history
+	query := `
+		SELECT id, setting_key, action, old_value, new_value, value_changed, is_encrypted, changed_by, created_at
+		FROM settings_history
+		WHERE setting_key = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, key, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list setting history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []*SettingHistoryResponse
+	for rows.Next() {
+		var entry models.SettingHistory
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.SettingKey,
+			&entry.Action,
+			&entry.OldValue,
+			&entry.NewValue,
+			&entry.ValueChanged,
+			&entry.IsEncrypted,
+			&entry.ChangedBy,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan setting history: %w", err)
+		}
+		history = append(history, s.toHistoryResponse(&entry))
+	}
+
+	if history == nil {
+		history = []*SettingHistoryResponse{}
+	}
+
+	totalPages := (total + limit - 1) / limit
+
+	return &SettingHistoryListResponse{
+		History:    history,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: totalPages,
+	}, nil
+}
+
 // GetUserSetting retrieves a user setting by key
-func (s *SettingsService) GetUserSetting(userID, key string) (*SettingResponse, error) {
+func (s *SettingsService) GetUserSetting(ctx context.Context, userID, key string) (*SettingResponse, error) {
 	// Try cache first
 	cacheKey := s.getCacheKey(&userID, key)
 	var cached models.Setting
@@ -500,7 +635,7 @@ func (s *SettingsService) GetUserSetting(userID, key string) (*SettingResponse,
 	`
 
 	var setting models.Setting
-	err := s.db.QueryRow(query, userID, key).Scan(
+	err := s.db.QueryRowContext(ctx, query, userID, key).Scan(
 		&setting.ID,
 		&setting.UserID,
 		&setting.Key,
@@ -513,7 +648,7 @@ func (s *SettingsService) GetUserSetting(userID, key string) (*SettingResponse,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user setting not found")
+		return nil, ErrUserSettingNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user setting: %w", err)
@@ -534,7 +669,7 @@ func (s *SettingsService) GetUserSetting(userID, key string) (*SettingResponse,
 }
 
 // ListUserSettings retrieves all user settings
-func (s *SettingsService) ListUserSettings(userID string, page, limit int) (*SettingsListResponse, error) {
+func (s *SettingsService) ListUserSettings(ctx context.Context, userID string, page, limit int) (*SettingsListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -547,7 +682,7 @@ func (s *SettingsService) ListUserSettings(userID string, page, limit int) (*Set
 	// Count total
 	var total int
 	countQuery := `SELECT COUNT(*) FROM settings WHERE user_id = $1`
-	if err := s.db.QueryRow(countQuery, userID).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, countQuery, userID).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count user settings: %w", err)
 	}
 
@@ -560,7 +695,7 @@ func (s *SettingsService) ListUserSettings(userID string, page, limit int) (*Set
 		LIMIT $2 OFFSET $3
 	`
 
-	rows, err := s.db.Query(query, userID, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, userID, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list user settings: %w", err)
 	}
@@ -610,7 +745,7 @@ func (s *SettingsService) ListUserSettings(userID string, page, limit int) (*Set
 }
 
 // CreateOrUpdateUserSetting creates or updates a user setting
-func (s *SettingsService) CreateOrUpdateUserSetting(userID, key string, req *UpdateSettingRequest) (*SettingResponse, error) {
+func (s *SettingsService) CreateOrUpdateUserSetting(ctx context.Context, userID, key string, req *UpdateSettingRequest) (*SettingResponse, error) {
 	// Validate key
 	if err := s.validateKey(key); err != nil {
 		return nil, err
@@ -641,10 +776,10 @@ func (s *SettingsService) CreateOrUpdateUserSetting(userID, key string, req *Upd
 		RETURNING id, user_id, key, value, type, is_encrypted, description, created_at, updated_at
 	`
 
-	now := time.Now().UTC()
+	now := clients.Now()
 	var setting models.Setting
 
-	err := s.db.QueryRow(
+	err := s.db.QueryRowContext(ctx,
 		query,
 		userID,
 		key,
@@ -686,10 +821,10 @@ func (s *SettingsService) CreateOrUpdateUserSetting(userID, key string, req *Upd
 }
 
 // DeleteUserSetting deletes a user setting by key
-func (s *SettingsService) DeleteUserSetting(userID, key string) error {
+func (s *SettingsService) DeleteUserSetting(ctx context.Context, userID, key string) error {
 	query := `DELETE FROM settings WHERE user_id = $1 AND key = $2`
 
-	result, err := s.db.Exec(query, userID, key)
+	result, err := s.db.ExecContext(ctx, query, userID, key)
 	if err != nil {
 		return fmt.Errorf("failed to delete user setting: %w", err)
 	}
@@ -700,7 +835,7 @@ func (s *SettingsService) DeleteUserSetting(userID, key string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("user setting not found")
+		return ErrUserSettingNotFound
 	}
 
 	// Invalidate cache
@@ -7,7 +7,7 @@ import (
 // UploadRequest represents file metadata for upload
 type UploadRequest struct {
 	Visibility string `form:"visibility" binding:"required,oneof=public private"`
-	Metadata   string `form:"metadata"` // Optional JSON metadata
+	Metadata   string `form:"metadata"` // Optional JSON object, at most 4096 bytes
 }
 
 // FileResponse represents a file response
@@ -21,6 +21,7 @@ type FileResponse struct {
 	StorageType  string            `json:"storage_type"`
 	Visibility   string            `json:"visibility"`
 	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+	Checksum     string            `json:"checksum,omitempty"`
 	DownloadURL  string            `json:"download_url"`
 	CreatedAt    time.Time         `json:"created_at"`
 	UpdatedAt    time.Time         `json:"updated_at"`
@@ -29,7 +30,7 @@ type FileResponse struct {
 // UpdateFileRequest represents a file update request
 type UpdateFileRequest struct {
 	Visibility string `json:"visibility" binding:"omitempty,oneof=public private"`
-	Metadata   string `json:"metadata"` // Optional JSON metadata
+	Metadata   string `json:"metadata"` // Optional JSON object, at most 4096 bytes
 }
 
 // FilesListResponse represents a paginated list of files
@@ -45,3 +46,28 @@ type FilesListResponse struct {
 type FileUploadResponse struct {
 	File *FileResponse `json:"file"`
 }
+
+// PresignedURLResponse represents a time-limited download link
+type PresignedURLResponse struct {
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ShareFileRequest represents a request to share a file with a user
+type ShareFileRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+// TransferFileRequest represents a request to transfer file ownership
+type TransferFileRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+// FileShareResponse represents a file share
+type FileShareResponse struct {
+	ID        string    `json:"id"`
+	FileID    string    `json:"file_id"`
+	UserID    string    `json:"user_id"`
+	SharedBy  string    `json:"shared_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -1,13 +1,18 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"os"
 	"strconv"
 
+	"gogin/internal/models"
 	"gogin/internal/response"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // uploadFile handles file upload
@@ -19,7 +24,7 @@ import (
 // @Security BearerAuth
 // @Param file formData file true "File to upload"
 // @Param visibility formData string true "File visibility (public or private)"
-// @Param metadata formData string false "Optional JSON metadata"
+// @Param metadata formData string false "Optional JSON object metadata, at most 4096 bytes"
 // @Success 201 {object} response.Response{data=FileUploadResponse}
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
@@ -35,16 +40,16 @@ func (m *StorageModule) uploadFile(c *gin.Context) {
 	// Parse multipart form
 	var req UploadRequest
 	if err := c.ShouldBind(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
 	// Get file from form
 	file, err := c.FormFile("file")
 	if err != nil {
+		if response.BodyTooLarge(c, err) {
+			return
+		}
 		response.BadRequest(c, "No file provided")
 		return
 	}
@@ -52,19 +57,16 @@ func (m *StorageModule) uploadFile(c *gin.Context) {
 	// Upload file
 	uploadedFile, err := m.service.UploadFile(file, &req, userID)
 	if err != nil {
-		if err.Error() == fmt.Sprintf("file size exceeds maximum allowed size of %d bytes", m.config.Storage.MaxFileSize) {
-			response.Error(c, http.StatusRequestEntityTooLarge, "File too large", err.Error())
+		if errors.Is(err, ErrFileTooLarge) {
+			response.PayloadTooLarge(c, err.Error())
 			return
 		}
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to upload file")
 		return
 	}
 
 	// Get base URL for download links
-	baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
-	if baseURL == "://" {
-		baseURL = "http://" + c.Request.Host
-	}
+	baseURL := BaseURL(c, m.config.App.PublicURL)
 
 	fileResp := m.service.ToFileResponse(uploadedFile, baseURL)
 
@@ -78,7 +80,7 @@ func (m *StorageModule) uploadFile(c *gin.Context) {
 // @Description Get a paginated list of files (public files + user's private files if authenticated)
 // @Tags Storage
 // @Produce json
-// @Param visibility query string false "Filter by visibility (public or private)"
+// @Param visibility query string false "Filter by visibility: public, or private for only the caller's private files"
 // @Param page query int false "Page number (default: 1)"
 // @Param limit query int false "Items per page (default: 20, max: 100)"
 // @Success 200 {object} response.Response{data=FilesListResponse}
@@ -107,15 +109,12 @@ func (m *StorageModule) listFiles(c *gin.Context) {
 	// List files
 	files, total, err := m.service.ListFiles(userID, visibility, page, limit)
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to list files")
 		return
 	}
 
 	// Get base URL for download links
-	baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
-	if baseURL == "://" {
-		baseURL = "http://" + c.Request.Host
-	}
+	baseURL := BaseURL(c, m.config.App.PublicURL)
 
 	// Convert to response DTOs
 	fileResponses := make([]*FileResponse, len(files))
@@ -156,19 +155,12 @@ func (m *StorageModule) getFile(c *gin.Context) {
 
 	file, err := m.service.GetFile(fileID, userID)
 	if err != nil {
-		if err.Error() == "access denied" {
-			response.Forbidden(c, "Access denied")
-			return
-		}
-		response.NotFound(c, "File not found")
+		response.HandleServiceError(c, err, "Failed to get file")
 		return
 	}
 
 	// Get base URL for download links
-	baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
-	if baseURL == "://" {
-		baseURL = "http://" + c.Request.Host
-	}
+	baseURL := BaseURL(c, m.config.App.PublicURL)
 
 	fileResp := m.service.ToFileResponse(file, baseURL)
 
@@ -183,7 +175,11 @@ func (m *StorageModule) getFile(c *gin.Context) {
 // @Tags Storage
 // @Produce application/octet-stream
 // @Param id path string true "File ID"
+// @Param expires query int false "Presigned URL expiry (unix seconds)"
+// @Param signature query string false "Presigned URL signature"
+// @Param Range header string false "Byte range, e.g. bytes=0-1023"
 // @Success 200 {file} binary "File content"
+// @Success 206 {file} binary "Partial file content"
 // @Failure 403 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /storage/files/{id}/download [get]
@@ -196,24 +192,206 @@ func (m *StorageModule) downloadFile(c *gin.Context) {
 		userID = uid.(string)
 	}
 
-	file, err := m.service.GetFile(fileID, userID)
+	var file *models.File
+	var err error
+	if c.GetString("signed_file_id") == fileID {
+		// Presigned URL already authorized access to this file
+		file, err = m.service.GetSignedFile(fileID)
+	} else {
+		file, err = m.service.GetFile(fileID, userID)
+	}
 	if err != nil {
-		if err.Error() == "access denied" {
-			response.Forbidden(c, "Access denied")
+		response.HandleServiceError(c, err, "Failed to get file")
+		return
+	}
+
+	ServeFile(c, file)
+}
+
+// ServeFile streams a file the caller has already been authorized to download
+func ServeFile(c *gin.Context, file *models.File) {
+	// Make sure the content is still on disk before streaming
+	content, err := os.Open(file.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			response.NotFound(c, "File content not found")
 			return
 		}
-		response.NotFound(c, "File not found")
+		response.InternalError(c, "Failed to open file")
+		return
+	}
+	defer content.Close()
+
+	info, err := content.Stat()
+	if err != nil {
+		response.InternalError(c, "Failed to read file")
 		return
 	}
 
 	// Set headers for download
-	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Transfer-Encoding", "binary")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", file.OriginalName))
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.OriginalName}))
 	c.Header("Content-Type", file.MimeType)
 
-	// Serve the file
-	c.File(file.Path)
+	// ServeContent handles Range, If-Modified-Since and Content-Length
+	http.ServeContent(c.Writer, c.Request, file.OriginalName, info.ModTime(), content)
+}
+
+// presignFile creates a time-limited download URL
+// @Summary Create presigned download URL
+// @Description Create a time-limited signed download URL for a file the caller can access
+// @Tags Storage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Success 200 {object} response.Response{data=PresignedURLResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /storage/files/{id}/presign [get]
+func (m *StorageModule) presignFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	// Get user ID from context (required for presign)
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Authentication required")
+		return
+	}
+
+	// Get base URL for download links
+	baseURL := BaseURL(c, m.config.App.PublicURL)
+
+	presigned, err := m.service.PresignFile(fileID, userID.(string), baseURL)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to create presigned URL")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Presigned URL created successfully", presigned)
+}
+
+// shareFile grants a user read access to a private file
+// @Summary Share a file
+// @Description Grant another user read access to a file (owner only)
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param request body ShareFileRequest true "User to share with"
+// @Success 201 {object} response.Response{data=object{share=FileShareResponse}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /storage/files/{id}/share [post]
+func (m *StorageModule) shareFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Authentication required")
+		return
+	}
+
+	var req ShareFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	share, err := m.service.ShareFile(fileID, userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to share file")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "File shared successfully", gin.H{
+		"share": share,
+	})
+}
+
+// unshareFile revokes a user's access to a file
+// @Summary Unshare a file
+// @Description Revoke a user's read access to a file (owner only)
+// @Tags Storage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param userId path string true "User ID"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /storage/files/{id}/share/{userId} [delete]
+func (m *StorageModule) unshareFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Authentication required")
+		return
+	}
+
+	sharedWith := c.Param("userId")
+	if _, err := uuid.Parse(sharedWith); err != nil {
+		response.BadRequest(c, "userId must be a user ID")
+		return
+	}
+
+	err := m.service.UnshareFile(fileID, userID.(string), sharedWith)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to unshare file")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "File unshared successfully", nil)
+}
+
+// transferFile transfers file ownership to another user
+// @Summary Transfer file ownership
+// @Description Transfer ownership of a file to another user (owner only)
+// @Tags Storage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "File ID"
+// @Param request body TransferFileRequest true "New owner"
+// @Success 200 {object} response.Response{data=object{file=FileResponse}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /storage/files/{id}/owner [put]
+func (m *StorageModule) transferFile(c *gin.Context) {
+	fileID := c.Param("id")
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "Authentication required")
+		return
+	}
+
+	var req TransferFileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	file, err := m.service.TransferFile(fileID, userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to transfer file")
+		return
+	}
+
+	// Get base URL for download links
+	baseURL := BaseURL(c, m.config.App.PublicURL)
+
+	response.Success(c, http.StatusOK, "File ownership transferred successfully", gin.H{
+		"file": m.service.ToFileResponse(file, baseURL),
+	})
 }
 
 // updateFile updates file metadata
@@ -243,32 +421,18 @@ func (m *StorageModule) updateFile(c *gin.Context) {
 
 	var req UpdateFileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
 	file, err := m.service.UpdateFile(fileID, &req, userID.(string))
 	if err != nil {
-		if err.Error() == "access denied" {
-			response.Forbidden(c, "Access denied")
-			return
-		}
-		if err.Error() == "file not found" {
-			response.NotFound(c, "File not found")
-			return
-		}
-		response.BadRequest(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to update file")
 		return
 	}
 
 	// Get base URL for download links
-	baseURL := fmt.Sprintf("%s://%s", c.Request.URL.Scheme, c.Request.Host)
-	if baseURL == "://" {
-		baseURL = "http://" + c.Request.Host
-	}
+	baseURL := BaseURL(c, m.config.App.PublicURL)
 
 	fileResp := m.service.ToFileResponse(file, baseURL)
 
@@ -277,13 +441,14 @@ func (m *StorageModule) updateFile(c *gin.Context) {
 	})
 }
 
-// deleteFile soft deletes a file
+// deleteFile soft deletes a file, or permanently deletes it for admins
 // @Summary Delete a file
-// @Description Soft delete a file by ID
+// @Description Soft delete a file by ID. Admins may pass permanent=true to remove the physical file and record immediately.
 // @Tags Storage
 // @Produce json
 // @Security BearerAuth
 // @Param id path string true "File ID"
+// @Param permanent query bool false "Permanently delete the file (admin only)"
 // @Success 200 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
@@ -299,19 +464,41 @@ func (m *StorageModule) deleteFile(c *gin.Context) {
 		return
 	}
 
-	err := m.service.DeleteFile(fileID, userID.(string))
-	if err != nil {
-		if err.Error() == "access denied" {
-			response.Forbidden(c, "Access denied")
+	if c.Query("permanent") == "true" {
+		role, _ := c.Get("role")
+		if role != "admin" {
+			response.Forbidden(c, "Only admins can permanently delete files")
 			return
 		}
-		if err.Error() == "file not found" {
-			response.NotFound(c, "File not found")
+
+		if err := m.service.HardDeleteFile(fileID); err != nil {
+			response.HandleServiceError(c, err, "Failed to delete file")
 			return
 		}
-		response.BadRequest(c, err.Error())
+
+		response.Success(c, http.StatusOK, "File permanently deleted", nil)
+		return
+	}
+
+	err := m.service.DeleteFile(fileID, userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to delete file")
 		return
 	}
 
 	response.Success(c, http.StatusOK, "File deleted successfully", nil)
 }
+
+// BaseURL returns the scheme and host download links are built on: publicURL when it
+// is configured, otherwise the host the request was made to
+func BaseURL(c *gin.Context, publicURL string) string {
+	if publicURL != "" {
+		return publicURL
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, c.Request.Host)
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// UploadFormOverhead is the room left for multipart boundaries and form fields on uploads
+const UploadFormOverhead = 1 << 20
+
 // StorageModule handles file storage
 type StorageModule struct {
 	service        *StorageService
@@ -19,7 +22,7 @@ type StorageModule struct {
 
 // NewStorageModule creates a new storage module
 func NewStorageModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *StorageModule {
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	redisHelper := redishelper.NewRedisHelper(redis)
 	authMiddleware := middleware.NewAuthMiddleware(jwtUtil, redisHelper)
 
@@ -36,8 +39,8 @@ func NewStorageModule(db *clients.Database, redis *clients.RedisClient, cfg *con
 func (m *StorageModule) RegisterRoutes(router *gin.RouterGroup) {
 	storage := router.Group("/storage")
 	{
-		// Upload route - requires authentication
-		storage.POST("/upload", m.authMiddleware.RequireAuth(), m.uploadFile)
+		// Upload route - requires authentication, allows bodies up to the max file size plus form fields
+		storage.POST("/upload", middleware.BodyLimit(m.config.Storage.MaxFileSize+UploadFormOverhead), m.authMiddleware.RequireAuth(), m.uploadFile)
 
 		// Files routes - public access with optional auth for private files
 		files := storage.Group("/files")
@@ -48,14 +51,26 @@ func (m *StorageModule) RegisterRoutes(router *gin.RouterGroup) {
 			// Get file metadata - public for public files, requires auth for private files
 			files.GET("/:id", m.authMiddleware.OptionalAuth(), m.getFile)
 
-			// Download file - public for public files, requires auth for private files
-			files.GET("/:id/download", m.authMiddleware.OptionalAuth(), m.downloadFile)
+			// Download file - public for public files, requires auth or a presigned URL for private files
+			files.GET("/:id/download", m.authMiddleware.OptionalAuth(), middleware.SignedURL(m.config.App.EncryptionKey), m.downloadFile)
+
+			// Presign download URL - requires authentication
+			files.GET("/:id/presign", m.authMiddleware.RequireAuth(), m.presignFile)
 
 			// Update file - requires authentication
 			files.PUT("/:id", m.authMiddleware.RequireAuth(), m.updateFile)
 
 			// Delete file - requires authentication
 			files.DELETE("/:id", m.authMiddleware.RequireAuth(), m.deleteFile)
+
+			// Share file with a user - owner only
+			files.POST("/:id/share", m.authMiddleware.RequireAuth(), m.shareFile)
+
+			// Revoke a user's access - owner only
+			files.DELETE("/:id/share/:userId", m.authMiddleware.RequireAuth(), m.unshareFile)
+
+			// Transfer ownership - owner only
+			files.PUT("/:id/owner", m.authMiddleware.RequireAuth(), m.transferFile)
 		}
 	}
 }
@@ -1,11 +1,15 @@
 package storage
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,10 +18,32 @@ import (
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/models"
+	"gogin/internal/response"
+	"gogin/internal/utils"
 
 	"github.com/google/uuid"
 )
 
+// Errors returned by the storage service; match them with errors.Is
+var (
+	ErrFileNotFound       = response.NotFoundError("file not found")
+	ErrShareNotFound      = response.NotFoundError("share not found")
+	ErrUserNotFound       = response.NotFoundError("user not found")
+	ErrAccessDenied       = response.ForbiddenError("access denied")
+	ErrShareWithOwner     = response.InvalidError("cannot share a file with its owner")
+	ErrFileTooLarge       = response.InvalidError("file too large")
+	ErrFileTypeNotAllowed = response.InvalidError("file type not allowed")
+	ErrInvalidVisibility  = response.InvalidError("visibility must be public or private")
+	ErrInvalidMetadata    = response.InvalidError("metadata must be a JSON object")
+	ErrMetadataTooLarge   = response.InvalidError("metadata too large")
+)
+
+// maxMetadataSize caps the JSON metadata stored with a file, in bytes
+const maxMetadataSize = 4096
+
+// avatarMetadata marks files uploaded as user avatars so earlier ones can be found and cleaned up
+const avatarMetadata = `{"purpose":"avatar"}`
+
 // StorageService handles file storage business logic
 type StorageService struct {
 	db     *clients.Database
@@ -34,28 +60,122 @@ func NewStorageService(db *clients.Database, cfg *config.Config) *StorageService
 
 // UploadFile handles file upload
 func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadRequest, userID string) (*models.File, error) {
+	if err := validateMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+
 	// Validate file size
 	if file.Size > s.config.Storage.MaxFileSize {
-		return nil, fmt.Errorf("file size exceeds maximum allowed size of %d bytes", s.config.Storage.MaxFileSize)
+		return nil, fmt.Errorf("%w: maximum allowed size is %d bytes", ErrFileTooLarge, s.config.Storage.MaxFileSize)
 	}
 
-	// Generate unique filename
-	fileID := uuid.New().String()
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%s%s", fileID, ext)
+	// Detect the real content type instead of trusting the client header
+	mimeType, err := s.detectMimeType(file)
+	if err != nil {
+		return nil, err
+	}
+	if !s.isMimeTypeAllowed(mimeType) {
+		return nil, fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, mimeType)
+	}
 
-	// Determine storage path
-	var filePath string
-	var storageType string
+	return s.storeFile(file, req, userID, mimeType)
+}
 
+// UploadAvatar stores an image as a public avatar file, enforcing the avatar size and
+// type limits rather than the general upload ones
+func (s *StorageService) UploadAvatar(file *multipart.FileHeader, userID string) (*models.File, error) {
+	if file.Size > s.config.Storage.AvatarMaxSize {
+		return nil, fmt.Errorf("%w: maximum allowed avatar size is %d bytes", ErrFileTooLarge, s.config.Storage.AvatarMaxSize)
+	}
+
+	mimeType, err := s.detectMimeType(file)
+	if err != nil {
+		return nil, err
+	}
+	if !mimeTypeMatches(s.config.Storage.AvatarMimeTypes, mimeType) {
+		return nil, fmt.Errorf("%w: %s", ErrFileTypeNotAllowed, mimeType)
+	}
+
+	req := &UploadRequest{Visibility: "public", Metadata: avatarMetadata}
+	return s.storeFile(file, req, userID, mimeType)
+}
+
+// DeleteAvatarsExcept soft deletes the user's avatar files other than keepID, leaving
+// them for the purge worker to remove
+func (s *StorageService) DeleteAvatarsExcept(userID, keepID string) error {
+	query := `
+		UPDATE files
+		SET deleted_at = $1, updated_at = $1
+		WHERE user_id = $2 AND id <> $3 AND deleted_at IS NULL AND metadata->>'purpose' = 'avatar'
+	`
+
+	if _, err := s.db.DB.Exec(query, clients.Now(), userID, keepID); err != nil {
+		return fmt.Errorf("failed to delete previous avatars: %w", err)
+	}
+	return nil
+}
+
+// validateMetadata checks that metadata, when given, is a JSON object within the size limit,
+// so it always parses when the file is read back
+func validateMetadata(metadata string) error {
+	if metadata == "" {
+		return nil
+	}
+	if len(metadata) > maxMetadataSize {
+		return fmt.Errorf("%w: maximum allowed size is %d bytes", ErrMetadataTooLarge, maxMetadataSize)
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &object); err != nil || object == nil {
+		return ErrInvalidMetadata
+	}
+	return nil
+}
+
+// storeFile saves a validated upload and records it. When the user already stored
+// identical contents, the new record references that object instead of writing a copy.
+func (s *StorageService) storeFile(file *multipart.FileHeader, req *UploadRequest, userID, mimeType string) (*models.File, error) {
 	if s.config.Storage.Type == "s3" {
 		// TODO: Implement S3 upload
 		return nil, fmt.Errorf("S3 storage not yet implemented")
-	} else {
-		// Local storage
-		storageType = "local"
-		filePath = filepath.Join(s.config.Storage.BasePath, fileName)
+	}
+
+	checksum, err := fileChecksum(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Generate unique filename
+	fileID := uuid.New().String()
+	ext := filepath.Ext(file.Filename)
+	fileName := fmt.Sprintf("%s%s", fileID, ext)
+	storageType := "local"
+	filePath := filepath.Join(s.config.Storage.BasePath, fileName)
+
+	// Lock the matching row so a concurrent hard delete cannot remove the object before
+	// the new reference is committed
+	found := false
+	if userID != "" {
+		err := tx.QueryRow(`
+			SELECT file_name, path FROM files
+			WHERE user_id = $1 AND checksum = $2 AND storage_type = $3
+			LIMIT 1
+			FOR SHARE
+		`, userID, checksum, storageType).Scan(&fileName, &filePath)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to look up existing file: %w", err)
+		}
+		found = err == nil
+	}
 
+	written := false
+	if !found {
 		// Ensure storage directory exists
 		if err := os.MkdirAll(s.config.Storage.BasePath, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create storage directory: %w", err)
@@ -65,6 +185,7 @@ func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadReque
 		if err := s.saveFile(file, filePath); err != nil {
 			return nil, fmt.Errorf("failed to save file: %w", err)
 		}
+		written = true
 	}
 
 	// Create file record
@@ -73,13 +194,14 @@ func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadReque
 		UserID:       sql.NullString{String: userID, Valid: userID != ""},
 		FileName:     fileName,
 		OriginalName: file.Filename,
-		MimeType:     file.Header.Get("Content-Type"),
+		MimeType:     mimeType,
 		Size:         file.Size,
 		Path:         filePath,
 		StorageType:  storageType,
 		Visibility:   req.Visibility,
-		CreatedAt:    time.Now().UTC(),
-		UpdatedAt:    time.Now().UTC(),
+		Checksum:     sql.NullString{String: checksum, Valid: true},
+		CreatedAt:    clients.Now(),
+		UpdatedAt:    clients.Now(),
 	}
 
 	// Store metadata if provided
@@ -89,11 +211,11 @@ func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadReque
 
 	// Insert into database
 	query := `
-		INSERT INTO files (id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO files (id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, checksum, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
-	_, err := s.db.DB.Exec(query,
+	_, err = tx.Exec(query,
 		fileModel.ID,
 		fileModel.UserID,
 		fileModel.FileName,
@@ -104,13 +226,17 @@ func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadReque
 		fileModel.StorageType,
 		fileModel.Visibility,
 		fileModel.Metadata,
+		fileModel.Checksum,
 		fileModel.CreatedAt,
 		fileModel.UpdatedAt,
 	)
+	if err == nil {
+		err = tx.Commit()
+	}
 
 	if err != nil {
-		// Clean up file if database insert fails
-		if storageType == "local" {
+		// Clean up the file if it was written for this record
+		if written {
 			os.Remove(filePath)
 		}
 		return nil, fmt.Errorf("failed to create file record: %w", err)
@@ -119,6 +245,70 @@ func (s *StorageService) UploadFile(file *multipart.FileHeader, req *UploadReque
 	return fileModel, nil
 }
 
+// fileChecksum returns the hex SHA-256 of an upload's contents
+func fileChecksum(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, src); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// detectMimeType sniffs the first 512 bytes of an upload to determine its content type
+func (s *StorageService) detectMimeType(file *multipart.FileHeader) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	defer src.Close()
+
+	buffer := make([]byte, 512)
+	n, err := io.ReadFull(src, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
+	mimeType := http.DetectContentType(buffer[:n])
+
+	// Drop parameters such as "; charset=utf-8"
+	if idx := strings.Index(mimeType, ";"); idx != -1 {
+		mimeType = strings.TrimSpace(mimeType[:idx])
+	}
+
+	return mimeType, nil
+}
+
+// isMimeTypeAllowed checks a content type against the configured allowlist
+func (s *StorageService) isMimeTypeAllowed(mimeType string) bool {
+	allowed := s.config.Storage.AllowedMimeTypes
+	if len(allowed) == 0 {
+		return true
+	}
+
+	return mimeTypeMatches(allowed, mimeType)
+}
+
+// mimeTypeMatches reports whether a content type matches any of the patterns
+func mimeTypeMatches(patterns []string, mimeType string) bool {
+	for _, pattern := range patterns {
+		if pattern == "*" || pattern == mimeType {
+			return true
+		}
+		// Wildcard subtypes, e.g. image/*
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mimeType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // saveFile saves uploaded file to disk
 func (s *StorageService) saveFile(file *multipart.FileHeader, dst string) error {
 	src, err := file.Open()
@@ -137,10 +327,225 @@ func (s *StorageService) saveFile(file *multipart.FileHeader, dst string) error
 	return err
 }
 
-// GetFile retrieves a file by ID
+// GetFile retrieves a file by ID, enforcing private file access
 func (s *StorageService) GetFile(fileID string, userID string) (*models.File, error) {
+	file, err := s.getFileRecord(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check permissions for private files
+	if file.Visibility == "private" {
+		// If file has a user, only that user or users it is shared with can access it
+		if file.UserID.Valid && file.UserID.String != userID {
+			shared, err := s.isSharedWith(file.ID, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !shared {
+				return nil, ErrAccessDenied
+			}
+		}
+	}
+
+	return file, nil
+}
+
+// isSharedWith reports whether a file has been shared with a user
+func (s *StorageService) isSharedWith(fileID, userID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	var shared bool
+	err := s.db.DB.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM file_shares WHERE file_id = $1 AND user_id = $2)`,
+		fileID, userID,
+	).Scan(&shared)
+	if err != nil {
+		return false, fmt.Errorf("failed to check file share: %w", err)
+	}
+
+	return shared, nil
+}
+
+// getOwnedFile retrieves a file and verifies the caller owns it
+func (s *StorageService) getOwnedFile(fileID, userID string) (*models.File, error) {
+	file, err := s.getFileRecord(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !file.UserID.Valid || file.UserID.String != userID {
+		return nil, ErrAccessDenied
+	}
+
+	return file, nil
+}
+
+// userExists checks that an active user exists
+func (s *StorageService) userExists(userID string) (bool, error) {
+	var exists bool
+	err := s.db.DB.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)`,
+		userID,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ShareFile grants another user read access to a file (owner only)
+func (s *StorageService) ShareFile(fileID, ownerID string, req *ShareFileRequest) (*FileShareResponse, error) {
+	if _, err := s.getOwnedFile(fileID, ownerID); err != nil {
+		return nil, err
+	}
+
+	if req.UserID == ownerID {
+		return nil, ErrShareWithOwner
+	}
+
+	exists, err := s.userExists(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	query := `
+		INSERT INTO file_shares (file_id, user_id, shared_by, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (file_id, user_id) DO UPDATE SET shared_by = EXCLUDED.shared_by
+		RETURNING id, file_id, user_id, shared_by, created_at
+	`
+
+	var share models.FileShare
+	err = s.db.DB.QueryRow(query, fileID, req.UserID, ownerID, clients.Now()).Scan(
+		&share.ID,
+		&share.FileID,
+		&share.UserID,
+		&share.SharedBy,
+		&share.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to share file: %w", err)
+	}
+
+	response := &FileShareResponse{
+		ID:        share.ID,
+		FileID:    share.FileID,
+		UserID:    share.UserID,
+		CreatedAt: share.CreatedAt,
+	}
+	if share.SharedBy.Valid {
+		response.SharedBy = share.SharedBy.String
+	}
+
+	return response, nil
+}
+
+// UnshareFile revokes a user's access to a file (owner only)
+func (s *StorageService) UnshareFile(fileID, ownerID, userID string) error {
+	if _, err := s.getOwnedFile(fileID, ownerID); err != nil {
+		return err
+	}
+
+	result, err := s.db.DB.Exec(`DELETE FROM file_shares WHERE file_id = $1 AND user_id = $2`, fileID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to unshare file: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrShareNotFound
+	}
+
+	return nil
+}
+
+// TransferFile transfers ownership of a file to another user (owner only)
+func (s *StorageService) TransferFile(fileID, ownerID string, req *TransferFileRequest) (*models.File, error) {
+	file, err := s.getOwnedFile(fileID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := s.userExists(req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := clients.Now()
+	if _, err := tx.Exec(`UPDATE files SET user_id = $1, updated_at = $2 WHERE id = $3`, req.UserID, now, fileID); err != nil {
+		return nil, fmt.Errorf("failed to transfer file: %w", err)
+	}
+
+	// The new owner no longer needs a share entry
+	if _, err := tx.Exec(`DELETE FROM file_shares WHERE file_id = $1 AND user_id = $2`, fileID, req.UserID); err != nil {
+		return nil, fmt.Errorf("failed to transfer file: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	file.UserID = sql.NullString{String: req.UserID, Valid: true}
+	file.UpdatedAt = now
+
+	return file, nil
+}
+
+// GetSignedFile retrieves a file for a download authorized by a presigned URL
+func (s *StorageService) GetSignedFile(fileID string) (*models.File, error) {
+	return s.getFileRecord(fileID)
+}
+
+// GetAuthorizedFile retrieves a file whose access was checked elsewhere, e.g. a ticket
+// attachment the caller can see through the ticket
+func (s *StorageService) GetAuthorizedFile(fileID string) (*models.File, error) {
+	return s.getFileRecord(fileID)
+}
+
+// PresignFile creates a time-limited download URL for a file the caller can access
+func (s *StorageService) PresignFile(fileID, userID, baseURL string) (*PresignedURLResponse, error) {
+	file, err := s.GetFile(fileID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if file.StorageType != "local" {
+		return nil, fmt.Errorf("presigned URLs are not supported for %s storage", file.StorageType)
+	}
+
+	expiresAt := time.Now().UTC().Add(s.config.Storage.PresignExpiry).Truncate(time.Second)
+	signature := utils.SignFileURL(s.config.App.EncryptionKey, file.ID, expiresAt.Unix())
+
+	return &PresignedURLResponse{
+		URL:       fmt.Sprintf("%s?expires=%d&signature=%s", DownloadURL(baseURL, file.ID), expiresAt.Unix(), signature),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// getFileRecord retrieves a non-deleted file by ID without access checks
+func (s *StorageService) getFileRecord(fileID string) (*models.File, error) {
 	query := `
-		SELECT id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, created_at, updated_at, deleted_at
+		SELECT id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, checksum, created_at, updated_at, deleted_at
 		FROM files
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -157,26 +562,19 @@ func (s *StorageService) GetFile(fileID string, userID string) (*models.File, er
 		&file.StorageType,
 		&file.Visibility,
 		&file.Metadata,
+		&file.Checksum,
 		&file.CreatedAt,
 		&file.UpdatedAt,
 		&file.DeletedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("file not found")
+		return nil, ErrFileNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file: %w", err)
 	}
 
-	// Check permissions for private files
-	if file.Visibility == "private" {
-		// If file has a user, only that user can access it
-		if file.UserID.Valid && file.UserID.String != userID {
-			return nil, fmt.Errorf("access denied")
-		}
-	}
-
 	return &file, nil
 }
 
@@ -189,24 +587,29 @@ func (s *StorageService) ListFiles(userID string, visibility string, page, limit
 	args := []interface{}{}
 	argCount := 1
 
-	// Filter by visibility if specified
-	if visibility != "" && (visibility == "public" || visibility == "private") {
-		conditions = append(conditions, fmt.Sprintf("visibility = $%d", argCount))
-		args = append(args, visibility)
+	// private: only the caller's private files; public: only public files;
+	// no filter: public files plus the caller's private files
+	switch visibility {
+	case "private":
+		if userID == "" {
+			// Anonymous callers own no files
+			return []*models.File{}, 0, nil
+		}
+		conditions = append(conditions, fmt.Sprintf("visibility = 'private' AND user_id = $%d", argCount))
+		args = append(args, userID)
 		argCount++
-	}
-
-	// For private files, show only user's files
-	// For public files or mixed, show public files + user's private files
-	if visibility == "private" || visibility == "" {
+	case "public":
+		conditions = append(conditions, "visibility = 'public'")
+	case "":
 		if userID != "" {
 			conditions = append(conditions, fmt.Sprintf("(visibility = 'public' OR user_id = $%d)", argCount))
 			args = append(args, userID)
 			argCount++
 		} else {
-			// If no user, only show public files
 			conditions = append(conditions, "visibility = 'public'")
 		}
+	default:
+		return nil, 0, ErrInvalidVisibility
 	}
 
 	whereClause := strings.Join(conditions, " AND ")
@@ -221,7 +624,7 @@ func (s *StorageService) ListFiles(userID string, visibility string, page, limit
 
 	// Get files
 	query := fmt.Sprintf(`
-		SELECT id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, created_at, updated_at, deleted_at
+		SELECT id, user_id, file_name, original_name, mime_type, size, path, storage_type, visibility, metadata, checksum, created_at, updated_at, deleted_at
 		FROM files
 		WHERE %s
 		ORDER BY created_at DESC
@@ -250,6 +653,7 @@ func (s *StorageService) ListFiles(userID string, visibility string, page, limit
 			&file.StorageType,
 			&file.Visibility,
 			&file.Metadata,
+			&file.Checksum,
 			&file.CreatedAt,
 			&file.UpdatedAt,
 			&file.DeletedAt,
@@ -273,7 +677,7 @@ func (s *StorageService) DeleteFile(fileID string, userID string) error {
 
 	// Check ownership for deletion
 	if file.UserID.Valid && file.UserID.String != userID {
-		return fmt.Errorf("access denied")
+		return ErrAccessDenied
 	}
 
 	// Soft delete
@@ -283,17 +687,108 @@ func (s *StorageService) DeleteFile(fileID string, userID string) error {
 		WHERE id = $3
 	`
 
-	_, err = s.db.DB.Exec(query, time.Now().UTC(), time.Now().UTC(), fileID)
+	_, err = s.db.DB.Exec(query, clients.Now(), clients.Now(), fileID)
 	if err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 
-	// Optionally: Delete physical file after soft delete
-	// For now, we'll keep the file on disk for recovery purposes
+	// The physical file is kept for recovery until the purge worker removes it
 
 	return nil
 }
 
+// HardDeleteFile permanently removes a file from the database (admin only). The stored
+// object is only removed once no other record references it.
+func (s *StorageService) HardDeleteFile(fileID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var path, storageType string
+	err = tx.QueryRow(`DELETE FROM files WHERE id = $1 RETURNING path, storage_type`, fileID).Scan(&path, &storageType)
+	if err == sql.ErrNoRows {
+		return ErrFileNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete file record: %w", err)
+	}
+
+	var referenced bool
+	if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM files WHERE path = $1)`, path).Scan(&referenced); err != nil {
+		return fmt.Errorf("failed to count file references: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	if !referenced {
+		return s.removePhysicalFile(path, storageType)
+	}
+
+	return nil
+}
+
+// PurgeDeletedFiles permanently removes files soft-deleted before the cutoff
+func (s *StorageService) PurgeDeletedFiles(cutoff time.Time) (int, error) {
+	return s.hardDeleteFiles(`SELECT id FROM files WHERE deleted_at IS NOT NULL AND deleted_at < $1`, cutoff)
+}
+
+// PurgeUserFiles permanently removes every file owned by a user, deleted or not, so
+// purging the user leaves no stored objects behind. The count excludes failures.
+func (s *StorageService) PurgeUserFiles(userID string) (int, error) {
+	return s.hardDeleteFiles(`SELECT id FROM files WHERE user_id = $1`, userID)
+}
+
+// hardDeleteFiles hard deletes each file whose id the query selects. A file that
+// fails is logged and skipped so one bad object doesn't block the rest.
+func (s *StorageService) hardDeleteFiles(query string, args ...interface{}) (int, error) {
+	rows, err := s.db.DB.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list files: %w", err)
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan file: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	purged := 0
+	for _, id := range ids {
+		if err := s.HardDeleteFile(id); err != nil {
+			log.Printf("Failed to purge file %s: %v", id, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// removePhysicalFile deletes the stored file contents
+func (s *StorageService) removePhysicalFile(path, storageType string) error {
+	switch storageType {
+	case "local":
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove file from disk: %w", err)
+		}
+		return nil
+	case "s3":
+		// TODO: Implement S3 delete alongside S3 upload
+		return fmt.Errorf("S3 storage not yet implemented")
+	default:
+		return fmt.Errorf("unknown storage type: %s", storageType)
+	}
+}
+
 // UpdateFile updates file metadata and visibility
 func (s *StorageService) UpdateFile(fileID string, req *UpdateFileRequest, userID string) (*models.File, error) {
 	// First check if file exists and user has permission
@@ -304,12 +799,16 @@ func (s *StorageService) UpdateFile(fileID string, req *UpdateFileRequest, userI
 
 	// Check ownership for update
 	if file.UserID.Valid && file.UserID.String != userID {
-		return nil, fmt.Errorf("access denied")
+		return nil, ErrAccessDenied
+	}
+
+	if err := validateMetadata(req.Metadata); err != nil {
+		return nil, err
 	}
 
 	// Build update query dynamically based on provided fields
 	updates := []string{"updated_at = $1"}
-	args := []interface{}{time.Now().UTC()}
+	args := []interface{}{clients.Now()}
 	argCount := 2
 
 	if req.Visibility != "" {
@@ -339,11 +838,16 @@ func (s *StorageService) UpdateFile(fileID string, req *UpdateFileRequest, userI
 		return nil, fmt.Errorf("failed to update file: %w", err)
 	}
 
-	file.UpdatedAt = time.Now().UTC()
+	file.UpdatedAt = clients.Now()
 
 	return file, nil
 }
 
+// DownloadURL returns the URL a file is downloaded from
+func DownloadURL(baseURL, fileID string) string {
+	return fmt.Sprintf("%s/api/v1/storage/files/%s/download", baseURL, fileID)
+}
+
 // ToFileResponse converts a File model to FileResponse DTO
 func (s *StorageService) ToFileResponse(file *models.File, baseURL string) *FileResponse {
 	response := &FileResponse{
@@ -354,7 +858,7 @@ func (s *StorageService) ToFileResponse(file *models.File, baseURL string) *File
 		Size:         file.Size,
 		StorageType:  file.StorageType,
 		Visibility:   file.Visibility,
-		DownloadURL:  fmt.Sprintf("%s/api/v1/storage/files/%s/download", baseURL, file.ID),
+		DownloadURL:  DownloadURL(baseURL, file.ID),
 		CreatedAt:    file.CreatedAt,
 		UpdatedAt:    file.UpdatedAt,
 	}
@@ -362,6 +866,9 @@ func (s *StorageService) ToFileResponse(file *models.File, baseURL string) *File
 	if file.UserID.Valid {
 		response.UserID = file.UserID.String
 	}
+	if file.Checksum.Valid {
+		response.Checksum = file.Checksum.String
+	}
 
 	// Parse metadata if exists
 	if file.Metadata.Valid && file.Metadata.String != "" {
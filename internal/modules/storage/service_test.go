@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"gogin/internal/config"
+	"gogin/internal/testutil"
+)
+
+func TestListFilesVisibility(t *testing.T) {
+	db := testutil.Database(t)
+	service := NewStorageService(db, &config.Config{})
+
+	ownerID := testutil.CreateUser(t, db, "user")
+	otherID := testutil.CreateUser(t, db, "user")
+
+	// Deleting a user only clears user_id on its files, so each file is removed explicitly
+	insertFile := func(userID, visibility string) string {
+		t.Helper()
+		var id string
+		err := db.QueryRow(`
+			INSERT INTO files (user_id, file_name, original_name, mime_type, size, path, storage_type, visibility)
+			VALUES ($1, 'test.txt', 'test.txt', 'text/plain', 1, 'test/test.txt', 'local', $2)
+			RETURNING id
+		`, userID, visibility).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert file: %v", err)
+		}
+		t.Cleanup(func() { db.Exec(`DELETE FROM files WHERE id = $1`, id) })
+		return id
+	}
+	ownPrivate := insertFile(ownerID, "private")
+	ownPublic := insertFile(ownerID, "public")
+	otherPrivate := insertFile(otherID, "private")
+	otherPublic := insertFile(otherID, "public")
+
+	tests := []struct {
+		name       string
+		userID     string
+		visibility string
+		want       []string
+		notWant    []string
+	}{
+		{"private", ownerID, "private", []string{ownPrivate}, []string{ownPublic, otherPrivate, otherPublic}},
+		{"public", ownerID, "public", []string{ownPublic, otherPublic}, []string{ownPrivate, otherPrivate}},
+		{"no filter", ownerID, "", []string{ownPrivate, ownPublic, otherPublic}, []string{otherPrivate}},
+		{"anonymous private", "", "private", nil, []string{ownPrivate, ownPublic, otherPrivate, otherPublic}},
+		{"anonymous no filter", "", "", []string{ownPublic, otherPublic}, []string{ownPrivate, otherPrivate}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, _, err := service.ListFiles(tt.userID, tt.visibility, 1, 100)
+			if err != nil {
+				t.Fatalf("ListFiles: %v", err)
+			}
+
+			listed := make(map[string]bool, len(files))
+			for _, file := range files {
+				listed[file.ID] = true
+			}
+			for _, id := range tt.want {
+				if !listed[id] {
+					t.Errorf("file %s not listed", id)
+				}
+			}
+			for _, id := range tt.notWant {
+				if listed[id] {
+					t.Errorf("file %s listed", id)
+				}
+			}
+		})
+	}
+
+	if _, _, err := service.ListFiles(ownerID, "shared", 1, 100); !errors.Is(err, ErrInvalidVisibility) {
+		t.Errorf("ListFiles(shared) error = %v, want %v", err, ErrInvalidVisibility)
+	}
+}
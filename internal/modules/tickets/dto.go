@@ -1,13 +1,17 @@
 package tickets
 
-import "time"
+import (
+	"time"
+
+	"gogin/internal/response"
+)
 
 // CreateTicketRequest represents the request body for creating a ticket
 type CreateTicketRequest struct {
 	Subject     string `json:"subject" binding:"required,min=5,max=255"`
 	Description string `json:"description" binding:"required,min=10"`
 	Priority    string `json:"priority" binding:"required,oneof=low medium high urgent"`
-	Category    string `json:"category"`
+	Category    string `json:"category" binding:"omitempty,max=100"`
 }
 
 // UpdateTicketRequest represents the request body for updating a ticket
@@ -15,7 +19,7 @@ type UpdateTicketRequest struct {
 	Subject     string `json:"subject" binding:"omitempty,min=5,max=255"`
 	Description string `json:"description" binding:"omitempty,min=10"`
 	Priority    string `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
-	Category    string `json:"category"`
+	Category    string `json:"category" binding:"omitempty,max=100"`
 }
 
 // UpdateTicketStatusRequest represents the request body for updating ticket status
@@ -28,38 +32,97 @@ type AssignTicketRequest struct {
 	AssignedTo string `json:"assigned_to" binding:"required,uuid"`
 }
 
-// CreateReplyRequest represents the request body for creating a reply
+// CreateReplyRequest represents the request body for creating a reply. AttachmentIDs
+// are IDs of up to 5 uploaded files the author can access. IsInternal marks a
+// staff-only note that the ticket owner never sees.
 type CreateReplyRequest struct {
+	Content       string   `json:"content" binding:"required,min=1"`
+	AttachmentIDs []string `json:"attachment_ids" binding:"omitempty,max=5,dive,uuid"`
+	IsInternal    bool     `json:"is_internal"`
+}
+
+// UpdateReplyRequest represents the request body for editing a reply
+type UpdateReplyRequest struct {
 	Content string `json:"content" binding:"required,min=1"`
 }
 
+// CreateCategoryRequest represents the request body for adding a ticket category
+type CreateCategoryRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description"`
+}
+
+// UpdateCategoryRequest represents the request body for updating a ticket category
+type UpdateCategoryRequest struct {
+	Name        string  `json:"name" binding:"omitempty,max=100"`
+	Description *string `json:"description"`
+}
+
+// CategoryResponse represents a ticket category
+type CategoryResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description *string   `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TicketEscalatedEvent is published on ticket.escalated when a ticket becomes urgent
+type TicketEscalatedEvent struct {
+	TicketID    string    `json:"ticket_id"`
+	UserID      string    `json:"user_id"`
+	Subject     string    `json:"subject"`
+	Description string    `json:"description"`
+	Status      string    `json:"status"`
+	Priority    string    `json:"priority"`
+	Category    *string   `json:"category,omitempty"`
+	AssignedTo  *string   `json:"assigned_to,omitempty"`
+	EscalatedAt time.Time `json:"escalated_at"`
+	// RequestID traces the event back to the API request that escalated the ticket
+	RequestID string `json:"request_id,omitempty"`
+}
+
 // TicketResponse represents a sanitized ticket response
 type TicketResponse struct {
-	ID          string     `json:"id"`
-	UserID      string     `json:"user_id"`
-	Subject     string     `json:"subject"`
-	Description string     `json:"description"`
-	Status      string     `json:"status"`
-	Priority    string     `json:"priority"`
-	Category    *string    `json:"category,omitempty"`
-	AssignedTo  *string    `json:"assigned_to,omitempty"`
-	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
-	ClosedAt    *time.Time `json:"closed_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
-	ReplyCount  int        `json:"reply_count,omitempty"`
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	Subject         string     `json:"subject"`
+	Description     string     `json:"description"`
+	Status          string     `json:"status"`
+	Priority        string     `json:"priority"`
+	Category        *string    `json:"category,omitempty"`
+	AssignedTo      *string    `json:"assigned_to,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	FirstResponseAt *time.Time `json:"first_response_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	ReplyCount      int        `json:"reply_count,omitempty"`
 }
 
 // ReplyResponse represents a sanitized reply response
 type ReplyResponse struct {
-	ID        string     `json:"id"`
-	TicketID  string     `json:"ticket_id"`
-	UserID    string     `json:"user_id"`
-	IsStaff   bool       `json:"is_staff"`
-	Content   string     `json:"content"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID         string     `json:"id"`
+	TicketID   string     `json:"ticket_id"`
+	UserID     string     `json:"user_id"`
+	IsStaff    bool       `json:"is_staff"`
+	IsInternal bool       `json:"is_internal"`
+	Content    string     `json:"content"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+
+	Attachments []*ReplyAttachmentResponse `json:"attachments,omitempty"`
+}
+
+// ReplyAttachmentResponse describes a file attached to a reply. DownloadURL is served
+// by the ticket, so anyone who can read the ticket can download it.
+type ReplyAttachmentResponse struct {
+	FileID       string `json:"file_id"`
+	OriginalName string `json:"original_name"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	DownloadURL  string `json:"download_url"`
 }
 
 // TicketDetailResponse represents a ticket with all its replies
@@ -70,9 +133,27 @@ type TicketDetailResponse struct {
 
 // TicketsListResponse represents a paginated list of tickets
 type TicketsListResponse struct {
-	Tickets    []*TicketResponse `json:"tickets"`
-	Total      int               `json:"total"`
-	Page       int               `json:"page"`
-	Limit      int               `json:"limit"`
-	TotalPages int               `json:"total_pages"`
+	Tickets []*TicketResponse `json:"tickets"`
+	response.PaginationMeta
+}
+
+// TicketMetricsResponse represents SLA metrics for a single ticket
+type TicketMetricsResponse struct {
+	TicketID                   string     `json:"ticket_id"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	FirstResponseAt            *time.Time `json:"first_response_at,omitempty"`
+	ResolvedAt                 *time.Time `json:"resolved_at,omitempty"`
+	TimeToFirstResponseSeconds *int64     `json:"time_to_first_response_seconds,omitempty"`
+	TimeToResolutionSeconds    *int64     `json:"time_to_resolution_seconds,omitempty"`
+}
+
+// TicketMetricsSummaryResponse represents aggregate SLA metrics over a date range
+type TicketMetricsSummaryResponse struct {
+	From                          time.Time `json:"from"`
+	To                            time.Time `json:"to"`
+	TotalTickets                  int       `json:"total_tickets"`
+	RespondedTickets              int       `json:"responded_tickets"`
+	ResolvedTickets               int       `json:"resolved_tickets"`
+	AvgTimeToFirstResponseSeconds *float64  `json:"avg_time_to_first_response_seconds,omitempty"`
+	AvgTimeToResolutionSeconds    *float64  `json:"avg_time_to_resolution_seconds,omitempty"`
 }
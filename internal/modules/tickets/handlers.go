@@ -2,62 +2,16 @@ package tickets
 
 import (
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 
+	"gogin/internal/modules/storage"
 	"gogin/internal/response"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
-// getValidationErrors extracts detailed validation error messages
-func getValidationErrors(err error) []response.ResponseError {
-	var errors []response.ResponseError
-
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
-		for _, e := range validationErrors {
-			var message string
-			field := e.Field()
-
-			switch e.Tag() {
-			case "required":
-				message = field + " is required"
-			case "min":
-				message = field + " must be at least " + e.Param() + " characters"
-			case "max":
-				message = field + " must be at most " + e.Param() + " characters"
-			case "oneof":
-				if field == "Priority" {
-					message = "priority must be one of: low, medium, high, urgent"
-				} else if field == "Status" {
-					message = "status must be one of: open, in_progress, resolved, closed"
-				} else {
-					validValues := strings.ReplaceAll(e.Param(), " ", ", ")
-					message = field + " must be one of: " + validValues
-				}
-			case "uuid":
-				message = field + " must be a valid UUID"
-			default:
-				message = field + " is invalid"
-			}
-
-			errors = append(errors, response.ResponseError{
-				Code:    "VALIDATION_ERROR",
-				Message: message,
-				Field:   strings.ToLower(field),
-			})
-		}
-	} else {
-		errors = append(errors, response.ResponseError{
-			Code:    "BAD_REQUEST",
-			Message: "Invalid request body",
-		})
-	}
-
-	return errors
-}
-
 // @Summary Create support ticket
 // @Description Create a new support ticket
 // @Tags Tickets
@@ -79,13 +33,13 @@ func (m *TicketsModule) createTicket(c *gin.Context) {
 
 	var req CreateTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	ticket, err := m.service.CreateTicket(userID.(string), &req)
+	ticket, err := m.service.CreateTicket(c.Request.Context(), userID.(string), &req)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to create ticket")
 		return
 	}
 
@@ -95,7 +49,7 @@ func (m *TicketsModule) createTicket(c *gin.Context) {
 }
 
 // @Summary Get ticket details
-// @Description Get a specific ticket with all replies
+// @Description Get a specific ticket with all replies. Internal staff notes are only included for admins.
 // @Tags Tickets
 // @Produce json
 // @Security BearerAuth
@@ -117,13 +71,10 @@ func (m *TicketsModule) getTicket(c *gin.Context) {
 	ticketID := c.Param("id")
 
 	// Get ticket with replies
-	ticketDetail, err := m.service.GetTicketWithReplies(ticketID)
+	// Internal notes are only shown to staff
+	ticketDetail, err := m.service.GetTicketWithReplies(c.Request.Context(), ticketID, storage.BaseURL(c, m.config.App.PublicURL), role == "admin")
 	if err != nil {
-		if err.Error() == "ticket not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to get ticket")
 		return
 	}
 
@@ -136,6 +87,104 @@ func (m *TicketsModule) getTicket(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Ticket retrieved successfully", ticketDetail)
 }
 
+// @Summary Get ticket metrics
+// @Description Get SLA metrics (time to first response and time to resolution) for a ticket
+// @Tags Tickets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ticket ID"
+// @Success 200 {object} response.Response{data=object{metrics=TicketMetricsResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tickets/{id}/metrics [get]
+func (m *TicketsModule) getTicketMetrics(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	role, _ := c.Get("role")
+	ticketID := c.Param("id")
+
+	ticket, err := m.service.GetTicketByID(c.Request.Context(), ticketID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get ticket")
+		return
+	}
+
+	// Check authorization: user can only view their own tickets unless admin
+	if role != "admin" && ticket.UserID != userID.(string) {
+		response.Forbidden(c, "Access denied")
+		return
+	}
+
+	metrics, err := m.service.GetTicketMetrics(c.Request.Context(), ticketID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get ticket metrics")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Ticket metrics retrieved successfully", gin.H{
+		"metrics": metrics,
+	})
+}
+
+// @Summary Get ticket metrics summary
+// @Description Get average SLA metrics for tickets created within a date range (admin only). Defaults to the last 30 days.
+// @Tags Tickets
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start date (YYYY-MM-DD, inclusive)"
+// @Param to query string false "End date (YYYY-MM-DD, inclusive)"
+// @Success 200 {object} response.Response{data=object{metrics=TicketMetricsSummaryResponse}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tickets/metrics [get]
+func (m *TicketsModule) getTicketMetricsSummary(c *gin.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -30)
+	to := today.AddDate(0, 0, 1)
+
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "Invalid from date, expected YYYY-MM-DD")
+			return
+		}
+		from = parsed
+	}
+
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			response.BadRequest(c, "Invalid to date, expected YYYY-MM-DD")
+			return
+		}
+		// Include the whole end day
+		to = parsed.AddDate(0, 0, 1)
+	}
+
+	if !from.Before(to) {
+		response.BadRequest(c, "from date must not be after to date")
+		return
+	}
+
+	metrics, err := m.service.GetTicketMetricsSummary(c.Request.Context(), from, to)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get ticket metrics")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Ticket metrics retrieved successfully", gin.H{
+		"metrics": metrics,
+	})
+}
+
 // @Summary List my tickets
 // @Description List all tickets created by the authenticated user
 // @Tags Tickets
@@ -156,12 +205,11 @@ func (m *TicketsModule) listMyTickets(c *gin.Context) {
 	}
 
 	status := c.Query("status")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	pagination := response.ParsePagination(c)
 
-	tickets, err := m.service.ListUserTickets(userID.(string), status, page, limit)
+	tickets, err := m.service.ListUserTickets(c.Request.Context(), userID.(string), status, pagination)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to list tickets")
 		return
 	}
 
@@ -175,9 +223,15 @@ func (m *TicketsModule) listMyTickets(c *gin.Context) {
 // @Security BearerAuth
 // @Param status query string false "Filter by status" Enums(open, in_progress, resolved, closed)
 // @Param priority query string false "Filter by priority" Enums(low, medium, high, urgent)
-// @Param page query int false "Page number" default(1)
+// @Param search query string false "Search keyword matched against subject and description"
+// @Param assigned_to query string false "Filter by assignee user ID, or 'unassigned'"
+// @Param filter[status] query string false "Comma-separated statuses; filter[priority], filter[category], filter[user_id] and filter[assigned_to] work the same way"
+// @Param sort query string false "Sort field, prefixed with - for descending: created_at, updated_at, priority or status" default(-created_at)
+// @Param cursor query string false "Opaque cursor from a previous next_cursor, only with the default sort; preferred over page for large result sets"
+// @Param page query int false "Page number, ignored when cursor is set" default(1)
 // @Param limit query int false "Items per page" default(20)
 // @Success 200 {object} response.Response{data=TicketsListResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
@@ -185,12 +239,37 @@ func (m *TicketsModule) listMyTickets(c *gin.Context) {
 func (m *TicketsModule) listAllTickets(c *gin.Context) {
 	status := c.Query("status")
 	priority := c.Query("priority")
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	search := strings.TrimSpace(c.Query("search"))
+	assignedTo := c.Query("assigned_to")
+	pagination := response.ParsePagination(c)
 
-	tickets, err := m.service.ListAllTickets(status, priority, page, limit)
+	cursor, err := response.ParseCursor(c)
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.BadRequest(c, "Invalid cursor")
+		return
+	}
+	pagination.Cursor = cursor
+
+	if assignedTo != "" && assignedTo != "unassigned" {
+		if _, err := uuid.Parse(assignedTo); err != nil {
+			response.BadRequest(c, "assigned_to must be a user ID or 'unassigned'")
+			return
+		}
+	}
+
+	listQuery, err := response.ParseListQuery(c, adminTicketListSpec)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	if cursor != nil && listQuery.CustomSort {
+		response.BadRequest(c, "cursor can only be used with the default sort")
+		return
+	}
+
+	tickets, err := m.service.ListAllTickets(c.Request.Context(), status, priority, search, assignedTo, listQuery, pagination)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to list tickets")
 		return
 	}
 
@@ -223,17 +302,13 @@ func (m *TicketsModule) updateTicket(c *gin.Context) {
 
 	var req UpdateTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	ticket, err := m.service.UpdateTicket(ticketID, userID.(string), &req)
+	ticket, err := m.service.UpdateTicket(c.Request.Context(), ticketID, userID.(string), &req)
 	if err != nil {
-		if err.Error() == "ticket not found or access denied" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to update ticket")
 		return
 	}
 
@@ -243,7 +318,7 @@ func (m *TicketsModule) updateTicket(c *gin.Context) {
 }
 
 // @Summary Update ticket status
-// @Description Update the status of a ticket (admin only)
+// @Description Update the status of a ticket (admin only). Allowed transitions: open→in_progress→resolved→closed, and resolved→open to reopen.
 // @Tags Tickets
 // @Accept json
 // @Produce json
@@ -262,17 +337,13 @@ func (m *TicketsModule) updateTicketStatus(c *gin.Context) {
 
 	var req UpdateTicketStatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	ticket, err := m.service.UpdateTicketStatus(ticketID, &req)
+	ticket, err := m.service.UpdateTicketStatus(c.Request.Context(), ticketID, &req)
 	if err != nil {
-		if err.Error() == "ticket not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to update ticket status")
 		return
 	}
 
@@ -301,17 +372,13 @@ func (m *TicketsModule) assignTicket(c *gin.Context) {
 
 	var req AssignTicketRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
-	ticket, err := m.service.AssignTicket(ticketID, &req)
+	ticket, err := m.service.AssignTicket(c.Request.Context(), ticketID, &req)
 	if err != nil {
-		if err.Error() == "ticket not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to assign ticket")
 		return
 	}
 
@@ -321,7 +388,7 @@ func (m *TicketsModule) assignTicket(c *gin.Context) {
 }
 
 // @Summary Add reply to ticket
-// @Description Add a reply to a support ticket
+// @Description Add a reply to a support ticket, optionally attaching up to 5 uploaded files the caller can access. Staff may set is_internal to add a note the ticket owner can't see.
 // @Tags Tickets
 // @Accept json
 // @Produce json
@@ -346,18 +413,14 @@ func (m *TicketsModule) createReply(c *gin.Context) {
 
 	var req CreateReplyRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.ValidationError(c, getValidationErrors(err))
+		response.BindError(c, err)
 		return
 	}
 
 	// Check if user has access to this ticket
-	ticket, err := m.service.GetTicketByID(ticketID)
+	ticket, err := m.service.GetTicketByID(c.Request.Context(), ticketID)
 	if err != nil {
-		if err.Error() == "ticket not found" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to get ticket")
 		return
 	}
 
@@ -370,9 +433,14 @@ func (m *TicketsModule) createReply(c *gin.Context) {
 	// Determine if reply is from staff
 	isStaff := role == "admin"
 
-	reply, err := m.service.CreateReply(ticketID, userID.(string), isStaff, &req)
+	if req.IsInternal && !isStaff {
+		response.Forbidden(c, "Only staff can add internal notes")
+		return
+	}
+
+	reply, err := m.service.CreateReply(c.Request.Context(), ticketID, userID.(string), isStaff, &req, storage.BaseURL(c, m.config.App.PublicURL))
 	if err != nil {
-		response.InternalError(c, err.Error())
+		response.HandleServiceError(c, err, "Failed to add reply")
 		return
 	}
 
@@ -381,6 +449,83 @@ func (m *TicketsModule) createReply(c *gin.Context) {
 	})
 }
 
+// @Summary Edit reply
+// @Description Edit the content of a ticket reply (reply author or admin only)
+// @Tags Tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ticket ID"
+// @Param replyId path string true "Reply ID"
+// @Param request body UpdateReplyRequest true "Updated reply content"
+// @Success 200 {object} response.Response{data=object{reply=ReplyResponse}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tickets/{id}/replies/{replyId} [put]
+func (m *TicketsModule) updateReply(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	role, _ := c.Get("role")
+	ticketID := c.Param("id")
+	replyID := c.Param("replyId")
+
+	var req UpdateReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	reply, err := m.service.UpdateReply(c.Request.Context(), ticketID, replyID, userID.(string), role == "admin", &req, storage.BaseURL(c, m.config.App.PublicURL))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update reply")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reply updated successfully", gin.H{
+		"reply": reply,
+	})
+}
+
+// @Summary Delete reply
+// @Description Soft delete a ticket reply (reply author or admin only). The reply stays in the thread with its content hidden.
+// @Tags Tickets
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ticket ID"
+// @Param replyId path string true "Reply ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tickets/{id}/replies/{replyId} [delete]
+func (m *TicketsModule) deleteReply(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	role, _ := c.Get("role")
+	ticketID := c.Param("id")
+	replyID := c.Param("replyId")
+
+	err := m.service.DeleteReply(c.Request.Context(), ticketID, replyID, userID.(string), role == "admin")
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to delete reply")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reply deleted successfully", nil)
+}
+
 // @Summary Delete ticket
 // @Description Delete a ticket (users can only delete their own open tickets)
 // @Tags Tickets
@@ -402,15 +547,158 @@ func (m *TicketsModule) deleteTicket(c *gin.Context) {
 
 	ticketID := c.Param("id")
 
-	err := m.service.DeleteTicket(ticketID, userID.(string))
+	err := m.service.DeleteTicket(c.Request.Context(), ticketID, userID.(string))
 	if err != nil {
-		if err.Error() == "ticket not found or cannot be deleted" {
-			response.NotFound(c, err.Error())
-		} else {
-			response.InternalError(c, err.Error())
-		}
+		response.HandleServiceError(c, err, "Failed to delete ticket")
 		return
 	}
 
 	response.Success(c, http.StatusOK, "Ticket deleted successfully", nil)
 }
+
+// @Summary List ticket categories
+// @Description List the categories tickets can be filed under, for populating a category picker
+// @Tags Tickets
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=object{categories=[]CategoryResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 500 {object} response.Response
+// @Router /tickets/categories [get]
+func (m *TicketsModule) listCategories(c *gin.Context) {
+	categories, err := m.service.ListCategories(c.Request.Context())
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to list categories")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Categories retrieved successfully", gin.H{
+		"categories": categories,
+	})
+}
+
+// @Summary Create ticket category
+// @Description Add a ticket category. Names are unique regardless of case (admin only)
+// @Tags Tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateCategoryRequest true "Category details"
+// @Success 201 {object} response.Response{data=object{category=CategoryResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /tickets/categories [post]
+func (m *TicketsModule) createCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	category, err := m.service.CreateCategory(c.Request.Context(), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to create category")
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Category created successfully", gin.H{
+		"category": category,
+	})
+}
+
+// @Summary Update ticket category
+// @Description Rename or redescribe a ticket category. Tickets filed under the old name are moved to the new one (admin only)
+// @Tags Tickets
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param categoryId path string true "Category ID"
+// @Param request body UpdateCategoryRequest true "Fields to update"
+// @Success 200 {object} response.Response{data=object{category=CategoryResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /tickets/categories/{categoryId} [put]
+func (m *TicketsModule) updateCategory(c *gin.Context) {
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	category, err := m.service.UpdateCategory(c.Request.Context(), c.Param("categoryId"), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update category")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category updated successfully", gin.H{
+		"category": category,
+	})
+}
+
+// @Summary Delete ticket category
+// @Description Delete a ticket category. Existing tickets keep the category name (admin only)
+// @Tags Tickets
+// @Produce json
+// @Security BearerAuth
+// @Param categoryId path string true "Category ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tickets/categories/{categoryId} [delete]
+func (m *TicketsModule) deleteCategory(c *gin.Context) {
+	if err := m.service.DeleteCategory(c.Request.Context(), c.Param("categoryId")); err != nil {
+		response.HandleServiceError(c, err, "Failed to delete category")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category deleted successfully", nil)
+}
+
+// @Summary Download reply attachment
+// @Description Download a file attached to a reply on the ticket (ticket owner or admin). Attachments of internal notes are only available to admins.
+// @Tags Tickets
+// @Produce application/octet-stream
+// @Security BearerAuth
+// @Param id path string true "Ticket ID"
+// @Param fileId path string true "File ID"
+// @Success 200 {file} binary "File content"
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /tickets/{id}/attachments/{fileId} [get]
+func (m *TicketsModule) downloadAttachment(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	role, _ := c.Get("role")
+	ticketID := c.Param("id")
+
+	ticket, err := m.service.GetTicketByID(c.Request.Context(), ticketID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get ticket")
+		return
+	}
+
+	if role != "admin" && ticket.UserID != userID.(string) {
+		response.Forbidden(c, "Access denied")
+		return
+	}
+
+	file, err := m.service.GetAttachment(c.Request.Context(), ticketID, c.Param("fileId"), role == "admin")
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to get attachment")
+		return
+	}
+
+	storage.ServeFile(c, file)
+}
@@ -4,7 +4,9 @@ import (
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/middleware"
+	"gogin/internal/modules/notifications"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/storage"
 	"gogin/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,17 +15,20 @@ import (
 type TicketsModule struct {
 	service        *TicketsService
 	authMiddleware *middleware.AuthMiddleware
+	config         *config.Config
 }
 
-// NewTicketsModule creates a new instance of the tickets module
-func NewTicketsModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *TicketsModule {
+// NewTicketsModule creates a new instance of the tickets module. Ticket notifications
+// are sent through the shared notificationsService.
+func NewTicketsModule(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, notificationsService *notifications.NotificationsService, cfg *config.Config) *TicketsModule {
 	redisHelper := redishelper.NewRedisHelper(redis)
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
-	service := NewTicketsService(db, redisHelper, cfg)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
+	service := NewTicketsService(db, redisHelper, cfg, nats, notificationsService, storage.NewStorageService(db, cfg))
 
 	return &TicketsModule{
 		service:        service,
 		authMiddleware: middleware.NewAuthMiddleware(jwtUtil, redisHelper),
+		config:         cfg,
 	}
 }
 
@@ -34,20 +39,29 @@ func (m *TicketsModule) RegisterRoutes(router *gin.RouterGroup) {
 
 	// User routes (authenticated users)
 	{
-		tickets.POST("", m.createTicket)              // Create ticket
-		tickets.GET("/my", m.listMyTickets)           // List my tickets
-		tickets.GET("/:id", m.getTicket)              // Get ticket details
-		tickets.PUT("/:id", m.updateTicket)           // Update ticket
-		tickets.DELETE("/:id", m.deleteTicket)        // Delete ticket
-		tickets.POST("/:id/replies", m.createReply)   // Add reply
+		tickets.POST("", m.createTicket)                              // Create ticket
+		tickets.GET("/my", m.listMyTickets)                           // List my tickets
+		tickets.GET("/categories", m.listCategories)                  // List ticket categories
+		tickets.GET("/:id", m.getTicket)                              // Get ticket details
+		tickets.GET("/:id/metrics", m.getTicketMetrics)               // Get ticket SLA metrics
+		tickets.PUT("/:id", m.updateTicket)                           // Update ticket
+		tickets.DELETE("/:id", m.deleteTicket)                        // Delete ticket
+		tickets.POST("/:id/replies", m.createReply)                   // Add reply
+		tickets.PUT("/:id/replies/:replyId", m.updateReply)           // Edit reply
+		tickets.DELETE("/:id/replies/:replyId", m.deleteReply)        // Delete reply
+		tickets.GET("/:id/attachments/:fileId", m.downloadAttachment) // Download reply attachment
 	}
 
 	// Admin routes
 	admin := tickets.Group("")
 	admin.Use(middleware.RequireAdmin())
 	{
-		admin.GET("", m.listAllTickets)                // List all tickets
-		admin.PUT("/:id/status", m.updateTicketStatus) // Update status
-		admin.PUT("/:id/assign", m.assignTicket)       // Assign ticket
+		admin.GET("", m.listAllTickets)                           // List all tickets
+		admin.GET("/metrics", m.getTicketMetricsSummary)          // Aggregate SLA metrics
+		admin.PUT("/:id/status", m.updateTicketStatus)            // Update status
+		admin.PUT("/:id/assign", m.assignTicket)                  // Assign ticket
+		admin.POST("/categories", m.createCategory)               // Create category
+		admin.PUT("/categories/:categoryId", m.updateCategory)    // Update category
+		admin.DELETE("/categories/:categoryId", m.deleteCategory) // Delete category
 	}
 }
@@ -1,27 +1,104 @@
 package tickets
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"strings"
 	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/models"
+	"gogin/internal/modules/notifications"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/storage"
+	"gogin/internal/response"
+	"gogin/internal/utils"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// uniqueViolation is the Postgres error code for a unique constraint violation
+	uniqueViolation = "23505"
+
+	// replySnippetLength is the maximum number of characters of a reply included in notifications
+	replySnippetLength = 200
+	// EscalationSubject is the NATS subject for urgent ticket escalations
+	EscalationSubject = "ticket.escalated"
+
+	// EscalationRecipientsSettingKey is the system setting holding the comma-separated
+	// list of email addresses notified when a ticket escalates to urgent
+	EscalationRecipientsSettingKey = "tickets.escalation_recipients"
+)
+
+// Errors returned by the tickets service; match them with errors.Is
+var (
+	ErrTicketNotFound          = response.NotFoundError("ticket not found")
+	ErrTicketNotEditable       = response.NotFoundError("ticket not found or access denied")
+	ErrTicketNotDeletable      = response.NotFoundError("ticket not found or cannot be deleted")
+	ErrReplyNotFound           = response.NotFoundError("reply not found")
+	ErrReplyAccessDenied       = response.ForbiddenError("access denied")
+	ErrInternalNoteDenied      = response.ForbiddenError("only staff can add internal notes")
+	ErrInvalidStatusTransition = response.InvalidError("invalid status transition")
+	ErrInvalidAttachment       = response.InvalidError("attachment not found or access denied")
+	ErrAttachmentNotFound      = response.NotFoundError("attachment not found")
+	ErrCategoryNotFound        = response.NotFoundError("category not found")
+	ErrCategoryExists          = response.ConflictError("category already exists")
+	ErrUnknownCategory         = response.InvalidError("unknown ticket category")
 )
 
 type TicketsService struct {
-	db          *clients.Database
-	redisHelper *redishelper.RedisHelper
-	config      *config.Config
+	db            *clients.Database
+	redisHelper   *redishelper.RedisHelper
+	config        *config.Config
+	notifications *notifications.NotificationsService
+	nats          *clients.NATSClient
+	storage       *storage.StorageService
 }
 
-func NewTicketsService(db *clients.Database, redisHelper *redishelper.RedisHelper, cfg *config.Config) *TicketsService {
+func NewTicketsService(db *clients.Database, redisHelper *redishelper.RedisHelper, cfg *config.Config, nats *clients.NATSClient, notificationsService *notifications.NotificationsService, storageService *storage.StorageService) *TicketsService {
 	return &TicketsService{
-		db:          db,
-		redisHelper: redisHelper,
-		config:      cfg,
+		db:            db,
+		redisHelper:   redisHelper,
+		config:        cfg,
+		notifications: notificationsService,
+		nats:          nats,
+		storage:       storageService,
+	}
+}
+
+// publishEscalation publishes a ticket.escalated event (best-effort)
+func (s *TicketsService) publishEscalation(ctx context.Context, ticket *TicketResponse) {
+	if s.nats == nil {
+		return
+	}
+
+	event := &TicketEscalatedEvent{
+		TicketID:    ticket.ID,
+		UserID:      ticket.UserID,
+		Subject:     ticket.Subject,
+		Description: ticket.Description,
+		Status:      ticket.Status,
+		Priority:    ticket.Priority,
+		Category:    ticket.Category,
+		AssignedTo:  ticket.AssignedTo,
+		EscalatedAt: time.Now().UTC(),
+		RequestID:   utils.RequestIDFromContext(ctx),
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to marshal escalation event for ticket %s: %v", ticket.ID, err)
+		return
+	}
+
+	if err := s.nats.Publish(EscalationSubject, data); err != nil {
+		log.Printf("Failed to publish escalation event for ticket %s: %v", ticket.ID, err)
 	}
 }
 
@@ -56,22 +133,29 @@ func (s *TicketsService) toTicketResponse(ticket *models.SupportTicket) *TicketR
 		response.ClosedAt = &ticket.ClosedAt.Time
 	}
 
+	if ticket.FirstResponseAt.Valid {
+		response.FirstResponseAt = &ticket.FirstResponseAt.Time
+	}
+
 	return response
 }
 
 // toReplyResponse converts a models.SupportTicketReply to ReplyResponse
 func (s *TicketsService) toReplyResponse(reply *models.SupportTicketReply) *ReplyResponse {
 	response := &ReplyResponse{
-		ID:        reply.ID,
-		TicketID:  reply.TicketID,
-		UserID:    reply.UserID,
-		IsStaff:   reply.IsStaff,
-		Content:   reply.Content,
-		CreatedAt: reply.CreatedAt,
-		UpdatedAt: reply.UpdatedAt,
+		ID:         reply.ID,
+		TicketID:   reply.TicketID,
+		UserID:     reply.UserID,
+		IsStaff:    reply.IsStaff,
+		IsInternal: reply.IsInternal,
+		Content:    reply.Content,
+		CreatedAt:  reply.CreatedAt,
+		UpdatedAt:  reply.UpdatedAt,
 	}
 
+	// Deleted replies stay in the thread but their content is hidden
 	if reply.DeletedAt.Valid {
+		response.Content = ""
 		response.DeletedAt = &reply.DeletedAt.Time
 	}
 
@@ -79,19 +163,26 @@ func (s *TicketsService) toReplyResponse(reply *models.SupportTicketReply) *Repl
 }
 
 // CreateTicket creates a new support ticket
-func (s *TicketsService) CreateTicket(userID string, req *CreateTicketRequest) (*TicketResponse, error) {
+func (s *TicketsService) CreateTicket(ctx context.Context, userID string, req *CreateTicketRequest) (*TicketResponse, error) {
 	query := `
 		INSERT INTO support_tickets (user_id, subject, description, priority, category, status, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at
 	`
 
-	now := time.Now().UTC()
-	var ticket models.SupportTicket
+	var category sql.NullString
+	if req.Category != "" {
+		name, err := s.resolveCategory(ctx, req.Category)
+		if err != nil {
+			return nil, err
+		}
+		category = sql.NullString{String: name, Valid: true}
+	}
 
-	category := sql.NullString{String: req.Category, Valid: req.Category != ""}
+	now := clients.Now()
+	var ticket models.SupportTicket
 
-	err := s.db.QueryRow(
+	err := s.db.QueryRowContext(ctx,
 		query,
 		userID,
 		req.Subject,
@@ -112,6 +203,7 @@ func (s *TicketsService) CreateTicket(userID string, req *CreateTicketRequest) (
 		&ticket.AssignedTo,
 		&ticket.ResolvedAt,
 		&ticket.ClosedAt,
+		&ticket.FirstResponseAt,
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
 	)
@@ -123,19 +215,26 @@ func (s *TicketsService) CreateTicket(userID string, req *CreateTicketRequest) (
 	// Invalidate user tickets cache
 	s.redisHelper.CacheDelete(fmt.Sprintf("user_tickets:%s", userID))
 
-	return s.toTicketResponse(&ticket), nil
+	response := s.toTicketResponse(&ticket)
+	if response.Priority == "urgent" {
+		s.publishEscalation(ctx, response)
+	}
+
+	return response, nil
 }
 
 // GetTicketByID retrieves a ticket by ID
-func (s *TicketsService) GetTicketByID(ticketID string) (*TicketResponse, error) {
+func (s *TicketsService) GetTicketByID(ctx context.Context, ticketID string) (*TicketResponse, error) {
 	query := `
-		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at,
+			(SELECT COUNT(*) FROM support_ticket_replies r WHERE r.ticket_id = support_tickets.id AND r.deleted_at IS NULL AND r.is_internal = FALSE) AS reply_count
 		FROM support_tickets
 		WHERE id = $1
 	`
 
 	var ticket models.SupportTicket
-	err := s.db.QueryRow(query, ticketID).Scan(
+	var replyCount int
+	err := s.db.QueryRowContext(ctx, query, ticketID).Scan(
 		&ticket.ID,
 		&ticket.UserID,
 		&ticket.Subject,
@@ -146,37 +245,43 @@ func (s *TicketsService) GetTicketByID(ticketID string) (*TicketResponse, error)
 		&ticket.AssignedTo,
 		&ticket.ResolvedAt,
 		&ticket.ClosedAt,
+		&ticket.FirstResponseAt,
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
+		&replyCount,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found")
+		return nil, ErrTicketNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ticket: %w", err)
 	}
 
-	return s.toTicketResponse(&ticket), nil
+	response := s.toTicketResponse(&ticket)
+	response.ReplyCount = replyCount
+
+	return response, nil
 }
 
-// GetTicketWithReplies retrieves a ticket with all its replies
-func (s *TicketsService) GetTicketWithReplies(ticketID string) (*TicketDetailResponse, error) {
+// GetTicketWithReplies retrieves a ticket with all its replies and their attachments.
+// Internal notes are only included for staff.
+func (s *TicketsService) GetTicketWithReplies(ctx context.Context, ticketID, baseURL string, includeInternal bool) (*TicketDetailResponse, error) {
 	// Get ticket
-	ticket, err := s.GetTicketByID(ticketID)
+	ticket, err := s.GetTicketByID(ctx, ticketID)
 	if err != nil {
 		return nil, err
 	}
 
 	// Get replies
 	query := `
-		SELECT id, ticket_id, user_id, is_staff, content, created_at, updated_at, deleted_at
+		SELECT id, ticket_id, user_id, is_staff, is_internal, content, created_at, updated_at, deleted_at
 		FROM support_ticket_replies
-		WHERE ticket_id = $1 AND deleted_at IS NULL
+		WHERE ticket_id = $1 AND (is_internal = FALSE OR $2)
 		ORDER BY created_at ASC
 	`
 
-	rows, err := s.db.Query(query, ticketID)
+	rows, err := s.db.QueryContext(ctx, query, ticketID, includeInternal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get replies: %w", err)
 	}
@@ -190,6 +295,7 @@ func (s *TicketsService) GetTicketWithReplies(ticketID string) (*TicketDetailRes
 			&reply.TicketID,
 			&reply.UserID,
 			&reply.IsStaff,
+			&reply.IsInternal,
 			&reply.Content,
 			&reply.CreatedAt,
 			&reply.UpdatedAt,
@@ -204,6 +310,10 @@ func (s *TicketsService) GetTicketWithReplies(ticketID string) (*TicketDetailRes
 		replies = []*ReplyResponse{}
 	}
 
+	if err := s.loadAttachments(ctx, replies, baseURL); err != nil {
+		return nil, err
+	}
+
 	return &TicketDetailResponse{
 		Ticket:  ticket,
 		Replies: replies,
@@ -211,20 +321,12 @@ func (s *TicketsService) GetTicketWithReplies(ticketID string) (*TicketDetailRes
 }
 
 // ListUserTickets lists all tickets for a specific user
-func (s *TicketsService) ListUserTickets(userID string, status string, page, limit int) (*TicketsListResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-
-	offset := (page - 1) * limit
-
+func (s *TicketsService) ListUserTickets(ctx context.Context, userID string, status string, pagination response.Pagination) (*TicketsListResponse, error) {
 	// Build query
 	countQuery := `SELECT COUNT(*) FROM support_tickets WHERE user_id = $1`
 	query := `
-		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at,
+			(SELECT COUNT(*) FROM support_ticket_replies r WHERE r.ticket_id = support_tickets.id AND r.deleted_at IS NULL AND r.is_internal = FALSE) AS reply_count
 		FROM support_tickets
 		WHERE user_id = $1
 	`
@@ -239,15 +341,15 @@ func (s *TicketsService) ListUserTickets(userID string, status string, page, lim
 
 	// Count total
 	var total int
-	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count tickets: %w", err)
 	}
 
 	// Query tickets
 	query += ` ORDER BY created_at DESC LIMIT $` + fmt.Sprintf("%d", len(args)+1) + ` OFFSET $` + fmt.Sprintf("%d", len(args)+2)
-	args = append(args, limit, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tickets: %w", err)
 	}
@@ -256,6 +358,7 @@ func (s *TicketsService) ListUserTickets(userID string, status string, page, lim
 	var tickets []*TicketResponse
 	for rows.Next() {
 		var ticket models.SupportTicket
+		var replyCount int
 		if err := rows.Scan(
 			&ticket.ID,
 			&ticket.UserID,
@@ -267,44 +370,60 @@ func (s *TicketsService) ListUserTickets(userID string, status string, page, lim
 			&ticket.AssignedTo,
 			&ticket.ResolvedAt,
 			&ticket.ClosedAt,
+			&ticket.FirstResponseAt,
 			&ticket.CreatedAt,
 			&ticket.UpdatedAt,
+			&replyCount,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan ticket: %w", err)
 		}
-		tickets = append(tickets, s.toTicketResponse(&ticket))
+		ticketResponse := s.toTicketResponse(&ticket)
+		ticketResponse.ReplyCount = replyCount
+		tickets = append(tickets, ticketResponse)
 	}
 
 	if tickets == nil {
 		tickets = []*TicketResponse{}
 	}
 
-	totalPages := (total + limit - 1) / limit
-
 	return &TicketsListResponse{
-		Tickets:    tickets,
-		Total:      total,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
+		Tickets:        tickets,
+		PaginationMeta: pagination.Meta(total),
 	}, nil
 }
 
-// ListAllTickets lists all tickets (admin only)
-func (s *TicketsService) ListAllTickets(status, priority string, page, limit int) (*TicketsListResponse, error) {
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
+// Priority and status sort by rank rather than alphabetically: priority from low to
+// urgent and status in workflow order, so "-priority" puts urgent tickets first
+const (
+	priorityRank = `CASE priority WHEN 'low' THEN 1 WHEN 'medium' THEN 2 WHEN 'high' THEN 3 WHEN 'urgent' THEN 4 ELSE 0 END`
+	statusRank   = `CASE status WHEN 'open' THEN 1 WHEN 'in_progress' THEN 2 WHEN 'resolved' THEN 3 WHEN 'closed' THEN 4 ELSE 0 END`
+)
 
-	offset := (page - 1) * limit
+// adminTicketListSpec whitelists the filter[...] and sort fields of the admin ticket list
+var adminTicketListSpec = response.ListSpec{
+	Filters: map[string]response.FilterField{
+		"status":      {Column: "status"},
+		"priority":    {Column: "priority"},
+		"category":    {Column: "category"},
+		"user_id":     {Column: "user_id", Type: response.FilterUUID},
+		"assigned_to": {Column: "assigned_to", Type: response.FilterUUID},
+	},
+	Sorts: map[string]string{
+		"created_at": "created_at",
+		"updated_at": "updated_at",
+		"priority":   priorityRank,
+		"status":     statusRank,
+	},
+	DefaultSort: "-created_at",
+}
 
+// ListAllTickets lists all tickets (admin only)
+func (s *TicketsService) ListAllTickets(ctx context.Context, status, priority, search, assignedTo string, listQuery response.ListQuery, pagination response.Pagination) (*TicketsListResponse, error) {
 	// Build query
 	countQuery := `SELECT COUNT(*) FROM support_tickets WHERE 1=1`
 	query := `
-		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		SELECT id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at,
+			(SELECT COUNT(*) FROM support_ticket_replies r WHERE r.ticket_id = support_tickets.id AND r.deleted_at IS NULL AND r.is_internal = FALSE) AS reply_count
 		FROM support_tickets
 		WHERE 1=1
 	`
@@ -326,18 +445,48 @@ func (s *TicketsService) ListAllTickets(status, priority string, page, limit int
 		args = append(args, priority)
 	}
 
+	if assignedTo == "unassigned" {
+		countQuery += ` AND assigned_to IS NULL`
+		query += ` AND assigned_to IS NULL`
+	} else if assignedTo != "" {
+		argCount++
+		countQuery += fmt.Sprintf(` AND assigned_to = $%d`, argCount)
+		query += fmt.Sprintf(` AND assigned_to = $%d`, argCount)
+		args = append(args, assignedTo)
+	}
+
+	if search != "" {
+		argCount++
+		searchClause := fmt.Sprintf(` AND (subject ILIKE $%d OR description ILIKE $%d)`, argCount, argCount)
+		countQuery += searchClause
+		query += searchClause
+		args = append(args, "%"+escapeLikePattern(search)+"%")
+	}
+
+	filterClause, args := listQuery.Where(args)
+	argCount = len(args)
+	countQuery += filterClause
+	query += filterClause
+
 	// Count total
 	var total int
-	if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, fmt.Errorf("failed to count tickets: %w", err)
 	}
 
-	// Query tickets
-	argCount++
-	query += fmt.Sprintf(` ORDER BY created_at DESC LIMIT $%d OFFSET $%d`, argCount, argCount+1)
-	args = append(args, limit, offset)
+	// Query tickets, by keyset after the cursor when given, otherwise by offset.
+	// One extra row is fetched to tell whether another page follows.
+	if pagination.Cursor != nil {
+		argCount++
+		query += fmt.Sprintf(` AND (created_at, id) < ($%d::timestamp, $%d) ORDER BY created_at DESC, id DESC LIMIT $%d`, argCount, argCount+1, argCount+2)
+		args = append(args, pagination.Cursor.CreatedAtParam(), pagination.Cursor.ID, pagination.Limit+1)
+	} else {
+		argCount++
+		query += fmt.Sprintf(` ORDER BY %s LIMIT $%d OFFSET $%d`, listQuery.OrderBy(), argCount, argCount+1)
+		args = append(args, pagination.Limit+1, pagination.Offset)
+	}
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list tickets: %w", err)
 	}
@@ -346,6 +495,7 @@ func (s *TicketsService) ListAllTickets(status, priority string, page, limit int
 	var tickets []*TicketResponse
 	for rows.Next() {
 		var ticket models.SupportTicket
+		var replyCount int
 		if err := rows.Scan(
 			&ticket.ID,
 			&ticket.UserID,
@@ -357,34 +507,49 @@ func (s *TicketsService) ListAllTickets(status, priority string, page, limit int
 			&ticket.AssignedTo,
 			&ticket.ResolvedAt,
 			&ticket.ClosedAt,
+			&ticket.FirstResponseAt,
 			&ticket.CreatedAt,
 			&ticket.UpdatedAt,
+			&replyCount,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan ticket: %w", err)
 		}
-		tickets = append(tickets, s.toTicketResponse(&ticket))
+		ticketResponse := s.toTicketResponse(&ticket)
+		ticketResponse.ReplyCount = replyCount
+		tickets = append(tickets, ticketResponse)
+	}
+
+	meta := pagination.Meta(total)
+	if len(tickets) > pagination.Limit {
+		tickets = tickets[:pagination.Limit]
+		// Cursors follow the default order, so custom sorts page by offset only
+		if !listQuery.CustomSort {
+			last := tickets[len(tickets)-1]
+			meta.NextCursor = response.EncodeCursor(last.CreatedAt, last.ID)
+		}
 	}
 
 	if tickets == nil {
 		tickets = []*TicketResponse{}
 	}
 
-	totalPages := (total + limit - 1) / limit
-
 	return &TicketsListResponse{
-		Tickets:    tickets,
-		Total:      total,
-		Page:       page,
-		Limit:      limit,
-		TotalPages: totalPages,
+		Tickets:        tickets,
+		PaginationMeta: meta,
 	}, nil
 }
 
+// escapeLikePattern escapes LIKE wildcards so user input is matched literally
+func escapeLikePattern(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
+
 // UpdateTicket updates a ticket
-func (s *TicketsService) UpdateTicket(ticketID, userID string, req *UpdateTicketRequest) (*TicketResponse, error) {
+func (s *TicketsService) UpdateTicket(ctx context.Context, ticketID, userID string, req *UpdateTicketRequest) (*TicketResponse, error) {
 	// Build dynamic update query
 	query := `UPDATE support_tickets SET updated_at = $1`
-	args := []interface{}{time.Now().UTC()}
+	args := []interface{}{clients.Now()}
 	argCount := 1
 
 	if req.Subject != "" {
@@ -406,18 +571,27 @@ func (s *TicketsService) UpdateTicket(ticketID, userID string, req *UpdateTicket
 	}
 
 	if req.Category != "" {
+		category, err := s.resolveCategory(ctx, req.Category)
+		if err != nil {
+			return nil, err
+		}
 		argCount++
 		query += fmt.Sprintf(`, category = $%d`, argCount)
-		args = append(args, req.Category)
+		args = append(args, category)
 	}
 
+	// The previous priority is read under a row lock in the same statement, so of two
+	// concurrent updates to urgent only the first sees the ticket escalate
 	argCount++
-	query += fmt.Sprintf(` WHERE id = $%d AND user_id = $%d`, argCount, argCount+1)
-	query += ` RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at`
+	query += fmt.Sprintf(`
+		FROM (SELECT priority AS previous_priority FROM support_tickets WHERE id = $%d AND user_id = $%d FOR UPDATE) previous
+		WHERE id = $%d AND user_id = $%d`, argCount, argCount+1, argCount, argCount+1)
+	query += ` RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at, previous.previous_priority`
 	args = append(args, ticketID, userID)
 
 	var ticket models.SupportTicket
-	err := s.db.QueryRow(query, args...).Scan(
+	var previousPriority string
+	err := s.db.QueryRowContext(ctx, query, args...).Scan(
 		&ticket.ID,
 		&ticket.UserID,
 		&ticket.Subject,
@@ -428,12 +602,14 @@ func (s *TicketsService) UpdateTicket(ticketID, userID string, req *UpdateTicket
 		&ticket.AssignedTo,
 		&ticket.ResolvedAt,
 		&ticket.ClosedAt,
+		&ticket.FirstResponseAt,
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
+		&previousPriority,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found or access denied")
+		return nil, ErrTicketNotEditable
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update ticket: %w", err)
@@ -442,30 +618,81 @@ func (s *TicketsService) UpdateTicket(ticketID, userID string, req *UpdateTicket
 	// Invalidate cache
 	s.redisHelper.CacheDelete(fmt.Sprintf("user_tickets:%s", userID))
 
-	return s.toTicketResponse(&ticket), nil
+	response := s.toTicketResponse(&ticket)
+	if response.Priority == "urgent" && previousPriority != "urgent" {
+		s.publishEscalation(ctx, response)
+	}
+
+	return response, nil
+}
+
+// ticketStatusTransitions lists the statuses each ticket status may move to.
+// Resolved tickets may be reopened; closed tickets are final.
+var ticketStatusTransitions = map[string][]string{
+	"open":        {"in_progress"},
+	"in_progress": {"resolved"},
+	"resolved":    {"closed", "open"},
+	"closed":      {},
+}
+
+// canTransitionStatus reports whether a ticket may move from one status to another
+func canTransitionStatus(from, to string) bool {
+	for _, allowed := range ticketStatusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
 }
 
 // UpdateTicketStatus updates the status of a ticket (admin only)
-func (s *TicketsService) UpdateTicketStatus(ticketID string, req *UpdateTicketStatusRequest) (*TicketResponse, error) {
-	now := time.Now().UTC()
+func (s *TicketsService) UpdateTicketStatus(ctx context.Context, ticketID string, req *UpdateTicketStatusRequest) (*TicketResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var currentStatus string
 	var resolvedAt, closedAt sql.NullTime
+	err = tx.QueryRowContext(ctx,
+		`SELECT status, resolved_at, closed_at FROM support_tickets WHERE id = $1 FOR UPDATE`,
+		ticketID,
+	).Scan(&currentStatus, &resolvedAt, &closedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrTicketNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket: %w", err)
+	}
+
+	if !canTransitionStatus(currentStatus, req.Status) {
+		return nil, fmt.Errorf("%w from %s to %s", ErrInvalidStatusTransition, currentStatus, req.Status)
+	}
+
+	now := clients.Now()
 
 	// Set timestamps based on status
-	if req.Status == "resolved" {
+	switch req.Status {
+	case "resolved":
 		resolvedAt = sql.NullTime{Time: now, Valid: true}
-	} else if req.Status == "closed" {
+	case "closed":
 		closedAt = sql.NullTime{Time: now, Valid: true}
+	case "open":
+		// Reopening clears any previous resolution
+		resolvedAt = sql.NullTime{}
+		closedAt = sql.NullTime{}
 	}
 
 	query := `
 		UPDATE support_tickets
 		SET status = $1, resolved_at = $2, closed_at = $3, updated_at = $4
 		WHERE id = $5
-		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at
 	`
 
 	var ticket models.SupportTicket
-	err := s.db.QueryRow(query, req.Status, resolvedAt, closedAt, now, ticketID).Scan(
+	err = tx.QueryRowContext(ctx, query, req.Status, resolvedAt, closedAt, now, ticketID).Scan(
 		&ticket.ID,
 		&ticket.UserID,
 		&ticket.Subject,
@@ -476,17 +703,22 @@ func (s *TicketsService) UpdateTicketStatus(ticketID string, req *UpdateTicketSt
 		&ticket.AssignedTo,
 		&ticket.ResolvedAt,
 		&ticket.ClosedAt,
+		&ticket.FirstResponseAt,
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found")
+		return nil, ErrTicketNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update ticket status: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	// Invalidate cache
 	s.redisHelper.CacheDelete(fmt.Sprintf("user_tickets:%s", ticket.UserID))
 
@@ -494,18 +726,18 @@ func (s *TicketsService) UpdateTicketStatus(ticketID string, req *UpdateTicketSt
 }
 
 // AssignTicket assigns a ticket to an admin (admin only)
-func (s *TicketsService) AssignTicket(ticketID string, req *AssignTicketRequest) (*TicketResponse, error) {
+func (s *TicketsService) AssignTicket(ctx context.Context, ticketID string, req *AssignTicketRequest) (*TicketResponse, error) {
 	query := `
 		UPDATE support_tickets
 		SET assigned_to = $1, updated_at = $2
 		WHERE id = $3
-		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, created_at, updated_at
+		RETURNING id, user_id, subject, description, status, priority, category, assigned_to, resolved_at, closed_at, first_response_at, created_at, updated_at
 	`
 
-	now := time.Now().UTC()
+	now := clients.Now()
 	var ticket models.SupportTicket
 
-	err := s.db.QueryRow(query, req.AssignedTo, now, ticketID).Scan(
+	err := s.db.QueryRowContext(ctx, query, req.AssignedTo, now, ticketID).Scan(
 		&ticket.ID,
 		&ticket.UserID,
 		&ticket.Subject,
@@ -516,12 +748,13 @@ func (s *TicketsService) AssignTicket(ticketID string, req *AssignTicketRequest)
 		&ticket.AssignedTo,
 		&ticket.ResolvedAt,
 		&ticket.ClosedAt,
+		&ticket.FirstResponseAt,
 		&ticket.CreatedAt,
 		&ticket.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("ticket not found")
+		return nil, ErrTicketNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to assign ticket: %w", err)
@@ -530,22 +763,40 @@ func (s *TicketsService) AssignTicket(ticketID string, req *AssignTicketRequest)
 	return s.toTicketResponse(&ticket), nil
 }
 
-// CreateReply creates a reply to a ticket
-func (s *TicketsService) CreateReply(ticketID, userID string, isStaff bool, req *CreateReplyRequest) (*ReplyResponse, error) {
+// CreateReply creates a reply to a ticket, attaching the requested files. Internal
+// notes may only be added by staff; they don't count as a first response and the
+// ticket owner is not notified of them.
+func (s *TicketsService) CreateReply(ctx context.Context, ticketID, userID string, isStaff bool, req *CreateReplyRequest, baseURL string) (*ReplyResponse, error) {
+	if req.IsInternal && !isStaff {
+		return nil, ErrInternalNoteDenied
+	}
+
+	files, err := s.resolveAttachments(userID, req.AttachmentIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		INSERT INTO support_ticket_replies (ticket_id, user_id, is_staff, content, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, ticket_id, user_id, is_staff, content, created_at, updated_at, deleted_at
+		INSERT INTO support_ticket_replies (ticket_id, user_id, is_staff, is_internal, content, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, ticket_id, user_id, is_staff, is_internal, content, created_at, updated_at, deleted_at
 	`
 
-	now := time.Now().UTC()
+	now := clients.Now()
 	var reply models.SupportTicketReply
 
-	err := s.db.QueryRow(query, ticketID, userID, isStaff, req.Content, now, now).Scan(
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, ticketID, userID, isStaff, req.IsInternal, req.Content, now, now).Scan(
 		&reply.ID,
 		&reply.TicketID,
 		&reply.UserID,
 		&reply.IsStaff,
+		&reply.IsInternal,
 		&reply.Content,
 		&reply.CreatedAt,
 		&reply.UpdatedAt,
@@ -556,14 +807,378 @@ func (s *TicketsService) CreateReply(ticketID, userID string, isStaff bool, req
 		return nil, fmt.Errorf("failed to create reply: %w", err)
 	}
 
-	return s.toReplyResponse(&reply), nil
+	for _, file := range files {
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO ticket_reply_attachments (reply_id, file_id, created_at) VALUES ($1, $2, $3)`,
+			reply.ID, file.ID, now,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to attach file: %w", err)
+		}
+	}
+
+	// Record the first staff response for SLA tracking
+	if isStaff && !req.IsInternal {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE support_tickets SET first_response_at = $1 WHERE id = $2 AND first_response_at IS NULL`,
+			now, ticketID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record first response: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	// Notification delivery is best-effort and must not fail the reply
+	if !req.IsInternal {
+		if err := s.notifyReply(ctx, ticketID, userID, isStaff, req.Content); err != nil {
+			log.Printf("Failed to send reply notification for ticket %s: %v", ticketID, err)
+		}
+	}
+
+	replyResponse := s.toReplyResponse(&reply)
+	for _, file := range files {
+		replyResponse.Attachments = append(replyResponse.Attachments, toAttachmentResponse(ticketID, file.ID, file.OriginalName, file.MimeType, file.Size, baseURL))
+	}
+
+	return replyResponse, nil
+}
+
+// resolveAttachments looks up the files to attach to a reply, ignoring repeated IDs.
+// Every file must be one the author can access through the storage module.
+func (s *TicketsService) resolveAttachments(userID string, fileIDs []string) ([]*models.File, error) {
+	files := make([]*models.File, 0, len(fileIDs))
+	seen := make(map[string]bool, len(fileIDs))
+	for _, fileID := range fileIDs {
+		if seen[fileID] {
+			continue
+		}
+		seen[fileID] = true
+
+		file, err := s.storage.GetFile(fileID, userID)
+		if errors.Is(err, storage.ErrFileNotFound) || errors.Is(err, storage.ErrAccessDenied) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAttachment, fileID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// loadAttachments fills in the attachments of the given replies. Attachments of
+// deleted replies are hidden along with their content, as are deleted files.
+func (s *TicketsService) loadAttachments(ctx context.Context, replies []*ReplyResponse, baseURL string) error {
+	byID := make(map[string]*ReplyResponse, len(replies))
+	replyIDs := make([]string, 0, len(replies))
+	for _, reply := range replies {
+		if reply.DeletedAt != nil {
+			continue
+		}
+		byID[reply.ID] = reply
+		replyIDs = append(replyIDs, reply.ID)
+	}
+	if len(replyIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		SELECT a.reply_id, f.id, f.original_name, f.mime_type, f.size
+		FROM ticket_reply_attachments a
+		JOIN files f ON f.id = a.file_id
+		WHERE a.reply_id = ANY($1) AND f.deleted_at IS NULL
+		ORDER BY a.created_at ASC, f.original_name ASC
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(replyIDs))
+	if err != nil {
+		return fmt.Errorf("failed to get reply attachments: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var replyID, fileID, originalName, mimeType string
+		var size int64
+		if err := rows.Scan(&replyID, &fileID, &originalName, &mimeType, &size); err != nil {
+			return fmt.Errorf("failed to scan reply attachment: %w", err)
+		}
+		reply := byID[replyID]
+		reply.Attachments = append(reply.Attachments, toAttachmentResponse(reply.TicketID, fileID, originalName, mimeType, size, baseURL))
+	}
+
+	return rows.Err()
+}
+
+// toAttachmentResponse describes an attached file with its ticket download URL
+func toAttachmentResponse(ticketID, fileID, originalName, mimeType string, size int64, baseURL string) *ReplyAttachmentResponse {
+	return &ReplyAttachmentResponse{
+		FileID:       fileID,
+		OriginalName: originalName,
+		MimeType:     mimeType,
+		Size:         size,
+		DownloadURL:  AttachmentURL(baseURL, ticketID, fileID),
+	}
+}
+
+// AttachmentURL returns the URL a reply attachment is downloaded from. The route checks
+// ticket access rather than file ownership, so both the ticket owner and staff can
+// download attachments regardless of who uploaded them.
+func AttachmentURL(baseURL, ticketID, fileID string) string {
+	return fmt.Sprintf("%s/api/v1/tickets/%s/attachments/%s", baseURL, ticketID, fileID)
+}
+
+// GetAttachment returns a file attached to a visible reply on the ticket. Callers must
+// have checked access to the ticket; internal notes' attachments are only returned to
+// staff, mirroring GetTicketWithReplies.
+func (s *TicketsService) GetAttachment(ctx context.Context, ticketID, fileID string, includeInternal bool) (*models.File, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1
+			FROM ticket_reply_attachments a
+			JOIN support_ticket_replies r ON r.id = a.reply_id
+			WHERE r.ticket_id = $1 AND a.file_id = $2 AND r.deleted_at IS NULL AND (r.is_internal = FALSE OR $3)
+		)
+	`
+
+	var attached bool
+	if err := s.db.QueryRowContext(ctx, query, ticketID, fileID, includeInternal).Scan(&attached); err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if !attached {
+		return nil, ErrAttachmentNotFound
+	}
+
+	file, err := s.storage.GetAuthorizedFile(fileID)
+	if errors.Is(err, storage.ErrFileNotFound) {
+		return nil, ErrAttachmentNotFound
+	}
+	return file, err
+}
+
+// notifyReply notifies the other party on a ticket about a new reply.
+// Staff replies go to the ticket owner; owner replies go to the assignee.
+func (s *TicketsService) notifyReply(ctx context.Context, ticketID, authorID string, isStaff bool, content string) error {
+	if s.notifications == nil {
+		return nil
+	}
+
+	ticket, err := s.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return err
+	}
+
+	var recipientID string
+	if isStaff {
+		recipientID = ticket.UserID
+	} else if ticket.AssignedTo != nil {
+		recipientID = *ticket.AssignedTo
+	}
+
+	if recipientID == "" || recipientID == authorID {
+		return nil
+	}
+
+	snippet := content
+	if runes := []rune(snippet); len(runes) > replySnippetLength {
+		snippet = string(runes[:replySnippetLength]) + "..."
+	}
+
+	_, err = s.notifications.SendNotification(ctx, &notifications.SendNotificationRequest{
+		UserID:  recipientID,
+		Type:    "ticket_reply",
+		Channel: "email",
+		Title:   fmt.Sprintf("New reply on ticket: %s", ticket.Subject),
+		Content: snippet,
+	})
+	return err
+}
+
+// GetTicketMetrics computes SLA metrics for a single ticket
+func (s *TicketsService) GetTicketMetrics(ctx context.Context, ticketID string) (*TicketMetricsResponse, error) {
+	ticket, err := s.GetTicketByID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &TicketMetricsResponse{
+		TicketID:        ticket.ID,
+		CreatedAt:       ticket.CreatedAt,
+		FirstResponseAt: ticket.FirstResponseAt,
+		ResolvedAt:      ticket.ResolvedAt,
+	}
+
+	if ticket.FirstResponseAt != nil {
+		seconds := int64(ticket.FirstResponseAt.Sub(ticket.CreatedAt).Seconds())
+		metrics.TimeToFirstResponseSeconds = &seconds
+	}
+
+	if ticket.ResolvedAt != nil {
+		seconds := int64(ticket.ResolvedAt.Sub(ticket.CreatedAt).Seconds())
+		metrics.TimeToResolutionSeconds = &seconds
+	}
+
+	return metrics, nil
+}
+
+// GetTicketMetricsSummary computes average SLA metrics for tickets created in a date range (admin only)
+func (s *TicketsService) GetTicketMetricsSummary(ctx context.Context, from, to time.Time) (*TicketMetricsSummaryResponse, error) {
+	query := `
+		SELECT
+			COUNT(*),
+			COUNT(first_response_at),
+			COUNT(resolved_at),
+			AVG(EXTRACT(EPOCH FROM (first_response_at - created_at))),
+			AVG(EXTRACT(EPOCH FROM (resolved_at - created_at)))
+		FROM support_tickets
+		WHERE created_at >= $1 AND created_at < $2
+	`
+
+	summary := &TicketMetricsSummaryResponse{
+		From: from,
+		To:   to,
+	}
+
+	var avgFirstResponse, avgResolution sql.NullFloat64
+	err := s.db.QueryRowContext(ctx, query, from, to).Scan(
+		&summary.TotalTickets,
+		&summary.RespondedTickets,
+		&summary.ResolvedTickets,
+		&avgFirstResponse,
+		&avgResolution,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ticket metrics: %w", err)
+	}
+
+	if avgFirstResponse.Valid {
+		summary.AvgTimeToFirstResponseSeconds = &avgFirstResponse.Float64
+	}
+
+	if avgResolution.Valid {
+		summary.AvgTimeToResolutionSeconds = &avgResolution.Float64
+	}
+
+	return summary, nil
+}
+
+// getReply retrieves a non-deleted reply belonging to a ticket
+func (s *TicketsService) getReply(ctx context.Context, ticketID, replyID string) (*models.SupportTicketReply, error) {
+	query := `
+		SELECT id, ticket_id, user_id, is_staff, is_internal, content, created_at, updated_at, deleted_at
+		FROM support_ticket_replies
+		WHERE id = $1 AND ticket_id = $2 AND deleted_at IS NULL
+	`
+
+	var reply models.SupportTicketReply
+	err := s.db.QueryRowContext(ctx, query, replyID, ticketID).Scan(
+		&reply.ID,
+		&reply.TicketID,
+		&reply.UserID,
+		&reply.IsStaff,
+		&reply.IsInternal,
+		&reply.Content,
+		&reply.CreatedAt,
+		&reply.UpdatedAt,
+		&reply.DeletedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrReplyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reply: %w", err)
+	}
+
+	return &reply, nil
+}
+
+// UpdateReply edits the content of a reply (author or admin only)
+func (s *TicketsService) UpdateReply(ctx context.Context, ticketID, replyID, userID string, isAdmin bool, req *UpdateReplyRequest, baseURL string) (*ReplyResponse, error) {
+	existing, err := s.getReply(ctx, ticketID, replyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isAdmin && existing.UserID != userID {
+		return nil, ErrReplyAccessDenied
+	}
+
+	query := `
+		UPDATE support_ticket_replies
+		SET content = $1, updated_at = $2
+		WHERE id = $3 AND deleted_at IS NULL
+		RETURNING id, ticket_id, user_id, is_staff, is_internal, content, created_at, updated_at, deleted_at
+	`
+
+	var reply models.SupportTicketReply
+	err = s.db.QueryRowContext(ctx, query, req.Content, clients.Now(), replyID).Scan(
+		&reply.ID,
+		&reply.TicketID,
+		&reply.UserID,
+		&reply.IsStaff,
+		&reply.IsInternal,
+		&reply.Content,
+		&reply.CreatedAt,
+		&reply.UpdatedAt,
+		&reply.DeletedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrReplyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update reply: %w", err)
+	}
+
+	replyResponse := s.toReplyResponse(&reply)
+	if err := s.loadAttachments(ctx, []*ReplyResponse{replyResponse}, baseURL); err != nil {
+		return nil, err
+	}
+
+	return replyResponse, nil
+}
+
+// DeleteReply soft deletes a reply (author or admin only)
+func (s *TicketsService) DeleteReply(ctx context.Context, ticketID, replyID, userID string, isAdmin bool) error {
+	existing, err := s.getReply(ctx, ticketID, replyID)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin && existing.UserID != userID {
+		return ErrReplyAccessDenied
+	}
+
+	now := clients.Now()
+	query := `UPDATE support_ticket_replies SET deleted_at = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+
+	result, err := s.db.ExecContext(ctx, query, now, now, replyID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reply: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrReplyNotFound
+	}
+
+	return nil
 }
 
 // DeleteTicket deletes a ticket (user can only delete their own open tickets)
-func (s *TicketsService) DeleteTicket(ticketID, userID string) error {
+func (s *TicketsService) DeleteTicket(ctx context.Context, ticketID, userID string) error {
 	query := `DELETE FROM support_tickets WHERE id = $1 AND user_id = $2 AND status = 'open'`
 
-	result, err := s.db.Exec(query, ticketID, userID)
+	result, err := s.db.ExecContext(ctx, query, ticketID, userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete ticket: %w", err)
 	}
@@ -574,7 +1189,7 @@ func (s *TicketsService) DeleteTicket(ticketID, userID string) error {
 	}
 
 	if rows == 0 {
-		return fmt.Errorf("ticket not found or cannot be deleted")
+		return ErrTicketNotDeletable
 	}
 
 	// Invalidate cache
@@ -582,3 +1197,221 @@ func (s *TicketsService) DeleteTicket(ticketID, userID string) error {
 
 	return nil
 }
+
+// resolveCategory returns the managed category matching name regardless of case, so
+// tickets always carry its canonical spelling. Names missing from the list are
+// rejected unless free-text categories are enabled.
+func (s *TicketsService) resolveCategory(ctx context.Context, name string) (string, error) {
+	name = strings.TrimSpace(name)
+
+	var canonical string
+	err := s.db.QueryRowContext(ctx, `SELECT name FROM ticket_categories WHERE LOWER(name) = LOWER($1)`, name).Scan(&canonical)
+	if err == sql.ErrNoRows {
+		if s.config.Tickets.FreeTextCategories {
+			return name, nil
+		}
+		return "", fmt.Errorf("%w: %s", ErrUnknownCategory, name)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return canonical, nil
+}
+
+// ListCategories lists all ticket categories by name
+func (s *TicketsService) ListCategories(ctx context.Context) ([]*CategoryResponse, error) {
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM ticket_categories
+		ORDER BY LOWER(name)
+	`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := []*CategoryResponse{}
+	for rows.Next() {
+		var category models.TicketCategory
+		if err := rows.Scan(
+			&category.ID,
+			&category.Name,
+			&category.Description,
+			&category.CreatedAt,
+			&category.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, toCategoryResponse(&category))
+	}
+
+	return categories, rows.Err()
+}
+
+// categoryNameTaken reports whether another category already uses name, ignoring case
+func (s *TicketsService) categoryNameTaken(ctx context.Context, name, excludeID string) (bool, error) {
+	var taken bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM ticket_categories WHERE LOWER(name) = LOWER($1) AND id::text <> $2)`,
+		name, excludeID,
+	).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("failed to check category: %w", err)
+	}
+
+	return taken, nil
+}
+
+// CreateCategory adds a ticket category (admin only)
+func (s *TicketsService) CreateCategory(ctx context.Context, req *CreateCategoryRequest) (*CategoryResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, response.InvalidError("name must not be blank")
+	}
+
+	taken, err := s.categoryNameTaken(ctx, name, "")
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrCategoryExists
+	}
+
+	query := `
+		INSERT INTO ticket_categories (name, description, created_at, updated_at)
+		VALUES ($1, $2, $3, $3)
+		RETURNING id, name, description, created_at, updated_at
+	`
+
+	var category models.TicketCategory
+	err = s.db.QueryRowContext(ctx, query,
+		name,
+		sql.NullString{String: req.Description, Valid: req.Description != ""},
+		clients.Now(),
+	).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Description,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	// A concurrent create can claim the name between the check and the insert
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return nil, ErrCategoryExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return toCategoryResponse(&category), nil
+}
+
+// UpdateCategory renames or redescribes a ticket category (admin only). Tickets filed
+// under the old name are moved to the new one.
+func (s *TicketsService) UpdateCategory(ctx context.Context, id string, req *UpdateCategoryRequest) (*CategoryResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRowContext(ctx, `SELECT name FROM ticket_categories WHERE id = $1 FOR UPDATE`, id).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		return nil, ErrCategoryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	name := oldName
+	if newName := strings.TrimSpace(req.Name); newName != "" && newName != oldName {
+		taken, err := s.categoryNameTaken(ctx, newName, id)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrCategoryExists
+		}
+		name = newName
+	}
+
+	query := `UPDATE ticket_categories SET name = $1, updated_at = $2`
+	args := []interface{}{name, clients.Now()}
+	if req.Description != nil {
+		query += `, description = $3`
+		args = append(args, sql.NullString{String: *req.Description, Valid: *req.Description != ""})
+	}
+	query += fmt.Sprintf(` WHERE id = $%d RETURNING id, name, description, created_at, updated_at`, len(args)+1)
+	args = append(args, id)
+
+	var category models.TicketCategory
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
+		&category.ID,
+		&category.Name,
+		&category.Description,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return nil, ErrCategoryExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	if name != oldName {
+		// Free-text tickets may spell the category in any case
+		if _, err := tx.ExecContext(ctx, `UPDATE support_tickets SET category = $1 WHERE LOWER(category) = LOWER($2)`, name, oldName); err != nil {
+			return nil, fmt.Errorf("failed to rename category on tickets: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return toCategoryResponse(&category), nil
+}
+
+// DeleteCategory removes a ticket category (admin only). Existing tickets keep the
+// category name; new tickets can no longer use it unless free-text categories are on.
+func (s *TicketsService) DeleteCategory(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM ticket_categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return ErrCategoryNotFound
+	}
+
+	return nil
+}
+
+// toCategoryResponse converts a models.TicketCategory to CategoryResponse
+func toCategoryResponse(category *models.TicketCategory) *CategoryResponse {
+	resp := &CategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+	}
+
+	if category.Description.Valid {
+		description := category.Description.String
+		resp.Description = &description
+	}
+
+	return resp
+}
@@ -0,0 +1,157 @@
+package tickets
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"gogin/internal/config"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/response"
+	"gogin/internal/testutil"
+)
+
+func TestReplyCountExcludesHiddenReplies(t *testing.T) {
+	db := testutil.Database(t)
+	userID := testutil.CreateUser(t, db, "user")
+	service := NewTicketsService(db, nil, &config.Config{}, nil, nil, nil)
+
+	var ticketID string
+	err := db.QueryRow(`
+		INSERT INTO support_tickets (user_id, subject, description)
+		VALUES ($1, 'Reply count', 'Counting replies')
+		RETURNING id
+	`, userID).Scan(&ticketID)
+	if err != nil {
+		t.Fatalf("insert ticket: %v", err)
+	}
+
+	// Two visible replies, one deleted and one internal note
+	_, err = db.Exec(`
+		INSERT INTO support_ticket_replies (ticket_id, user_id, content, deleted_at, is_internal) VALUES
+			($1, $2, 'first', NULL, FALSE),
+			($1, $2, 'second', NULL, FALSE),
+			($1, $2, 'deleted', NOW(), FALSE),
+			($1, $2, 'internal', NULL, TRUE)
+	`, ticketID, userID)
+	if err != nil {
+		t.Fatalf("insert replies: %v", err)
+	}
+
+	ticket, err := service.GetTicketByID(context.Background(), ticketID)
+	if err != nil {
+		t.Fatalf("GetTicketByID: %v", err)
+	}
+	if ticket.ReplyCount != 2 {
+		t.Errorf("GetTicketByID reply count = %d, want 2", ticket.ReplyCount)
+	}
+
+	list, err := service.ListUserTickets(context.Background(), userID, "", response.Pagination{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("ListUserTickets: %v", err)
+	}
+	if len(list.Tickets) != 1 || list.Tickets[0].ReplyCount != 2 {
+		t.Errorf("ListUserTickets = %+v, want one ticket with 2 replies", list.Tickets)
+	}
+}
+
+func TestCanTransitionStatus(t *testing.T) {
+	statuses := []string{"open", "in_progress", "resolved", "closed"}
+	allowed := map[string]bool{
+		"open->in_progress":     true,
+		"in_progress->resolved": true,
+		"resolved->closed":      true,
+		"resolved->open":        true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			transition := from + "->" + to
+			t.Run(transition, func(t *testing.T) {
+				if got := canTransitionStatus(from, to); got != allowed[transition] {
+					t.Errorf("canTransitionStatus(%q, %q) = %v, want %v", from, to, got, allowed[transition])
+				}
+			})
+		}
+	}
+}
+
+func TestReopenClearsResolution(t *testing.T) {
+	db := testutil.Database(t)
+	redisHelper := redishelper.NewRedisHelper(testutil.Redis(t))
+	userID := testutil.CreateUser(t, db, "user")
+	service := NewTicketsService(db, redisHelper, &config.Config{}, nil, nil, nil)
+
+	var ticketID string
+	err := db.QueryRow(`
+		INSERT INTO support_tickets (user_id, subject, description, status, resolved_at)
+		VALUES ($1, 'Reopen', 'Reopening a resolved ticket', 'resolved', NOW())
+		RETURNING id
+	`, userID).Scan(&ticketID)
+	if err != nil {
+		t.Fatalf("insert ticket: %v", err)
+	}
+
+	ticket, err := service.UpdateTicketStatus(context.Background(), ticketID, &UpdateTicketStatusRequest{Status: "open"})
+	if err != nil {
+		t.Fatalf("UpdateTicketStatus: %v", err)
+	}
+	if ticket.Status != "open" || ticket.ResolvedAt != nil || ticket.ClosedAt != nil {
+		t.Errorf("reopened ticket = status %q, resolved_at %v, closed_at %v; want open with both cleared",
+			ticket.Status, ticket.ResolvedAt, ticket.ClosedAt)
+	}
+
+	_, err = service.UpdateTicketStatus(context.Background(), ticketID, &UpdateTicketStatusRequest{Status: "closed"})
+	if !errors.Is(err, ErrInvalidStatusTransition) {
+		t.Errorf("open -> closed error = %v, want ErrInvalidStatusTransition", err)
+	}
+}
+
+func TestUpdateTicketPriority(t *testing.T) {
+	db := testutil.Database(t)
+	redisHelper := redishelper.NewRedisHelper(testutil.Redis(t))
+	userID := testutil.CreateUser(t, db, "user")
+	otherID := testutil.CreateUser(t, db, "user")
+	service := NewTicketsService(db, redisHelper, &config.Config{}, nil, nil, nil)
+
+	var ticketID string
+	err := db.QueryRow(`
+		INSERT INTO support_tickets (user_id, subject, description, priority)
+		VALUES ($1, 'Escalate', 'Raising the priority to urgent', 'low')
+		RETURNING id
+	`, userID).Scan(&ticketID)
+	if err != nil {
+		t.Fatalf("insert ticket: %v", err)
+	}
+
+	// Concurrent escalations serialize on the row lock and both succeed
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.UpdateTicket(context.Background(), ticketID, userID, &UpdateTicketRequest{Priority: "urgent"})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("UpdateTicket: %v", err)
+		}
+	}
+
+	ticket, err := service.GetTicketByID(context.Background(), ticketID)
+	if err != nil {
+		t.Fatalf("GetTicketByID: %v", err)
+	}
+	if ticket.Priority != "urgent" {
+		t.Errorf("priority = %q, want urgent", ticket.Priority)
+	}
+
+	_, err = service.UpdateTicket(context.Background(), ticketID, otherID, &UpdateTicketRequest{Priority: "low"})
+	if !errors.Is(err, ErrTicketNotEditable) {
+		t.Errorf("update by another user error = %v, want ErrTicketNotEditable", err)
+	}
+}
@@ -1,29 +1,37 @@
 package twilio
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 
 	"gogin/internal/config"
+	"gogin/internal/utils"
 )
 
 // TwilioClient wraps Twilio API
 type TwilioClient struct {
-	accountSID string
-	authToken  string
-	fromNumber string
+	accountSID        string
+	authToken         string
+	fromNumber        string
+	statusCallbackURL string
 }
 
 // NewTwilioClient creates a new Twilio client
 func NewTwilioClient(cfg config.TwilioConfig) *TwilioClient {
 	return &TwilioClient{
-		accountSID: cfg.AccountSID,
-		authToken:  cfg.AuthToken,
-		fromNumber: cfg.FromNumber,
+		accountSID:        cfg.AccountSID,
+		authToken:         cfg.AuthToken,
+		fromNumber:        cfg.FromNumber,
+		statusCallbackURL: cfg.StatusCallbackURL,
 	}
 }
 
@@ -31,12 +39,14 @@ func NewTwilioClient(cfg config.TwilioConfig) *TwilioClient {
 type SMSMessage struct {
 	To   string
 	Body string
+	// RequestID is sent as X-Request-ID so the call can be traced back to the request that caused it
+	RequestID string
 }
 
-// SendSMS sends an SMS via Twilio
-func (c *TwilioClient) SendSMS(msg *SMSMessage) error {
+// SendSMS sends an SMS via Twilio and returns the created message
+func (c *TwilioClient) SendSMS(msg *SMSMessage) (*TwilioResponse, error) {
 	if c.accountSID == "" || c.authToken == "" {
-		return fmt.Errorf("Twilio credentials not configured")
+		return nil, fmt.Errorf("Twilio credentials not configured")
 	}
 
 	apiURL := fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", c.accountSID)
@@ -45,47 +55,60 @@ func (c *TwilioClient) SendSMS(msg *SMSMessage) error {
 	data.Set("To", msg.To)
 	data.Set("From", c.fromNumber)
 	data.Set("Body", msg.Body)
+	if c.statusCallbackURL != "" {
+		data.Set("StatusCallback", c.statusCallbackURL)
+	}
 
 	req, err := http.NewRequest("POST", apiURL, strings.NewReader(data.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.SetBasicAuth(c.accountSID, c.authToken)
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if msg.RequestID != "" {
+		req.Header.Set(utils.RequestIDHeader, msg.RequestID)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send SMS: %w", err)
+		return nil, fmt.Errorf("failed to send SMS: %w", err)
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(resp.Body)
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Twilio API error (%d): %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("Twilio API error (%d): %s", resp.StatusCode, string(body))
+	}
+
+	result, err := c.ParseResponse(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Twilio response: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
-// SendVerificationCode sends a verification code via SMS
-func (c *TwilioClient) SendVerificationCode(phoneNumber, code string) error {
+// SendVerificationCode sends a verification code via SMS, tagged with the request ID carried by ctx
+func (c *TwilioClient) SendVerificationCode(ctx context.Context, phoneNumber, code string) error {
 	msg := &SMSMessage{
-		To:   phoneNumber,
-		Body: fmt.Sprintf("Your verification code is: %s", code),
+		To:        phoneNumber,
+		Body:      fmt.Sprintf("Your verification code is: %s", code),
+		RequestID: utils.RequestIDFromContext(ctx),
 	}
-	return c.SendSMS(msg)
+	_, err := c.SendSMS(msg)
+	return err
 }
 
 // TwilioResponse represents Twilio API response
 type TwilioResponse struct {
-	SID         string `json:"sid"`
-	Status      string `json:"status"`
-	To          string `json:"to"`
-	From        string `json:"from"`
-	Body        string `json:"body"`
-	ErrorCode   int    `json:"error_code,omitempty"`
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	To           string `json:"to"`
+	From         string `json:"from"`
+	Body         string `json:"body"`
+	ErrorCode    int    `json:"error_code,omitempty"`
 	ErrorMessage string `json:"error_message,omitempty"`
 }
 
@@ -95,3 +118,37 @@ func (c *TwilioClient) ParseResponse(body []byte) (*TwilioResponse, error) {
 	err := json.Unmarshal(body, &resp)
 	return &resp, err
 }
+
+// ValidateSignature verifies the X-Twilio-Signature header of a webhook request.
+// The signature is base64(HMAC-SHA1(authToken, url + sorted POST params as key+value)).
+func (c *TwilioClient) ValidateSignature(requestURL string, params url.Values, signature string) bool {
+	if c.authToken == "" || signature == "" {
+		return false
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var payload strings.Builder
+	payload.WriteString(requestURL)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			payload.WriteString(key)
+			payload.WriteString(value)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(c.authToken))
+	mac.Write([]byte(payload.String()))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// StatusCallbackURL returns the configured public status callback URL
+func (c *TwilioClient) StatusCallbackURL() string {
+	return c.statusCallbackURL
+}
@@ -2,20 +2,23 @@ package users
 
 import (
 	"time"
+
+	"gogin/internal/response"
 )
 
 // RegisterRequest represents a user registration request
 type RegisterRequest struct {
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=8"`
+	Password  string `json:"password" binding:"required"` // checked against the configured password policy
 	FirstName string `json:"first_name" binding:"required"`
 	LastName  string `json:"last_name" binding:"required"`
 }
 
 // LoginRequest represents a login request
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	DeviceName string `json:"device_name" binding:"omitempty,max=100"` // shown in the session list
 }
 
 // UpdateProfileRequest represents a profile update request
@@ -25,10 +28,27 @@ type UpdateProfileRequest struct {
 	Phone     string `json:"phone"`
 }
 
+// UpdateUserProfileRequest represents an update of the extended profile. Omitted fields are cleared.
+type UpdateUserProfileRequest struct {
+	Bio         string `json:"bio" binding:"omitempty,max=2000"`
+	DateOfBirth string `json:"date_of_birth"` // YYYY-MM-DD
+	Gender      string `json:"gender" binding:"omitempty,max=50"`
+	Address     string `json:"address" binding:"omitempty,max=500"`
+	City        string `json:"city" binding:"omitempty,max=100"`
+	State       string `json:"state" binding:"omitempty,max=100"`
+	Country     string `json:"country" binding:"omitempty,max=100"`
+	ZipCode     string `json:"zip_code" binding:"omitempty,max=20"`
+}
+
+// VerifyPhoneRequest represents a phone verification code submission
+type VerifyPhoneRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
 // ChangePasswordRequest represents a password change request
 type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
-	NewPassword string `json:"new_password" binding:"required,min=8"`
+	NewPassword string `json:"new_password" binding:"required"` // checked against the configured password policy
 }
 
 // UserResponse represents a user response (without sensitive data)
@@ -47,20 +67,69 @@ type UserResponse struct {
 	UpdatedAt     time.Time `json:"updated_at"`
 }
 
-// LoginResponse represents a login response with tokens
+// UserProfileResponse represents the extended profile, optionally with the core user
+type UserProfileResponse struct {
+	UserID      string        `json:"user_id"`
+	Bio         string        `json:"bio,omitempty"`
+	DateOfBirth string        `json:"date_of_birth,omitempty"`
+	Gender      string        `json:"gender,omitempty"`
+	Address     string        `json:"address,omitempty"`
+	City        string        `json:"city,omitempty"`
+	State       string        `json:"state,omitempty"`
+	Country     string        `json:"country,omitempty"`
+	ZipCode     string        `json:"zip_code,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+	User        *UserResponse `json:"user,omitempty"`
+}
+
+// LoginResponse represents a login response with tokens, or a 2FA challenge to complete
+// at /users/login/2fa when requires_2fa is set. ExpiresIn then applies to the challenge.
 type LoginResponse struct {
-	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
-	TokenType    string        `json:"token_type"`
-	ExpiresIn    int           `json:"expires_in"`
-	User         *UserResponse `json:"user"`
+	AccessToken    string        `json:"access_token,omitempty"`
+	RefreshToken   string        `json:"refresh_token,omitempty"`
+	TokenType      string        `json:"token_type,omitempty"`
+	ExpiresIn      int           `json:"expires_in"`
+	User           *UserResponse `json:"user,omitempty"`
+	Requires2FA    bool          `json:"requires_2fa,omitempty"`
+	ChallengeToken string        `json:"challenge_token,omitempty"`
+}
+
+// TwoFactorLoginRequest completes a login that requires 2FA
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required,max=20"` // TOTP or recovery code
+	DeviceName     string `json:"device_name" binding:"omitempty,max=100"`
+}
+
+// TwoFactorCodeRequest represents a TOTP code submission
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// TwoFactorSetupResponse carries the secret to add to an authenticator app
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI to render as a QR code
+}
+
+// TwoFactorConfirmResponse carries recovery codes, which are only ever shown once
+type TwoFactorConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// SessionResponse represents an active login session
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	Device    string    `json:"device,omitempty"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+	Current   bool      `json:"current"`
 }
 
 // UsersListResponse represents a paginated list of users
 type UsersListResponse struct {
-	Users      []*UserResponse `json:"users"`
-	Total      int             `json:"total"`
-	Page       int             `json:"page"`
-	Limit      int             `json:"limit"`
-	TotalPages int             `json:"total_pages"`
+	Users []*UserResponse `json:"users"`
+	response.PaginationMeta
 }
@@ -1,10 +1,18 @@
 package users
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"gogin/internal/clients"
+	"gogin/internal/middleware"
+	"gogin/internal/models"
+	"gogin/internal/modules/storage"
 	"gogin/internal/response"
+	"gogin/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,16 +31,15 @@ import (
 func (m *UsersModule) register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	user, err := m.service.CreateUser(&req)
+	user, err := m.service.CreateUser(c.Request.Context(), &req)
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		if !respondPasswordPolicyError(c, err, "password") {
+			response.BadRequest(c, err.Error())
+		}
 		return
 	}
 
@@ -51,26 +58,131 @@ func (m *UsersModule) register(c *gin.Context) {
 // @Success 200 {object} response.Response{data=LoginResponse}
 // @Failure 422 {object} response.Response{errors=[]response.ResponseError}
 // @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Router /users/login [post]
 func (m *UsersModule) login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	loginResp, err := m.service.AuthenticateUser(req.Email, req.Password)
+	// Throttle attempts per account and IP, so guessing one account's password is slowed
+	// without locking out other users behind the same NAT
+	limitKey := fmt.Sprintf("login:%s:%s", strings.ToLower(strings.TrimSpace(req.Email)), c.ClientIP())
+	result, err := middleware.RateLimitByKey(m.redis, limitKey, m.config.App.LoginRateLimit, m.config.App.LoginRateWindow)
+	if err != nil {
+		// Fail open like the global limiter
+		fmt.Printf("[RATE LIMIT ERROR] %v\n", err)
+	} else if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(result.RetryAfterSeconds()))
+		response.TooManyRequests(c, "Too many login attempts. Please try again later.")
+		return
+	}
+
+	loginResp, err := m.service.AuthenticateUser(c.Request.Context(), req.Email, req.Password, sessionClient(c, req.DeviceName))
 	if err != nil {
 		response.Unauthorized(c, err.Error())
 		return
 	}
 
+	if loginResp.Requires2FA {
+		response.Success(c, http.StatusOK, "Two-factor authentication required", loginResp)
+		return
+	}
+
 	response.Success(c, http.StatusOK, "Login successful", loginResp)
 }
 
+// loginTwoFactor completes a login for a user with 2FA enabled
+// @Summary Complete two-factor login
+// @Description Exchange the challenge token from /users/login and a TOTP or recovery code for tokens
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body TwoFactorLoginRequest true "Challenge and code"
+// @Success 200 {object} response.Response{data=LoginResponse}
+// @Failure 400 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /users/login/2fa [post]
+func (m *UsersModule) loginTwoFactor(c *gin.Context) {
+	var req TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	loginResp, err := m.service.CompleteTwoFactorLogin(c.Request.Context(), req.ChallengeToken, req.Code, sessionClient(c, req.DeviceName))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to complete login")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Login successful", loginResp)
+}
+
+// enableTwoFactor starts 2FA setup for the current user
+// @Summary Enable two-factor authentication
+// @Description Generate a TOTP secret and provisioning URI. 2FA is not active until confirmed.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=TwoFactorSetupResponse}
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /users/me/2fa/enable [post]
+func (m *UsersModule) enableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	setup, err := m.service.EnableTwoFactor(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Scan the provisioning URI with an authenticator app, then confirm with a code", setup)
+}
+
+// confirmTwoFactor activates 2FA for the current user
+// @Summary Confirm two-factor authentication
+// @Description Verify a code from the authenticator app to activate 2FA. Returns recovery codes, shown only once.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} response.Response{data=TwoFactorConfirmResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /users/me/2fa/confirm [post]
+func (m *UsersModule) confirmTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	confirmation, err := m.service.ConfirmTwoFactor(c.Request.Context(), userID.(string), req.Code)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to confirm two-factor authentication")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Two-factor authentication enabled", confirmation)
+}
+
 // getProfile retrieves the current user's profile
 // @Summary Get user profile
 // @Description Get the authenticated user's profile information
@@ -88,7 +200,7 @@ func (m *UsersModule) getProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := m.service.GetUserByID(userID.(string))
+	user, err := m.service.GetUserByID(c.Request.Context(), userID.(string))
 	if err != nil {
 		response.NotFound(c, "User not found")
 		return
@@ -121,14 +233,11 @@ func (m *UsersModule) updateProfile(c *gin.Context) {
 
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	user, err := m.service.UpdateUser(userID.(string), &req)
+	user, err := m.service.UpdateUser(c.Request.Context(), userID.(string), &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -139,6 +248,204 @@ func (m *UsersModule) updateProfile(c *gin.Context) {
 	})
 }
 
+// sendPhoneCode texts a verification code to the current user's phone
+// @Summary Send phone verification code
+// @Description Send a 6-digit code by SMS to the authenticated user's phone number. The code expires after 10 minutes.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /users/me/phone/send-code [post]
+func (m *UsersModule) sendPhoneCode(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	// Each code costs an SMS, so cap how often a user can request one
+	limitKey := fmt.Sprintf("phone_code:%s", userID.(string))
+	result, err := middleware.RateLimitByKey(m.redis, limitKey, m.config.Users.PhoneCodeRateLimit, m.config.Users.PhoneCodeRateWindow)
+	if err != nil {
+		fmt.Printf("[RATE LIMIT ERROR] %v\n", err)
+	} else if !result.Allowed {
+		c.Header("Retry-After", strconv.Itoa(result.RetryAfterSeconds()))
+		response.TooManyRequests(c, "Too many verification codes requested. Please try again later.")
+		return
+	}
+
+	if err := m.service.SendPhoneVerificationCode(c.Request.Context(), userID.(string)); err != nil {
+		response.HandleServiceError(c, err, "Failed to send verification code")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Verification code sent", nil)
+}
+
+// verifyPhone checks a phone verification code
+// @Summary Verify phone number
+// @Description Verify the authenticated user's phone number with the code sent by SMS
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body VerifyPhoneRequest true "Verification code"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Router /users/me/phone/verify [post]
+func (m *UsersModule) verifyPhone(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req VerifyPhoneRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	if err := m.service.VerifyPhone(c.Request.Context(), userID.(string), req.Code); err != nil {
+		response.HandleServiceError(c, err, "Failed to verify phone number")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Phone number verified successfully", nil)
+}
+
+// getUserProfile retrieves the current user's extended profile
+// @Summary Get extended profile
+// @Description Get the authenticated user's bio, date of birth, gender and address. Pass include=user to embed the core user.
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param include query string false "Set to user to include the core user"
+// @Success 200 {object} response.Response{data=object{profile=UserProfileResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /users/me/profile [get]
+func (m *UsersModule) getUserProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	profile, err := m.service.GetUserProfile(c.Request.Context(), userID.(string))
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to retrieve profile")
+		return
+	}
+
+	m.respondWithProfile(c, "Profile retrieved successfully", profile)
+}
+
+// updateUserProfile updates the current user's extended profile
+// @Summary Update extended profile
+// @Description Replace the authenticated user's bio, date of birth, gender and address. Pass include=user to embed the core user.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param include query string false "Set to user to include the core user"
+// @Param request body UpdateUserProfileRequest true "Profile details"
+// @Success 200 {object} response.Response{data=object{profile=UserProfileResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 422 {object} response.Response{errors=[]response.ResponseError}
+// @Failure 400 {object} response.Response
+// @Router /users/me/profile [put]
+func (m *UsersModule) updateUserProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req UpdateUserProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BindError(c, err)
+		return
+	}
+
+	profile, err := m.service.UpdateUserProfile(c.Request.Context(), userID.(string), &req)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to update profile")
+		return
+	}
+
+	m.respondWithProfile(c, "Profile updated successfully", profile)
+}
+
+// respondWithProfile writes the profile, merged with the core user when include=user
+func (m *UsersModule) respondWithProfile(c *gin.Context, message string, profile *models.UserProfile) {
+	resp := m.service.toProfileResponse(profile)
+	if c.Query("include") == "user" {
+		user, err := m.service.GetUserByID(c.Request.Context(), profile.UserID)
+		if err != nil {
+			response.NotFound(c, "User not found")
+			return
+		}
+		resp.User = m.service.sanitizeUser(user)
+	}
+
+	response.Success(c, http.StatusOK, message, gin.H{
+		"profile": resp,
+	})
+}
+
+// uploadAvatar replaces the current user's avatar
+// @Summary Upload avatar
+// @Description Upload an image as the authenticated user's avatar. The previous avatar is removed.
+// @Tags Users
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Avatar image"
+// @Success 200 {object} response.Response{data=object{user=UserResponse}}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 413 {object} response.Response
+// @Router /users/me/avatar [post]
+func (m *UsersModule) uploadAvatar(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		if response.BodyTooLarge(c, err) {
+			return
+		}
+		response.BadRequest(c, "No file provided")
+		return
+	}
+
+	baseURL := storage.BaseURL(c, m.config.App.PublicURL)
+
+	user, err := m.service.SetAvatar(c.Request.Context(), userID.(string), file, baseURL)
+	if err != nil {
+		if errors.Is(err, storage.ErrFileTooLarge) {
+			response.PayloadTooLarge(c, err.Error())
+			return
+		}
+		response.HandleServiceError(c, err, "Failed to upload avatar")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Avatar updated successfully", gin.H{
+		"user": m.service.sanitizeUser(user),
+	})
+}
+
 // changePassword changes the current user's password
 // @Summary Change password
 // @Description Change the authenticated user's password
@@ -161,16 +468,15 @@ func (m *UsersModule) changePassword(c *gin.Context) {
 
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	err := m.service.ChangePassword(userID.(string), req.OldPassword, req.NewPassword)
+	err := m.service.ChangePassword(c.Request.Context(), userID.(string), req.OldPassword, req.NewPassword)
 	if err != nil {
-		response.BadRequest(c, err.Error())
+		if !respondPasswordPolicyError(c, err, "new_password") {
+			response.BadRequest(c, err.Error())
+		}
 		return
 	}
 
@@ -195,16 +501,80 @@ func (m *UsersModule) logout(c *gin.Context) {
 
 	userID, _ := c.Get("user_id")
 
-	// Revoke current token
-	// Note: We would need to get expiry from token claims to properly revoke
-	// For now, we'll delete the session
-	if userID != nil {
+	// End the session the token belongs to, which also invalidates its tokens. Tokens
+	// issued before sessions existed carry none, so fall back to ending them all.
+	if sessionID, ok := c.Get("session_id"); ok {
+		m.service.redisHelper.DeleteSession(sessionID.(string))
+	} else if userID != nil {
 		m.service.redisHelper.DeleteAllUserSessions(userID.(string))
 	}
 
 	response.Success(c, http.StatusOK, "Logged out successfully", nil)
 }
 
+// listSessions lists the current user's active sessions
+// @Summary List sessions
+// @Description List the authenticated user's active login sessions with their device, IP address and user agent
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=object{sessions=[]SessionResponse}}
+// @Failure 401 {object} response.Response
+// @Router /users/me/sessions [get]
+func (m *UsersModule) listSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	currentSessionID := c.GetString("session_id")
+	sessions, err := m.service.ListSessions(userID.(string), currentSessionID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to list sessions")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Sessions retrieved successfully", gin.H{
+		"sessions": sessions,
+	})
+}
+
+// revokeSession revokes one of the current user's sessions
+// @Summary Revoke session
+// @Description Sign out a single device by ending its session
+// @Tags Users
+// @Produce json
+// @Security BearerAuth
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /users/me/sessions/{sessionId} [delete]
+func (m *UsersModule) revokeSession(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := m.service.RevokeSession(userID.(string), c.Param("sessionId")); err != nil {
+		response.HandleServiceError(c, err, "Failed to revoke session")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// sessionClient describes the client making a login request
+func sessionClient(c *gin.Context, device string) SessionClient {
+	return SessionClient{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Device:    device,
+	}
+}
+
 // deleteAccount handles account deletion
 // @Summary Delete account
 // @Description Delete the authenticated user's account
@@ -222,7 +592,7 @@ func (m *UsersModule) deleteAccount(c *gin.Context) {
 		return
 	}
 
-	err := m.service.DeleteUser(userID.(string))
+	err := m.service.DeleteUser(c.Request.Context(), userID.(string))
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -239,18 +609,26 @@ func (m *UsersModule) deleteAccount(c *gin.Context) {
 // @Tags Admin
 // @Produce json
 // @Security BearerAuth
+// @Param filter[role] query string false "Comma-separated roles; filter[status], filter[email_verified] and filter[phone_verified] work the same way"
+// @Param sort query string false "Sort field, prefixed with - for descending: created_at, updated_at, last_login_at, email, first_name or last_name" default(-created_at)
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(20)
-// @Success 200 {object} response.Response{data=object{users=[]UserResponse,total=int,page=int,limit=int,total_pages=int}}
+// @Success 200 {object} response.Response{data=UsersListResponse}
+// @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
 // @Failure 500 {object} response.Response
 // @Router /users [get]
 func (m *UsersModule) listUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	pagination := response.ParsePagination(c)
+
+	listQuery, err := response.ParseListQuery(c, adminUserListSpec)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
 
-	users, total, err := m.service.ListUsers(page, limit)
+	users, total, err := m.service.ListUsers(c.Request.Context(), listQuery, pagination)
 	if err != nil {
 		response.InternalError(c, "Failed to list users")
 		return
@@ -262,14 +640,9 @@ func (m *UsersModule) listUsers(c *gin.Context) {
 		userResponses[i] = m.service.sanitizeUser(user)
 	}
 
-	totalPages := (total + limit - 1) / limit
-
-	response.Success(c, http.StatusOK, "Users retrieved successfully", gin.H{
-		"users":       userResponses,
-		"total":       total,
-		"page":        page,
-		"limit":       limit,
-		"total_pages": totalPages,
+	response.Success(c, http.StatusOK, "Users retrieved successfully", UsersListResponse{
+		Users:          userResponses,
+		PaginationMeta: pagination.Meta(total),
 	})
 }
 
@@ -288,7 +661,7 @@ func (m *UsersModule) listUsers(c *gin.Context) {
 func (m *UsersModule) getUserByID(c *gin.Context) {
 	userID := c.Param("id")
 
-	user, err := m.service.GetUserByID(userID)
+	user, err := m.service.GetUserByID(c.Request.Context(), userID)
 	if err != nil {
 		response.NotFound(c, "User not found")
 		return
@@ -319,14 +692,11 @@ func (m *UsersModule) updateUser(c *gin.Context) {
 
 	var req UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
-	user, err := m.service.UpdateUser(userID, &req)
+	user, err := m.service.UpdateUser(c.Request.Context(), userID, &req)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -352,7 +722,7 @@ func (m *UsersModule) updateUser(c *gin.Context) {
 func (m *UsersModule) adminDeleteUser(c *gin.Context) {
 	userID := c.Param("id")
 
-	err := m.service.DeleteUser(userID)
+	err := m.service.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
 		response.BadRequest(c, err.Error())
 		return
@@ -361,6 +731,33 @@ func (m *UsersModule) adminDeleteUser(c *gin.Context) {
 	response.Success(c, http.StatusOK, "User deleted successfully", nil)
 }
 
+// restoreUser restores a soft-deleted user (admin only)
+// @Summary Restore user
+// @Description Restore a soft-deleted user account within the restore window (admin only)
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} response.Response{data=object{user=UserResponse}}
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /users/{id}/restore [post]
+func (m *UsersModule) restoreUser(c *gin.Context) {
+	userID := c.Param("id")
+
+	user, err := m.service.RestoreUser(c.Request.Context(), userID)
+	if err != nil {
+		response.HandleServiceError(c, err, "Failed to restore user")
+		return
+	}
+
+	response.Success(c, http.StatusOK, "User restored successfully", gin.H{
+		"user": m.service.sanitizeUser(user),
+	})
+}
+
 // updateUserStatus updates a user's status (admin only)
 // @Summary Update user status
 // @Description Update a user's status (active, inactive, or suspended) (admin only)
@@ -384,16 +781,13 @@ func (m *UsersModule) updateUserStatus(c *gin.Context) {
 		Status string `json:"status" binding:"required,oneof=active inactive suspended"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errors := []response.ResponseError{
-			response.NewError("VALIDATION_ERROR", err.Error(), ""),
-		}
-		response.ValidationError(c, errors)
+		response.BindError(c, err)
 		return
 	}
 
 	// Update user status in database
-	query := `UPDATE users SET status = $1, updated_at = NOW() WHERE id = $2 AND deleted_at IS NULL`
-	result, err := m.service.db.Exec(query, req.Status, userID)
+	query := `UPDATE users SET status = $1, updated_at = $2 WHERE id = $3 AND deleted_at IS NULL`
+	result, err := m.service.db.Exec(query, req.Status, clients.Now(), userID)
 	if err != nil {
 		response.InternalError(c, "Failed to update user status")
 		return
@@ -414,3 +808,20 @@ func (m *UsersModule) updateUserStatus(c *gin.Context) {
 		"status": req.Status,
 	})
 }
+
+// respondPasswordPolicyError reports a password policy failure as one validation error
+// per unmet requirement, so clients can show which ones are missing. It returns false,
+// writing nothing, when err is not a policy failure.
+func respondPasswordPolicyError(c *gin.Context, err error, field string) bool {
+	var policyErr *utils.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	errs := make([]response.ResponseError, len(policyErr.Failed))
+	for i, requirement := range policyErr.Failed {
+		errs[i] = response.NewError("PASSWORD_"+strings.ToUpper(requirement.Code), requirement.Message, field)
+	}
+	response.ValidationError(c, errs)
+	return true
+}
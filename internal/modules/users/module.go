@@ -1,10 +1,14 @@
 package users
 
 import (
+	"time"
+
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/middleware"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/storage"
+	"gogin/internal/modules/twilio"
 	"gogin/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -14,29 +18,37 @@ import (
 type UsersModule struct {
 	service     *UserService
 	authMiddleware *middleware.AuthMiddleware
+	rateLimiter    *middleware.RateLimiter
+	redis          *clients.RedisClient
+	config         *config.Config
 }
 
 // NewUsersModule creates a new users module
 func NewUsersModule(db *clients.Database, redis *clients.RedisClient, cfg *config.Config) *UsersModule {
-	jwtUtil := utils.NewJWTUtil(cfg.OAuth.JWTSecret, cfg.OAuth.JWTIssuer)
+	jwtUtil := utils.NewJWTUtilFromConfig(cfg.OAuth)
 	redisHelper := redishelper.NewRedisHelper(redis)
 	authMiddleware := middleware.NewAuthMiddleware(jwtUtil, redisHelper)
 
-	service := NewUserService(db, jwtUtil, redisHelper, cfg)
+	service := NewUserService(db, jwtUtil, redisHelper, storage.NewStorageService(db, cfg), twilio.NewTwilioClient(cfg.Twilio), cfg)
 
 	return &UsersModule{
 		service:     service,
 		authMiddleware: authMiddleware,
+		rateLimiter:    middleware.NewRateLimiter(redis, cfg.App.RateLimitRPS, time.Second),
+		redis:          redis,
+		config:         cfg,
 	}
 }
 
 // RegisterRoutes registers user routes
 func (m *UsersModule) RegisterRoutes(router *gin.RouterGroup) {
 	users := router.Group("/users")
+	authLimit := m.rateLimiter.LimitRoute(m.config.App.AuthRateLimit, time.Minute)
 	{
-		// Public routes
-		users.POST("/register", m.register)
-		users.POST("/login", m.login)
+		// Public routes, with tighter limits to deter credential stuffing and signup abuse
+		users.POST("/register", authLimit, m.register)
+		users.POST("/login", authLimit, m.login)
+		users.POST("/login/2fa", authLimit, m.loginTwoFactor)
 
 		// Protected routes
 		auth := users.Group("")
@@ -44,8 +56,17 @@ func (m *UsersModule) RegisterRoutes(router *gin.RouterGroup) {
 		{
 			auth.GET("/me", m.getProfile)
 			auth.PUT("/me", m.updateProfile)
+			auth.POST("/me/2fa/enable", m.enableTwoFactor)
+			auth.POST("/me/2fa/confirm", m.confirmTwoFactor)
+			auth.POST("/me/phone/send-code", m.sendPhoneCode)
+			auth.POST("/me/phone/verify", m.verifyPhone)
+			auth.GET("/me/profile", m.getUserProfile)
+			auth.PUT("/me/profile", m.updateUserProfile)
 			auth.PUT("/me/password", m.changePassword)
+			auth.POST("/me/avatar", middleware.BodyLimit(m.config.Storage.AvatarMaxSize+storage.UploadFormOverhead), m.uploadAvatar)
 			auth.POST("/logout", m.logout)
+			auth.GET("/me/sessions", m.listSessions)
+			auth.DELETE("/me/sessions/:sessionId", m.revokeSession)
 			auth.DELETE("/me", m.deleteAccount)
 		}
 
@@ -58,6 +79,7 @@ func (m *UsersModule) RegisterRoutes(router *gin.RouterGroup) {
 			admin.GET("/:id", m.getUserByID)
 			admin.PUT("/:id", m.updateUser)
 			admin.DELETE("/:id", m.adminDeleteUser)
+			admin.POST("/:id/restore", m.restoreUser)
 			admin.PUT("/:id/status", m.updateUserStatus)
 		}
 	}
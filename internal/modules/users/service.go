@@ -1,48 +1,98 @@
 package users
 
 import (
+	"context"
+	"crypto/subtle"
 	"database/sql"
 	"fmt"
+	"log"
+	"mime/multipart"
+	"sort"
 	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/config"
 	"gogin/internal/models"
 	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/storage"
+	"gogin/internal/modules/twilio"
+	"gogin/internal/response"
 	"gogin/internal/utils"
 
 	"github.com/google/uuid"
 )
 
+// Errors returned when restoring users; match them with errors.Is
+var (
+	ErrUserNotFound         = response.NotFoundError("user not found")
+	ErrUserNotDeleted       = response.ConflictError("user is not deleted")
+	ErrRestoreWindowExpired = response.ConflictError("user was deleted too long ago to be restored")
+	ErrEmailReused          = response.ConflictError("email has been reused by another account")
+	ErrInvalidDateOfBirth   = response.InvalidError("date_of_birth must be a past date in YYYY-MM-DD format")
+	ErrPhoneMissing         = response.InvalidError("add a phone number in E.164 format before verifying it")
+	ErrPhoneAlreadyVerified = response.ConflictError("phone number is already verified")
+	ErrInvalidPhoneCode     = response.InvalidError("invalid or expired verification code")
+	ErrSessionNotFound      = response.NotFoundError("session not found")
+)
+
+const (
+	phoneCodeLength      = 6
+	phoneCodeTTL         = 10 * time.Minute
+	phoneCodeMaxAttempts = 5
+)
+
+// phoneVerification is the pending code for a user, bound to the number it was sent to
+type phoneVerification struct {
+	ID    string `json:"id"`
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
+
+// dateOfBirthLayout is the format date_of_birth is accepted and returned in
+const dateOfBirthLayout = "2006-01-02"
+
+// SessionClient describes the client a login session is created for
+type SessionClient struct {
+	IPAddress string
+	UserAgent string
+	Device    string // optional name chosen by the user, e.g. "Work laptop"
+}
+
 // UserService handles user business logic
 type UserService struct {
 	db          *clients.Database
 	jwtUtil     *utils.JWTUtil
 	redisHelper *redishelper.RedisHelper
+	storage     *storage.StorageService
+	twilio      *twilio.TwilioClient
 	config      *config.Config
+	// passwordPolicy is built once so the breach checker's HTTP client is reused
+	passwordPolicy utils.PasswordPolicy
 }
 
 // NewUserService creates a new user service
-func NewUserService(db *clients.Database, jwtUtil *utils.JWTUtil, redisHelper *redishelper.RedisHelper, cfg *config.Config) *UserService {
+func NewUserService(db *clients.Database, jwtUtil *utils.JWTUtil, redisHelper *redishelper.RedisHelper, storageService *storage.StorageService, twilioClient *twilio.TwilioClient, cfg *config.Config) *UserService {
 	return &UserService{
-		db:          db,
-		jwtUtil:     jwtUtil,
-		redisHelper: redisHelper,
-		config:      cfg,
+		db:             db,
+		jwtUtil:        jwtUtil,
+		redisHelper:    redisHelper,
+		storage:        storageService,
+		twilio:         twilioClient,
+		config:         cfg,
+		passwordPolicy: utils.PasswordPolicyFromConfig(cfg.Users),
 	}
 }
 
 // CreateUser creates a new user
-func (s *UserService) CreateUser(req *RegisterRequest) (*models.User, error) {
+func (s *UserService) CreateUser(ctx context.Context, req *RegisterRequest) (*models.User, error) {
 	// Validate email
 	if !utils.IsEmailValid(req.Email) {
 		return nil, fmt.Errorf("invalid email address")
 	}
 
 	// Validate password
-	valid, msg := utils.IsPasswordValid(req.Password)
-	if !valid {
-		return nil, fmt.Errorf(msg)
+	if err := s.passwordPolicy.Validate(ctx, req.Password); err != nil {
+		return nil, err
 	}
 
 	// Hash password
@@ -52,7 +102,7 @@ func (s *UserService) CreateUser(req *RegisterRequest) (*models.User, error) {
 	}
 
 	// Check if email already exists
-	exists, err := s.emailExists(req.Email)
+	exists, err := s.emailExists(ctx, req.Email)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check email: %w", err)
 	}
@@ -71,8 +121,8 @@ func (s *UserService) CreateUser(req *RegisterRequest) (*models.User, error) {
 		Status:        "active",
 		EmailVerified: false,
 		PhoneVerified: false,
-		CreatedAt:     time.Now().UTC(),
-		UpdatedAt:     time.Now().UTC(),
+		CreatedAt:     clients.Now(),
+		UpdatedAt:     clients.Now(),
 	}
 
 	query := `
@@ -81,31 +131,36 @@ func (s *UserService) CreateUser(req *RegisterRequest) (*models.User, error) {
 		RETURNING id, email, first_name, last_name, role, status, email_verified, phone_verified, created_at, updated_at
 	`
 
-	err = s.db.QueryRow(
-		query,
-		user.ID, user.Email, user.PasswordHash, user.FirstName, user.LastName,
-		user.Role, user.Status, user.EmailVerified, user.PhoneVerified, user.CreatedAt, user.UpdatedAt,
-	).Scan(
-		&user.ID, &user.Email, &user.FirstName, &user.LastName,
-		&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified, &user.CreatedAt, &user.UpdatedAt,
-	)
+	// The user and profile are created together so a failure never leaves a user without a profile
+	err = s.db.WithTransactionContext(ctx, func(tx *sql.Tx) error {
+		err := tx.QueryRow(
+			query,
+			user.ID, user.Email, user.PasswordHash, user.FirstName, user.LastName,
+			user.Role, user.Status, user.EmailVerified, user.PhoneVerified, user.CreatedAt, user.UpdatedAt,
+		).Scan(
+			&user.ID, &user.Email, &user.FirstName, &user.LastName,
+			&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified, &user.CreatedAt, &user.UpdatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
 
+		if err := s.createUserProfile(tx, user.ID); err != nil {
+			return fmt.Errorf("failed to create user profile: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
-	}
-
-	// Create user profile
-	if err := s.createUserProfile(user.ID); err != nil {
-		return nil, fmt.Errorf("failed to create user profile: %w", err)
+		return nil, err
 	}
 
 	return user, nil
 }
 
 // AuthenticateUser authenticates a user and returns tokens
-func (s *UserService) AuthenticateUser(email, password string) (*LoginResponse, error) {
+func (s *UserService) AuthenticateUser(ctx context.Context, email, password string, client SessionClient) (*LoginResponse, error) {
 	// Get user by email
-	user, err := s.getUserByEmail(email)
+	user, err := s.getUserByEmail(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("invalid credentials")
 	}
@@ -120,8 +175,27 @@ func (s *UserService) AuthenticateUser(email, password string) (*LoginResponse,
 		return nil, fmt.Errorf("invalid credentials")
 	}
 
+	// With 2FA on, the password only earns a challenge to be completed at /users/login/2fa
+	twoFactor, err := s.twoFactorEnabled(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to check 2FA for user %s: %v", user.ID, err)
+		return nil, fmt.Errorf("authentication failed")
+	}
+	if twoFactor {
+		return s.createTwoFactorChallenge(user.ID)
+	}
+
+	return s.issueTokens(ctx, user, client)
+}
+
+// issueTokens starts a login session for a user who has signed in and creates an
+// access and refresh token pair bound to it
+func (s *UserService) issueTokens(ctx context.Context, user *models.User, client SessionClient) (*LoginResponse, error) {
+	sessionID := uuid.New().String()
+
 	// Generate tokens
-	accessToken, _, err := s.jwtUtil.GenerateAccessToken(
+	accessToken, _, err := s.jwtUtil.GenerateSessionAccessToken(
+		sessionID,
 		user.ID,
 		"web", // default client
 		user.Role,
@@ -132,7 +206,8 @@ func (s *UserService) AuthenticateUser(email, password string) (*LoginResponse,
 		return nil, fmt.Errorf("failed to generate access token: %w", err)
 	}
 
-	refreshToken, refreshTokenID, err := s.jwtUtil.GenerateRefreshToken(
+	refreshToken, refreshTokenID, err := s.jwtUtil.GenerateSessionRefreshToken(
+		sessionID,
 		user.ID,
 		"web",
 		s.config.OAuth.RefreshTokenExpiry,
@@ -141,8 +216,19 @@ func (s *UserService) AuthenticateUser(email, password string) (*LoginResponse,
 		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
 	}
 
+	// The session outlives access tokens so refreshing keeps it alive
+	sessionData := map[string]interface{}{
+		"ip_address":       client.IPAddress,
+		"user_agent":       client.UserAgent,
+		"device":           client.Device,
+		"refresh_token_id": refreshTokenID,
+	}
+	if err := s.redisHelper.SaveSession(user.ID, sessionID, sessionData, s.config.OAuth.RefreshTokenExpiry); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
 	// Update last login
-	s.updateLastLogin(user.ID)
+	s.updateLastLogin(ctx, user.ID)
 
 	// Store refresh token
 	s.storeRefreshToken(user.ID, refreshTokenID, s.config.OAuth.RefreshTokenExpiry)
@@ -157,7 +243,7 @@ func (s *UserService) AuthenticateUser(email, password string) (*LoginResponse,
 }
 
 // GetUserByID retrieves a user by ID
-func (s *UserService) GetUserByID(userID string) (*models.User, error) {
+func (s *UserService) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
 	query := `
 		SELECT id, email, first_name, last_name, phone, avatar, role, status,
 		       email_verified, phone_verified, last_login_at, created_at, updated_at, deleted_at
@@ -166,7 +252,7 @@ func (s *UserService) GetUserByID(userID string) (*models.User, error) {
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(query, userID).Scan(
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Avatar,
 		&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified,
 		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
@@ -183,19 +269,21 @@ func (s *UserService) GetUserByID(userID string) (*models.User, error) {
 }
 
 // UpdateUser updates user information
-func (s *UserService) UpdateUser(userID string, req *UpdateProfileRequest) (*models.User, error) {
+func (s *UserService) UpdateUser(ctx context.Context, userID string, req *UpdateProfileRequest) (*models.User, error) {
 	query := `
 		UPDATE users
-		SET first_name = $1, last_name = $2, phone = $3, updated_at = $4
+		SET first_name = $1, last_name = $2, phone = $3, updated_at = $4,
+		    phone_verified = phone_verified AND phone IS NOT DISTINCT FROM $3
 		WHERE id = $5 AND deleted_at IS NULL
 		RETURNING id, email, first_name, last_name, phone, avatar, role, status,
 		          email_verified, phone_verified, last_login_at, created_at, updated_at
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(
+	err := s.db.QueryRowContext(
+		ctx,
 		query,
-		req.FirstName, req.LastName, req.Phone, time.Now().UTC(), userID,
+		req.FirstName, req.LastName, req.Phone, clients.Now(), userID,
 	).Scan(
 		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Avatar,
 		&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified,
@@ -215,10 +303,253 @@ func (s *UserService) UpdateUser(userID string, req *UpdateProfileRequest) (*mod
 	return user, nil
 }
 
+// GetUserProfile retrieves the extended profile of an active user
+func (s *UserService) GetUserProfile(ctx context.Context, userID string) (*models.UserProfile, error) {
+	query := `
+		SELECT p.user_id, p.bio, p.date_of_birth, p.gender, p.address, p.city, p.state,
+		       p.country, p.zip_code, p.created_at, p.updated_at
+		FROM user_profiles p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.user_id = $1 AND u.deleted_at IS NULL
+	`
+
+	profile := &models.UserProfile{}
+	err := s.db.QueryRowContext(ctx, query, userID).Scan(
+		&profile.UserID, &profile.Bio, &profile.DateOfBirth, &profile.Gender, &profile.Address,
+		&profile.City, &profile.State, &profile.Country, &profile.ZipCode,
+		&profile.CreatedAt, &profile.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// UpdateUserProfile replaces the extended profile of an active user, creating the
+// row if the account predates profiles being created at registration
+func (s *UserService) UpdateUserProfile(ctx context.Context, userID string, req *UpdateUserProfileRequest) (*models.UserProfile, error) {
+	var dateOfBirth sql.NullTime
+	if req.DateOfBirth != "" {
+		dob, err := time.Parse(dateOfBirthLayout, req.DateOfBirth)
+		if err != nil || !dob.Before(time.Now().UTC().Truncate(24*time.Hour)) {
+			return nil, ErrInvalidDateOfBirth
+		}
+		dateOfBirth = sql.NullTime{Time: dob, Valid: true}
+	}
+
+	query := `
+		INSERT INTO user_profiles (user_id, bio, date_of_birth, gender, address, city, state, country, zip_code, created_at, updated_at)
+		SELECT id, $2, $3, $4, $5, $6, $7, $8, $9, $10, $10
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (user_id) DO UPDATE SET
+			bio = EXCLUDED.bio, date_of_birth = EXCLUDED.date_of_birth, gender = EXCLUDED.gender,
+			address = EXCLUDED.address, city = EXCLUDED.city, state = EXCLUDED.state,
+			country = EXCLUDED.country, zip_code = EXCLUDED.zip_code, updated_at = EXCLUDED.updated_at
+		RETURNING user_id, bio, date_of_birth, gender, address, city, state,
+		          country, zip_code, created_at, updated_at
+	`
+
+	profile := &models.UserProfile{}
+	err := s.db.QueryRowContext(
+		ctx,
+		query,
+		userID,
+		sql.NullString{String: req.Bio, Valid: req.Bio != ""},
+		dateOfBirth,
+		sql.NullString{String: req.Gender, Valid: req.Gender != ""},
+		sql.NullString{String: req.Address, Valid: req.Address != ""},
+		sql.NullString{String: req.City, Valid: req.City != ""},
+		sql.NullString{String: req.State, Valid: req.State != ""},
+		sql.NullString{String: req.Country, Valid: req.Country != ""},
+		sql.NullString{String: req.ZipCode, Valid: req.ZipCode != ""},
+		clients.Now(),
+	).Scan(
+		&profile.UserID, &profile.Bio, &profile.DateOfBirth, &profile.Gender, &profile.Address,
+		&profile.City, &profile.State, &profile.Country, &profile.ZipCode,
+		&profile.CreatedAt, &profile.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user profile: %w", err)
+	}
+
+	return profile, nil
+}
+
+// SetAvatar stores an uploaded image as the user's avatar and points users.avatar at
+// its download URL. Earlier avatar files are soft deleted once the user is updated.
+func (s *UserService) SetAvatar(ctx context.Context, userID string, file *multipart.FileHeader, baseURL string) (*models.User, error) {
+	uploaded, err := s.storage.UploadAvatar(file, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE users
+		SET avatar = $1, updated_at = $2
+		WHERE id = $3 AND deleted_at IS NULL
+		RETURNING id, email, first_name, last_name, phone, avatar, role, status,
+		          email_verified, phone_verified, last_login_at, created_at, updated_at
+	`
+
+	user := &models.User{}
+	err = s.db.QueryRowContext(ctx, query, storage.DownloadURL(baseURL, uploaded.ID), clients.Now(), userID).Scan(
+		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Avatar,
+		&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified,
+		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+	if err != nil {
+		// Don't leave an unreferenced avatar behind
+		if cleanupErr := s.storage.HardDeleteFile(uploaded.ID); cleanupErr != nil {
+			log.Printf("Failed to remove avatar %s after failed update: %v", uploaded.ID, cleanupErr)
+		}
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	if err := s.storage.DeleteAvatarsExcept(userID, uploaded.ID); err != nil {
+		log.Printf("Failed to clean up previous avatars for user %s: %v", userID, err)
+	}
+
+	s.redisHelper.CacheDelete(fmt.Sprintf("user:%s", userID))
+
+	return user, nil
+}
+
+// SendPhoneVerificationCode texts a one-time code to the user's phone number. Sending a
+// new code replaces any pending one.
+func (s *UserService) SendPhoneVerificationCode(ctx context.Context, userID string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.PhoneVerified {
+		return ErrPhoneAlreadyVerified
+	}
+	if !user.Phone.Valid || !utils.IsPhoneValid(user.Phone.String) {
+		return ErrPhoneMissing
+	}
+
+	code, err := utils.RandomDigits(phoneCodeLength)
+	if err != nil {
+		return fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	key := phoneCodeKey(userID)
+	pending := phoneVerification{ID: uuid.New().String(), Phone: user.Phone.String, Code: code}
+	if err := s.redisHelper.CacheSet(key, pending, phoneCodeTTL); err != nil {
+		return fmt.Errorf("failed to store verification code: %w", err)
+	}
+
+	if err := s.twilio.SendVerificationCode(ctx, user.Phone.String, code); err != nil {
+		s.redisHelper.CacheDelete(key)
+		return fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyPhone checks a code sent by SendPhoneVerificationCode and marks the phone number
+// verified. Each code allows a limited number of guesses.
+func (s *UserService) VerifyPhone(ctx context.Context, userID, code string) error {
+	key := phoneCodeKey(userID)
+
+	var pending phoneVerification
+	if err := s.redisHelper.CacheGet(key, &pending); err != nil {
+		return ErrInvalidPhoneCode
+	}
+
+	attempts, err := s.redisHelper.IncrementCounter(fmt.Sprintf("phone_code_attempts:%s", pending.ID), phoneCodeTTL)
+	if err != nil {
+		return fmt.Errorf("failed to record verification attempt: %w", err)
+	}
+	if attempts > phoneCodeMaxAttempts {
+		s.redisHelper.CacheDelete(key)
+		return ErrInvalidPhoneCode
+	}
+	if subtle.ConstantTimeCompare([]byte(code), []byte(pending.Code)) != 1 {
+		return ErrInvalidPhoneCode
+	}
+
+	// Only verify the number the code was sent to, in case the phone changed since
+	query := `
+		UPDATE users
+		SET phone_verified = true, updated_at = $1
+		WHERE id = $2 AND phone = $3 AND deleted_at IS NULL
+	`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), userID, pending.Phone)
+	if err != nil {
+		return fmt.Errorf("failed to verify phone: %w", err)
+	}
+
+	s.redisHelper.CacheDelete(key)
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrInvalidPhoneCode
+	}
+
+	s.redisHelper.CacheDelete(fmt.Sprintf("user:%s", userID))
+
+	return nil
+}
+
+// ListSessions returns the user's active login sessions, newest first, flagging the
+// one the request was made from
+func (s *UserService) ListSessions(userID, currentSessionID string) ([]*SessionResponse, error) {
+	sessions, err := s.redisHelper.ListUserSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		resp := &SessionResponse{
+			ID:        sessionString(session, "id"),
+			Device:    sessionString(session, "device"),
+			IPAddress: sessionString(session, "ip_address"),
+			UserAgent: sessionString(session, "user_agent"),
+		}
+		if createdAt, ok := session["created_at"].(float64); ok {
+			resp.CreatedAt = time.Unix(int64(createdAt), 0).UTC()
+		}
+		resp.Current = resp.ID == currentSessionID
+		result = append(result, resp)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return result, nil
+}
+
+// RevokeSession ends one of the user's sessions. Tokens issued for it stop working immediately.
+func (s *UserService) RevokeSession(userID, sessionID string) error {
+	session, err := s.redisHelper.GetSession(sessionID)
+	if err != nil || sessionString(session, "user_id") != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.redisHelper.DeleteSession(sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
 // ChangePassword changes user password
-func (s *UserService) ChangePassword(userID, oldPassword, newPassword string) error {
+func (s *UserService) ChangePassword(ctx context.Context, userID, oldPassword, newPassword string) error {
 	// Get user
-	user, err := s.GetUserByID(userID)
+	user, err := s.GetUserByID(ctx, userID)
 	if err != nil {
 		return err
 	}
@@ -229,9 +560,8 @@ func (s *UserService) ChangePassword(userID, oldPassword, newPassword string) er
 	}
 
 	// Validate new password
-	valid, msg := utils.IsPasswordValid(newPassword)
-	if !valid {
-		return fmt.Errorf(msg)
+	if err := s.passwordPolicy.Validate(ctx, newPassword); err != nil {
+		return err
 	}
 
 	// Hash new password
@@ -242,7 +572,7 @@ func (s *UserService) ChangePassword(userID, oldPassword, newPassword string) er
 
 	// Update password
 	query := `UPDATE users SET password_hash = $1, updated_at = $2 WHERE id = $3`
-	_, err = s.db.Exec(query, hashedPassword, time.Now().UTC(), userID)
+	_, err = s.db.ExecContext(ctx, query, hashedPassword, clients.Now(), userID)
 	if err != nil {
 		return fmt.Errorf("failed to update password: %w", err)
 	}
@@ -254,9 +584,9 @@ func (s *UserService) ChangePassword(userID, oldPassword, newPassword string) er
 }
 
 // DeleteUser soft deletes a user
-func (s *UserService) DeleteUser(userID string) error {
+func (s *UserService) DeleteUser(ctx context.Context, userID string) error {
 	query := `UPDATE users SET deleted_at = $1 WHERE id = $2 AND deleted_at IS NULL`
-	result, err := s.db.Exec(query, time.Now().UTC(), userID)
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), userID)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
@@ -275,36 +605,160 @@ func (s *UserService) DeleteUser(userID string) error {
 	return nil
 }
 
-// ListUsers lists all users with pagination
-func (s *UserService) ListUsers(page, limit int) ([]*models.User, int, error) {
-	if page < 1 {
-		page = 1
+// RestoreUser undoes a soft delete made within the configured restore window. It is
+// rejected if another active account has registered the same email since, which can
+// only happen when ReserveDeletedEmails is off.
+func (s *UserService) RestoreUser(ctx context.Context, userID string) (*models.User, error) {
+	var email string
+	var deletedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, `SELECT email, deleted_at FROM users WHERE id = $1`, userID).Scan(&email, &deletedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if !deletedAt.Valid {
+		return nil, ErrUserNotDeleted
+	}
+	if time.Since(deletedAt.Time) > s.config.Users.RestoreWindow {
+		return nil, fmt.Errorf("%w (deleted at %s)", ErrRestoreWindowExpired, deletedAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	var reused bool
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND id <> $2 AND deleted_at IS NULL)`
+	if err := s.db.QueryRowContext(ctx, query, email, userID).Scan(&reused); err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if reused {
+		return nil, fmt.Errorf("%w since the user was deleted at %s", ErrEmailReused, deletedAt.Time.UTC().Format(time.RFC3339))
+	}
+
+	query = `
+		UPDATE users
+		SET deleted_at = NULL, updated_at = $1
+		WHERE id = $2 AND deleted_at IS NOT NULL
+		RETURNING id, email, first_name, last_name, phone, avatar, role, status,
+		          email_verified, phone_verified, last_login_at, created_at, updated_at
+	`
+
+	user := &models.User{}
+	err = s.db.QueryRowContext(ctx, query, clients.Now(), userID).Scan(
+		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Avatar,
+		&user.Role, &user.Status, &user.EmailVerified, &user.PhoneVerified,
+		&user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt,
+	)
+
+	// Another request restored the user between the checks and the update
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotDeleted
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	s.redisHelper.CacheDelete(fmt.Sprintf("user:%s", userID))
+
+	return user, nil
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the cutoff, together
+// with their stored files. Users who still own OAuth clients are kept because the
+// clients reference them, and a user whose files could not all be removed is retried
+// on the next run rather than leaving orphaned objects behind.
+func (s *UserService) PurgeDeletedUsers(ctx context.Context, cutoff time.Time) (int, error) {
+	query := `
+		SELECT id FROM users
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1
+		  AND NOT EXISTS (SELECT 1 FROM oauth_clients WHERE created_by = users.id)
+	`
+	rows, err := s.db.QueryContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list deleted users: %w", err)
+	}
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan user: %w", err)
+		}
+		ids = append(ids, id)
 	}
-	if limit < 1 || limit > 100 {
-		limit = 20
+	rows.Close()
+
+	purged := 0
+	for _, id := range ids {
+		if _, err := s.storage.PurgeUserFiles(id); err != nil {
+			log.Printf("Failed to purge files of user %s: %v", id, err)
+			continue
+		}
+
+		var remaining bool
+		if err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM files WHERE user_id = $1)`, id).Scan(&remaining); err != nil || remaining {
+			log.Printf("Skipping purge of user %s until all of their files are removed", id)
+			continue
+		}
+
+		// Rows owned by the user cascade or have their reference cleared
+		result, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = $1 AND deleted_at IS NOT NULL AND deleted_at < $2`, id, cutoff)
+		if err != nil {
+			log.Printf("Failed to purge user %s: %v", id, err)
+			continue
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			purged++
+		}
 	}
 
-	offset := (page - 1) * limit
+	return purged, nil
+}
+
+// adminUserListSpec whitelists the filter[...] and sort fields of the admin user list
+var adminUserListSpec = response.ListSpec{
+	Filters: map[string]response.FilterField{
+		"role":           {Column: "role"},
+		"status":         {Column: "status"},
+		"email_verified": {Column: "email_verified", Type: response.FilterBool},
+		"phone_verified": {Column: "phone_verified", Type: response.FilterBool},
+	},
+	Sorts: map[string]string{
+		"created_at":    "created_at",
+		"updated_at":    "updated_at",
+		"last_login_at": "last_login_at",
+		"email":         "email",
+		"first_name":    "first_name",
+		"last_name":     "last_name",
+	},
+	DefaultSort: "-created_at",
+}
+
+// ListUsers lists all users with pagination, filtered and sorted by listQuery
+func (s *UserService) ListUsers(ctx context.Context, listQuery response.ListQuery, pagination response.Pagination) ([]*models.User, int, error) {
+	filterClause, args := listQuery.Where(nil)
 
 	// Get total count
 	var total int
-	countQuery := `SELECT COUNT(*) FROM users WHERE deleted_at IS NULL`
-	err := s.db.QueryRow(countQuery).Scan(&total)
+	countQuery := `SELECT COUNT(*) FROM users WHERE deleted_at IS NULL` + filterClause
+	err := s.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	// Get users
-	query := `
+	query := fmt.Sprintf(`
 		SELECT id, email, first_name, last_name, phone, avatar, role, status,
 		       email_verified, phone_verified, last_login_at, created_at, updated_at
 		FROM users
-		WHERE deleted_at IS NULL
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+		WHERE deleted_at IS NULL%s
+		ORDER BY %s
+		LIMIT $%d OFFSET $%d
+	`, filterClause, listQuery.OrderBy(), len(args)+1, len(args)+2)
+	args = append(args, pagination.Limit, pagination.Offset)
 
-	rows, err := s.db.Query(query, limit, offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, fmt.Errorf("failed to list users: %w", err)
 	}
@@ -329,14 +783,19 @@ func (s *UserService) ListUsers(page, limit int) ([]*models.User, int, error) {
 
 // Helper methods
 
-func (s *UserService) emailExists(email string) (bool, error) {
+// emailExists reports whether an active account uses email. With ReserveDeletedEmails
+// on, soft-deleted accounts count too so their email stays reserved for a restore.
+func (s *UserService) emailExists(ctx context.Context, email string) (bool, error) {
 	var exists bool
 	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1 AND deleted_at IS NULL)`
-	err := s.db.QueryRow(query, email).Scan(&exists)
+	if s.config.Users.ReserveDeletedEmails {
+		query = `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
+	}
+	err := s.db.QueryRowContext(ctx, query, email).Scan(&exists)
 	return exists, err
 }
 
-func (s *UserService) getUserByEmail(email string) (*models.User, error) {
+func (s *UserService) getUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
 		SELECT id, email, password_hash, first_name, last_name, phone, avatar, role, status,
 		       email_verified, phone_verified, last_login_at, created_at, updated_at, deleted_at
@@ -345,7 +804,7 @@ func (s *UserService) getUserByEmail(email string) (*models.User, error) {
 	`
 
 	user := &models.User{}
-	err := s.db.QueryRow(query, email).Scan(
+	err := s.db.QueryRowContext(ctx, query, email).Scan(
 		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName,
 		&user.Phone, &user.Avatar, &user.Role, &user.Status, &user.EmailVerified,
 		&user.PhoneVerified, &user.LastLoginAt, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
@@ -361,15 +820,25 @@ func (s *UserService) getUserByEmail(email string) (*models.User, error) {
 	return user, nil
 }
 
-func (s *UserService) createUserProfile(userID string) error {
+func (s *UserService) createUserProfile(tx *sql.Tx, userID string) error {
 	query := `INSERT INTO user_profiles (user_id, created_at, updated_at) VALUES ($1, $2, $3)`
-	_, err := s.db.Exec(query, userID, time.Now().UTC(), time.Now().UTC())
+	_, err := tx.Exec(query, userID, clients.Now(), clients.Now())
 	return err
 }
 
-func (s *UserService) updateLastLogin(userID string) {
+// sessionString reads a string field from decoded session data
+func sessionString(session map[string]interface{}, key string) string {
+	value, _ := session[key].(string)
+	return value
+}
+
+func phoneCodeKey(userID string) string {
+	return fmt.Sprintf("phone_code:%s", userID)
+}
+
+func (s *UserService) updateLastLogin(ctx context.Context, userID string) {
 	query := `UPDATE users SET last_login_at = $1 WHERE id = $2`
-	s.db.Exec(query, time.Now().UTC(), userID)
+	s.db.ExecContext(ctx, query, clients.Now(), userID)
 }
 
 func (s *UserService) storeRefreshToken(userID, tokenID string, expiry time.Duration) {
@@ -377,6 +846,25 @@ func (s *UserService) storeRefreshToken(userID, tokenID string, expiry time.Dura
 	s.redisHelper.CacheSet(key, map[string]string{"user_id": userID}, expiry)
 }
 
+func (s *UserService) toProfileResponse(profile *models.UserProfile) *UserProfileResponse {
+	resp := &UserProfileResponse{
+		UserID:    profile.UserID,
+		Bio:       profile.Bio.String,
+		Gender:    profile.Gender.String,
+		Address:   profile.Address.String,
+		City:      profile.City.String,
+		State:     profile.State.String,
+		Country:   profile.Country.String,
+		ZipCode:   profile.ZipCode.String,
+		CreatedAt: profile.CreatedAt,
+		UpdatedAt: profile.UpdatedAt,
+	}
+	if profile.DateOfBirth.Valid {
+		resp.DateOfBirth = profile.DateOfBirth.Time.Format(dateOfBirthLayout)
+	}
+	return resp
+}
+
 func (s *UserService) sanitizeUser(user *models.User) *UserResponse {
 	return &UserResponse{
 		ID:            user.ID,
@@ -0,0 +1,67 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gogin/internal/config"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/testutil"
+)
+
+func TestReserveDeletedEmails(t *testing.T) {
+	tests := []struct {
+		name        string
+		reserve     bool
+		wantCreated bool
+	}{
+		{"reserved", true, false},
+		{"released", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := testutil.Database(t)
+			redisHelper := redishelper.NewRedisHelper(testutil.Redis(t))
+			ctx := context.Background()
+
+			deletedID := testutil.CreateUser(t, db, "user")
+			email := deletedID + "@example.com"
+			if _, err := db.Exec(`UPDATE users SET deleted_at = NOW() WHERE id = $1`, deletedID); err != nil {
+				t.Fatalf("soft delete user: %v", err)
+			}
+
+			cfg := &config.Config{Users: config.UsersConfig{
+				PasswordMinLength:    8,
+				RestoreWindow:        time.Hour,
+				ReserveDeletedEmails: tt.reserve,
+			}}
+			service := NewUserService(db, nil, redisHelper, nil, nil, cfg)
+
+			user, err := service.CreateUser(ctx, &RegisterRequest{
+				Email:     email,
+				Password:  "Str0ng!Passw0rd",
+				FirstName: "New",
+				LastName:  "User",
+			})
+			if user != nil {
+				t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, user.ID) })
+			}
+			if created := err == nil; created != tt.wantCreated {
+				t.Fatalf("CreateUser() error = %v, want created %v", err, tt.wantCreated)
+			}
+
+			// A released email blocks restoring the old account; a reserved one does not
+			_, err = service.RestoreUser(ctx, deletedID)
+			if tt.wantCreated {
+				if !errors.Is(err, ErrEmailReused) {
+					t.Errorf("RestoreUser() error = %v, want %v", err, ErrEmailReused)
+				}
+			} else if err != nil {
+				t.Errorf("RestoreUser() error = %v, want nil", err)
+			}
+		})
+	}
+}
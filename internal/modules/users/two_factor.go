@@ -0,0 +1,300 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gogin/internal/clients"
+	"gogin/internal/response"
+	"gogin/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// Errors returned by the two-factor authentication flow; match them with errors.Is
+var (
+	ErrTwoFactorAlreadyEnabled = response.ConflictError("two-factor authentication is already enabled")
+	ErrTwoFactorNotSetUp       = response.InvalidError("two-factor authentication has not been set up")
+	ErrInvalidTwoFactorCode    = response.InvalidError("invalid two-factor authentication code")
+	ErrInvalidChallenge        = response.InvalidError("invalid or expired two-factor challenge")
+)
+
+const (
+	twoFactorChallengeTTL         = 5 * time.Minute
+	twoFactorChallengeMaxAttempts = 5
+	recoveryCodeCount             = 10
+)
+
+// twoFactorChallenge is a pending login waiting for a second factor
+type twoFactorChallenge struct {
+	UserID string `json:"user_id"`
+}
+
+// EnableTwoFactor generates a new TOTP secret for the user. It stays inactive until
+// confirmed with ConfirmTwoFactor, and calling this again replaces an unconfirmed secret.
+func (s *UserService) EnableTwoFactor(ctx context.Context, userID string) (*TwoFactorSetupResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	enabled, err := s.twoFactorEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	encrypted, err := utils.EncryptString(s.config.App.EncryptionKey, secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_two_factor (user_id, secret, enabled, created_at, updated_at)
+		VALUES ($1, $2, false, $3, $3)
+		ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = 0, updated_at = EXCLUDED.updated_at
+		WHERE user_two_factor.enabled = false
+	`
+	if _, err := s.db.ExecContext(ctx, query, userID, encrypted, clients.Now()); err != nil {
+		return nil, fmt.Errorf("failed to store secret: %w", err)
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.config.App.Name, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor activates 2FA once the user proves their authenticator works, and
+// returns a fresh set of recovery codes. Only their hashes are stored.
+func (s *UserService) ConfirmTwoFactor(ctx context.Context, userID, code string) (*TwoFactorConfirmResponse, error) {
+	tf, err := s.getTwoFactorSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf.enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	step, valid := utils.ValidateTOTP(tf.secret, code, time.Now(), tf.lastUsedStep)
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+	}
+
+	err = s.db.WithTransactionContext(ctx, func(tx *sql.Tx) error {
+		now := clients.Now()
+		if _, err := tx.Exec(`UPDATE user_two_factor SET enabled = true, enabled_at = $1, last_used_step = $2, updated_at = $1 WHERE user_id = $3`, now, step, userID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		for _, recoveryCode := range codes {
+			if _, err := tx.Exec(`INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`,
+				userID, hashRecoveryCode(recoveryCode), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+
+	return &TwoFactorConfirmResponse{RecoveryCodes: codes}, nil
+}
+
+// CompleteTwoFactorLogin exchanges a login challenge and a TOTP or unused recovery code
+// for tokens. Each challenge allows a limited number of attempts.
+func (s *UserService) CompleteTwoFactorLogin(ctx context.Context, challengeToken, code string, client SessionClient) (*LoginResponse, error) {
+	key := twoFactorChallengeKey(challengeToken)
+
+	var challenge twoFactorChallenge
+	if err := s.redisHelper.CacheGet(key, &challenge); err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	attempts, err := s.redisHelper.IncrementCounter(fmt.Sprintf("2fa_challenge_attempts:%s", challengeToken), twoFactorChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record attempt: %w", err)
+	}
+	if attempts > twoFactorChallengeMaxAttempts {
+		s.redisHelper.CacheDelete(key)
+		return nil, ErrInvalidChallenge
+	}
+
+	user, err := s.GetUserByID(ctx, challenge.UserID)
+	if err != nil || !user.IsActive() {
+		s.redisHelper.CacheDelete(key)
+		return nil, ErrInvalidChallenge
+	}
+
+	tf, err := s.getTwoFactorSecret(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	valid := false
+	if step, ok := utils.ValidateTOTP(tf.secret, code, time.Now(), tf.lastUsedStep); ok {
+		valid, err = s.useTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !valid {
+		valid, err = s.useRecoveryCode(ctx, user.ID, code)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !valid {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	// Challenges are single use
+	s.redisHelper.CacheDelete(key)
+
+	return s.issueTokens(ctx, user, client)
+}
+
+// createTwoFactorChallenge records a login that passed the password check
+func (s *UserService) createTwoFactorChallenge(userID string) (*LoginResponse, error) {
+	token := uuid.New().String()
+	if err := s.redisHelper.CacheSet(twoFactorChallengeKey(token), twoFactorChallenge{UserID: userID}, twoFactorChallengeTTL); err != nil {
+		return nil, fmt.Errorf("failed to start two-factor login")
+	}
+
+	return &LoginResponse{
+		Requires2FA:    true,
+		ChallengeToken: token,
+		ExpiresIn:      int(twoFactorChallengeTTL.Seconds()),
+	}, nil
+}
+
+// twoFactorEnabled reports whether the user has confirmed 2FA
+func (s *UserService) twoFactorEnabled(ctx context.Context, userID string) (bool, error) {
+	var enabled bool
+	err := s.db.QueryRowContext(ctx, `SELECT enabled FROM user_two_factor WHERE user_id = $1`, userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check two-factor authentication: %w", err)
+	}
+	return enabled, nil
+}
+
+// twoFactorState is a user's decrypted TOTP secret and its replay guard
+type twoFactorState struct {
+	secret       string
+	enabled      bool
+	lastUsedStep int64
+}
+
+// getTwoFactorSecret loads and decrypts the user's TOTP secret. Secrets encrypted with
+// the JWT secret before ENCRYPTION_KEY existed are re-encrypted with the new key.
+func (s *UserService) getTwoFactorSecret(ctx context.Context, userID string) (*twoFactorState, error) {
+	var encrypted string
+	var tf twoFactorState
+	err := s.db.QueryRowContext(ctx, `SELECT secret, enabled, last_used_step FROM user_two_factor WHERE user_id = $1`, userID).Scan(&encrypted, &tf.enabled, &tf.lastUsedStep)
+	if err == sql.ErrNoRows {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get two-factor secret: %w", err)
+	}
+
+	secret, legacy, err := utils.DecryptStringWithFallback(s.config.App.EncryptionKey, s.config.OAuth.JWTSecret, encrypted)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt two-factor secret: %w", err)
+	}
+	tf.secret = secret
+
+	if legacy {
+		reencrypted, err := utils.EncryptString(s.config.App.EncryptionKey, secret)
+		if err == nil {
+			_, err = s.db.ExecContext(ctx, `UPDATE user_two_factor SET secret = $1 WHERE user_id = $2 AND secret = $3`, reencrypted, userID, encrypted)
+		}
+		if err != nil {
+			log.Printf("Warning: failed to re-encrypt two-factor secret for user %s: %v", userID, err)
+		}
+	}
+
+	return &tf, nil
+}
+
+// useTOTPStep records the time step of an accepted TOTP code. It reports false when a
+// concurrent login already used this step or a later one, so each code works only once.
+func (s *UserService) useTOTPStep(ctx context.Context, userID string, step int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE user_two_factor SET last_used_step = $1 WHERE user_id = $2 AND last_used_step < $1`,
+		step, userID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record two-factor code: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// useRecoveryCode marks a matching unused recovery code as used
+func (s *UserService) useRecoveryCode(ctx context.Context, userID, code string) (bool, error) {
+	query := `
+		UPDATE user_recovery_codes
+		SET used_at = $1
+		WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL
+	`
+	result, err := s.db.ExecContext(ctx, query, clients.Now(), userID, hashRecoveryCode(code))
+	if err != nil {
+		return false, fmt.Errorf("failed to check recovery code: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// generateRecoveryCodes returns random single-use codes formatted as xxxxx-xxxxx
+func generateRecoveryCodes() ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, 7)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(raw))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode hashes a recovery code for storage. The codes carry 50 bits of
+// randomness, so a fast hash is enough.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func twoFactorChallengeKey(token string) string {
+	return fmt.Sprintf("2fa_challenge:%s", token)
+}
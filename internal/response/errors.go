@@ -0,0 +1,80 @@
+package response
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Sentinel error kinds returned by services. Handlers map them to HTTP statuses
+// with HandleServiceError instead of matching on error strings.
+var (
+	ErrNotFound  = errors.New("not found")
+	ErrConflict  = errors.New("conflict")
+	ErrForbidden = errors.New("forbidden")
+	ErrInvalid   = errors.New("invalid")
+)
+
+// ServiceError is a service failure of a known kind with a message that is safe to show clients
+type ServiceError struct {
+	Kind    error
+	Message string
+}
+
+func (e *ServiceError) Error() string {
+	return e.Message
+}
+
+// Unwrap lets errors.Is match the error's kind
+func (e *ServiceError) Unwrap() error {
+	return e.Kind
+}
+
+// NotFoundError reports a missing resource
+func NotFoundError(message string) error {
+	return &ServiceError{Kind: ErrNotFound, Message: message}
+}
+
+// ConflictError reports a request that clashes with existing state
+func ConflictError(message string) error {
+	return &ServiceError{Kind: ErrConflict, Message: message}
+}
+
+// ForbiddenError reports an action the caller may not perform on the resource
+func ForbiddenError(message string) error {
+	return &ServiceError{Kind: ErrForbidden, Message: message}
+}
+
+// InvalidError reports input the service rejected
+func InvalidError(message string) error {
+	return &ServiceError{Kind: ErrInvalid, Message: message}
+}
+
+// HandleServiceError writes the response for an error returned by a service. Known
+// kinds map to their HTTP status and a stable code; anything else is logged and
+// reported as fallback so internal details never reach the client. Services may wrap
+// a ServiceError with %w to add client-safe context, which is kept in the message.
+func HandleServiceError(c *gin.Context, err error, fallback string) {
+	var serviceErr *ServiceError
+	if !errors.As(err, &serviceErr) {
+		log.Printf("[ERROR] %s %s: %v", c.Request.Method, c.FullPath(), err)
+		InternalError(c, fallback)
+		return
+	}
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		NotFound(c, err.Error())
+	case errors.Is(err, ErrConflict):
+		Error(c, http.StatusConflict, err.Error(), "CONFLICT")
+	case errors.Is(err, ErrForbidden):
+		Forbidden(c, err.Error())
+	case errors.Is(err, ErrInvalid):
+		BadRequest(c, err.Error())
+	default:
+		log.Printf("[ERROR] %s %s: %v", c.Request.Method, c.FullPath(), err)
+		InternalError(c, fallback)
+	}
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWrappedServiceErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	errTicketNotFound := NotFoundError("ticket not found")
+	tests := []struct {
+		name       string
+		sentinel   error
+		kind       error
+		wrapped    error
+		wantStatus int
+	}{
+		{"not found", errTicketNotFound, ErrNotFound, fmt.Errorf("%w: %s", errTicketNotFound, "abc"), http.StatusNotFound},
+		{"conflict", ConflictError("category already exists"), ErrConflict, nil, http.StatusConflict},
+		{"forbidden", ForbiddenError("access denied"), ErrForbidden, nil, http.StatusForbidden},
+		{"invalid", InvalidError("invalid status transition"), ErrInvalid, nil, http.StatusBadRequest},
+		{"not a service error", errors.New("connection refused"), nil, nil, http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wrapped := tt.wrapped
+			if wrapped == nil {
+				wrapped = fmt.Errorf("%w from open to closed", tt.sentinel)
+			}
+			// Wrapping twice, as a caller adding its own context would
+			wrapped = fmt.Errorf("update ticket: %w", wrapped)
+
+			if !errors.Is(wrapped, tt.sentinel) {
+				t.Errorf("errors.Is(%v, sentinel) = false", wrapped)
+			}
+			if tt.kind != nil && !errors.Is(wrapped, tt.kind) {
+				t.Errorf("errors.Is(%v, kind) = false", wrapped)
+			}
+
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			HandleServiceError(c, wrapped, "fallback")
+			if rec.Code != tt.wantStatus {
+				t.Errorf("HandleServiceError status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package response
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FilterType controls how filter values are validated before they reach the database
+type FilterType int
+
+const (
+	FilterText FilterType = iota
+	FilterUUID
+	FilterBool
+	FilterInt
+)
+
+// FilterField maps a public filter name to the column it restricts
+type FilterField struct {
+	Column string
+	Type   FilterType
+}
+
+// ListSpec whitelists the filter and sort fields a list endpoint accepts. Sorts maps
+// public sort names to columns or fixed SQL expressions; DefaultSort is a sort value such as "-created_at".
+type ListSpec struct {
+	Filters     map[string]FilterField
+	Sorts       map[string]string
+	DefaultSort string
+}
+
+// Filter restricts Column to one of Values
+type Filter struct {
+	Column string
+	Values []string
+}
+
+// ListQuery is a validated set of filters and a sort order for a list query
+type ListQuery struct {
+	Filters    []Filter
+	SortColumn string
+	SortDesc   bool
+	// CustomSort is set when the client asked for a sort other than the default
+	CustomSort bool
+}
+
+// ParseListQuery reads filter[field]=value and sort=[-]field from the query string.
+// A filter may list several comma-separated values to match any of them, and a "-"
+// sort prefix sorts descending. Fields missing from spec are rejected, so the
+// returned error message is safe to show clients.
+func ParseListQuery(c *gin.Context, spec ListSpec) (ListQuery, error) {
+	var query ListQuery
+
+	params := c.Request.URL.Query()
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	// Deterministic SQL for the same request
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		name := key[len("filter[") : len(key)-1]
+		field, ok := spec.Filters[name]
+		if !ok {
+			return ListQuery{}, fmt.Errorf("unknown filter field: %s", name)
+		}
+
+		var values []string
+		for _, raw := range params[key] {
+			for _, value := range strings.Split(raw, ",") {
+				value = strings.TrimSpace(value)
+				if value == "" {
+					continue
+				}
+				if !validFilterValue(field.Type, value) {
+					return ListQuery{}, fmt.Errorf("invalid value for filter %s: %s", name, value)
+				}
+				values = append(values, value)
+			}
+		}
+		if len(values) == 0 {
+			return ListQuery{}, fmt.Errorf("filter %s requires a value", name)
+		}
+
+		query.Filters = append(query.Filters, Filter{Column: field.Column, Values: values})
+	}
+
+	sortValue := c.Query("sort")
+	query.CustomSort = sortValue != "" && sortValue != spec.DefaultSort
+	if sortValue == "" {
+		sortValue = spec.DefaultSort
+	}
+
+	name := strings.TrimPrefix(strings.TrimPrefix(sortValue, "-"), "+")
+	column, ok := spec.Sorts[name]
+	if !ok {
+		return ListQuery{}, fmt.Errorf("unknown sort field: %s", name)
+	}
+	query.SortColumn = column
+	query.SortDesc = strings.HasPrefix(sortValue, "-")
+
+	return query, nil
+}
+
+// validFilterValue checks a filter value against the type of its column
+func validFilterValue(filterType FilterType, value string) bool {
+	switch filterType {
+	case FilterUUID:
+		_, err := uuid.Parse(value)
+		return err == nil
+	case FilterBool:
+		_, err := strconv.ParseBool(value)
+		return err == nil
+	case FilterInt:
+		_, err := strconv.Atoi(value)
+		return err == nil
+	default:
+		return true
+	}
+}
+
+// Where renders the filters as " AND ..." conditions. Placeholders continue after the
+// arguments already bound in args, and the returned slice has the filter values appended.
+func (q ListQuery) Where(args []interface{}) (string, []interface{}) {
+	var b strings.Builder
+	for _, filter := range q.Filters {
+		placeholders := make([]string, len(filter.Values))
+		for i, value := range filter.Values {
+			args = append(args, value)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		fmt.Fprintf(&b, " AND %s IN (%s)", filter.Column, strings.Join(placeholders, ", "))
+	}
+	return b.String(), args
+}
+
+// OrderBy renders the sort as an ORDER BY expression, breaking ties by id
+func (q ListQuery) OrderBy() string {
+	direction := "ASC"
+	if q.SortDesc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, id %s", q.SortColumn, direction, direction)
+}
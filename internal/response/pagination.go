@@ -0,0 +1,130 @@
+package response
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DefaultPageLimit is the page size used when none is requested
+	DefaultPageLimit = 20
+	// MaxPageLimit is the largest page size any list endpoint returns
+	MaxPageLimit = 100
+)
+
+// cursorTimeLayout matches the microsecond precision of PostgreSQL timestamps
+const cursorTimeLayout = "2006-01-02 15:04:05.999999"
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Pagination holds validated page parameters for list queries. When Cursor is
+// set, lists that support it page by keyset instead of Offset.
+type Pagination struct {
+	Page   int
+	Limit  int
+	Offset int
+	Cursor *Cursor
+}
+
+// Cursor marks the last item of a page in (created_at, id) descending order
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// PaginationMeta describes a page of results; embed it in list responses.
+// Page and TotalPages are omitted for cursor-paginated requests.
+type PaginationMeta struct {
+	Total      int    `json:"total"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalPages int    `json:"total_pages,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ParsePagination reads page and limit from the query string. Invalid or
+// missing values fall back to page 1 and DefaultPageLimit, and limit is
+// capped at MaxPageLimit.
+func ParsePagination(c *gin.Context) Pagination {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = DefaultPageLimit
+	}
+	if limit > MaxPageLimit {
+		limit = MaxPageLimit
+	}
+
+	return Pagination{
+		Page:   page,
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+}
+
+// ParseCursor reads the opaque cursor query parameter, returning nil when absent
+func ParseCursor(c *gin.Context) (*Cursor, error) {
+	value := c.Query("cursor")
+	if value == "" {
+		return nil, nil
+	}
+	return DecodeCursor(value)
+}
+
+// EncodeCursor builds the opaque cursor pointing after the given item
+func EncodeCursor(createdAt time.Time, id string) string {
+	raw := createdAt.UTC().Format(cursorTimeLayout) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeCursor parses a cursor produced by EncodeCursor
+func DecodeCursor(value string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found || id == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	parsed, err := time.Parse(cursorTimeLayout, createdAt)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: parsed, ID: id}, nil
+}
+
+// CreatedAtParam formats the cursor time for comparison against a TIMESTAMP column
+func (c *Cursor) CreatedAtParam() string {
+	return c.CreatedAt.Format(cursorTimeLayout)
+}
+
+// Meta builds the pagination metadata for a result set of total items
+func (p Pagination) Meta(total int) PaginationMeta {
+	if p.Cursor != nil {
+		return PaginationMeta{
+			Total: total,
+			Limit: p.Limit,
+		}
+	}
+
+	return PaginationMeta{
+		Total:      total,
+		Page:       p.Page,
+		Limit:      p.Limit,
+		TotalPages: (total + p.Limit - 1) / p.Limit,
+	}
+}
@@ -96,6 +96,11 @@ func BadRequest(c *gin.Context, message string) {
 	Error(c, http.StatusBadRequest, message, "BAD_REQUEST")
 }
 
+// PayloadTooLarge sends a request entity too large response
+func PayloadTooLarge(c *gin.Context, message string) {
+	Error(c, http.StatusRequestEntityTooLarge, message, "PAYLOAD_TOO_LARGE")
+}
+
 // TooManyRequests sends a rate limit exceeded response
 func TooManyRequests(c *gin.Context, message string) {
 	Error(c, http.StatusTooManyRequests, message, "RATE_LIMIT_EXCEEDED")
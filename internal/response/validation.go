@@ -0,0 +1,103 @@
+package response
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// BindError responds to a failed request binding: 413 when the body ran past the
+// request's size limit, otherwise 422 with the translated validation errors
+func BindError(c *gin.Context, err error) {
+	if BodyTooLarge(c, err) {
+		return
+	}
+	ValidationError(c, TranslateValidationErrors(err))
+}
+
+// BodyTooLarge responds with 413 and returns true when err came from reading a body
+// without a declared length past its size limit
+func BodyTooLarge(c *gin.Context, err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		return false
+	}
+	PayloadTooLarge(c, fmt.Sprintf("Request body exceeds maximum allowed size of %d bytes", maxBytesErr.Limit))
+	return true
+}
+
+// TranslateValidationErrors converts a request binding error into field-level errors
+// with consistent messages. Errors that are not validation failures (malformed JSON,
+// wrong types) become a single BAD_REQUEST entry without echoing parser internals.
+func TranslateValidationErrors(err error) []ResponseError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return []ResponseError{NewError("BAD_REQUEST", "Invalid request body", "")}
+	}
+
+	result := make([]ResponseError, 0, len(validationErrors))
+	for _, e := range validationErrors {
+		field := toSnakeCase(e.Field())
+		result = append(result, NewError("VALIDATION_ERROR", validationMessage(field, e), field))
+	}
+	return result
+}
+
+// validationMessage describes a single failed validation tag
+func validationMessage(field string, e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return field + " is required"
+	case "email":
+		return field + " must be a valid email address"
+	case "uuid", "uuid4":
+		return field + " must be a valid UUID"
+	case "url":
+		return field + " must be a valid URL"
+	case "oneof":
+		return field + " must be one of: " + strings.ReplaceAll(e.Param(), " ", ", ")
+	case "min", "gte":
+		return field + " must be at least " + e.Param() + sizeUnit(e.Kind())
+	case "max", "lte":
+		return field + " must be at most " + e.Param() + sizeUnit(e.Kind())
+	case "len":
+		return field + " must be exactly " + e.Param() + sizeUnit(e.Kind())
+	default:
+		return field + " is invalid"
+	}
+}
+
+// sizeUnit names what a min/max bound counts for the field's kind
+func sizeUnit(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	default:
+		return ""
+	}
+}
+
+// toSnakeCase converts a Go field name such as ClientID to its JSON name client_id
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at a lower-to-upper boundary, or before the last capital of an acronym
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
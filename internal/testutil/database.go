@@ -0,0 +1,53 @@
+// Package testutil holds helpers shared by package tests
+package testutil
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"gogin/internal/clients"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// Database connects to the migrated Postgres database named by TEST_DATABASE_URL and
+// skips the test when it is not set. Tests create their own rows and remove them on cleanup.
+func Database(t testing.TB) *clients.Database {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping database: %v", err)
+	}
+
+	return &clients.Database{DB: db}
+}
+
+// CreateUser inserts an active user with the given role and deletes it, along with
+// the rows that cascade from it, when the test ends
+func CreateUser(t testing.TB, db *clients.Database, role string) string {
+	t.Helper()
+
+	id := uuid.New().String()
+	_, err := db.Exec(`
+		INSERT INTO users (id, email, password_hash, first_name, last_name, role)
+		VALUES ($1, $2, 'x', 'Test', 'User', $3)
+	`, id, id+"@example.com", role)
+	if err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, id) })
+
+	return id
+}
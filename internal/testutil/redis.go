@@ -0,0 +1,28 @@
+package testutil
+
+import (
+	"os"
+	"testing"
+
+	"gogin/internal/clients"
+	"gogin/internal/config"
+)
+
+// Redis connects to the Redis server at TEST_REDIS_ADDR (host:port) and skips the
+// test when it is not set. Tests should use keys unique to the test.
+func Redis(t testing.TB) *clients.RedisClient {
+	t.Helper()
+
+	addr := os.Getenv("TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("TEST_REDIS_ADDR not set")
+	}
+
+	redis, err := clients.NewRedisClient(config.RedisConfig{Addresses: []string{addr}, PoolSize: 10})
+	if err != nil {
+		t.Fatalf("connect to redis: %v", err)
+	}
+	t.Cleanup(func() { redis.Close() })
+
+	return redis
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// BreachedPasswordChecker looks passwords up in a Have I Been Pwned style range API.
+// Only the first five hex characters of the password's SHA-1 hash leave the server
+// (k-anonymity); the match against the returned suffixes happens locally.
+type BreachedPasswordChecker struct {
+	apiURL string
+	client *http.Client
+}
+
+// NewBreachedPasswordChecker creates a checker for the range API at apiURL, to which
+// the hash prefix is appended
+func NewBreachedPasswordChecker(apiURL string, timeout time.Duration) *BreachedPasswordChecker {
+	return &BreachedPasswordChecker{
+		apiURL: strings.TrimSuffix(apiURL, "/") + "/",
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// IsBreached reports whether the password appears in a known breach
+func (b *BreachedPasswordChecker) IsBreached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.apiURL+prefix, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	// Padding hides the real size of the response from anyone watching the traffic
+	req.Header.Set("Add-Padding", "true")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to query breached password API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("breached password API returned status %d", resp.StatusCode)
+	}
+
+	// Each line is SUFFIX:COUNT; padding entries have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && count != "0" && strings.EqualFold(candidate, suffix) {
+			return true, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return false, fmt.Errorf("failed to read breached password API response: %w", err)
+	}
+
+	return false, nil
+}
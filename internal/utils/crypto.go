@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// EncryptString encrypts plaintext with AES-256-GCM and returns the nonce and ciphertext base64 encoded
+func EncryptString(secret, plaintext string) (string, error) {
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptString reverses EncryptString
+func DecryptString(secret, ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	gcm, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
+	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// DecryptStringWithFallback decrypts with secret, falling back to legacySecret for values
+// encrypted before secret was introduced. legacy reports whether the fallback was used,
+// so callers can re-encrypt the value under secret.
+func DecryptStringWithFallback(secret, legacySecret, ciphertext string) (plaintext string, legacy bool, err error) {
+	plaintext, err = DecryptString(secret, ciphertext)
+	if err == nil || legacySecret == "" {
+		return plaintext, false, err
+	}
+	if plaintext, legacyErr := DecryptString(legacySecret, ciphertext); legacyErr == nil {
+		return plaintext, true, nil
+	}
+	return "", false, err
+}
+
+// newGCM builds an AES-256-GCM cipher, padding or truncating secret to a 32 byte key.
+// An empty secret is rejected since it would make the key a constant.
+func newGCM(secret string) (cipher.AEAD, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("encryption key must not be empty")
+	}
+
+	key := make([]byte, 32)
+	copy(key, secret)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,48 +1,178 @@
 package utils
 
 import (
+	"crypto/rsa"
+	"encoding/base64"
 	"fmt"
+	"math/big"
+	"sort"
 	"time"
 
+	"gogin/internal/config"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
+// Token types carried in the token_type claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // JWTClaims represents the claims in a JWT token
 type JWTClaims struct {
-	UserID   string   `json:"user_id,omitempty"`
-	ClientID string   `json:"client_id"`
-	Role     string   `json:"role,omitempty"`
-	Scopes   []string `json:"scopes"`
-	TokenID  string   `json:"jti"`
+	UserID    string   `json:"user_id,omitempty"`
+	ClientID  string   `json:"client_id"`
+	Role      string   `json:"role,omitempty"`
+	Scopes    []string `json:"scopes"`
+	TokenID   string   `json:"jti"`
+	TokenType string   `json:"token_type"`    // access or refresh
+	SessionID string   `json:"sid,omitempty"` // login session the token belongs to, if any
 	jwt.RegisteredClaims
 }
 
 // JWTUtil provides JWT operations
 type JWTUtil struct {
-	secret string
-	issuer string
+	secret     string
+	issuer     string
+	audience   string // optional; when set it is stamped on and required of every token
+	leeway     time.Duration
+	method     jwt.SigningMethod
+	keyID      string
+	privateKey *rsa.PrivateKey
+	publicKeys map[string]*rsa.PublicKey
+	// untypedRefreshUntil ends the window in which tokens without a token_type
+	// claim are still accepted as refresh tokens
+	untypedRefreshUntil time.Time
 }
 
-// NewJWTUtil creates a new JWT utility
+// defaultLeeway is the clock skew tolerated on exp and nbf when none is configured
+const defaultLeeway = 30 * time.Second
+
+// NewJWTUtil creates a new JWT utility signing with HS256
 func NewJWTUtil(secret, issuer string) *JWTUtil {
 	return &JWTUtil{
 		secret: secret,
 		issuer: issuer,
+		method: jwt.SigningMethodHS256,
+		leeway: defaultLeeway,
+	}
+}
+
+// NewJWTUtilFromConfig creates a JWT utility using the configured algorithm.
+// With RS256, tokens are signed by the private key and carry its kid; tokens
+// signed with the shared secret remain valid so HS256 tokens survive a switch.
+func NewJWTUtilFromConfig(cfg config.OAuthConfig) *JWTUtil {
+	j := NewJWTUtil(cfg.JWTSecret, cfg.JWTIssuer)
+	j.audience = cfg.JWTAudience
+	j.leeway = cfg.JWTLeeway
+	j.publicKeys = cfg.JWTPublicKeys
+	if cfg.UntypedRefreshUntil > 0 {
+		j.untypedRefreshUntil = time.Unix(cfg.UntypedRefreshUntil, 0)
+	}
+
+	if cfg.JWTAlgorithm == "RS256" && cfg.JWTPrivateKey != nil {
+		j.method = jwt.SigningMethodRS256
+		j.keyID = cfg.JWTKeyID
+		j.privateKey = cfg.JWTPrivateKey
+	}
+
+	return j
+}
+
+// JWK is an RSA public key in JSON Web Key format
+type JWK struct {
+	KeyID     string `json:"kid"`
+	KeyType   string `json:"kty"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Modulus   string `json:"n"`
+	Exponent  string `json:"e"`
+}
+
+// JWKSet is a JSON Web Key Set
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the RS256 verification keys, identified by the same kid used in token headers
+func (j *JWTUtil) JWKS() JWKSet {
+	keyIDs := make([]string, 0, len(j.publicKeys))
+	for keyID := range j.publicKeys {
+		keyIDs = append(keyIDs, keyID)
+	}
+	sort.Strings(keyIDs)
+
+	keys := make([]JWK, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		publicKey := j.publicKeys[keyID]
+		keys = append(keys, JWK{
+			KeyID:     keyID,
+			KeyType:   "RSA",
+			Use:       "sig",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			Modulus:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		})
+	}
+
+	return JWKSet{Keys: keys}
+}
+
+// sign signs claims with the configured algorithm, setting the kid header for RS256
+func (j *JWTUtil) sign(claims JWTClaims) (string, error) {
+	if j.audience != "" {
+		claims.Audience = jwt.ClaimStrings{j.audience}
+	}
+
+	token := jwt.NewWithClaims(j.method, claims)
+	if j.privateKey != nil {
+		token.Header["kid"] = j.keyID
+		return token.SignedString(j.privateKey)
+	}
+	return token.SignedString([]byte(j.secret))
+}
+
+// verificationKey picks the key for a token from its alg and kid headers
+func (j *JWTUtil) verificationKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if j.secret == "" {
+			return nil, fmt.Errorf("HMAC signed tokens are not accepted")
+		}
+		return []byte(j.secret), nil
+	case *jwt.SigningMethodRSA:
+		keyID, _ := token.Header["kid"].(string)
+		publicKey, ok := j.publicKeys[keyID]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", keyID)
+		}
+		return publicKey, nil
+	default:
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 	}
 }
 
 // GenerateAccessToken generates a new access token
 func (j *JWTUtil) GenerateAccessToken(userID, clientID, role string, scopes []string, expiry time.Duration) (string, string, error) {
+	return j.GenerateSessionAccessToken("", userID, clientID, role, scopes, expiry)
+}
+
+// GenerateSessionAccessToken generates an access token bound to a login session, so it
+// stops being accepted once the session is revoked. An empty sessionID binds none.
+func (j *JWTUtil) GenerateSessionAccessToken(sessionID, userID, clientID, role string, scopes []string, expiry time.Duration) (string, string, error) {
 	tokenID := uuid.New().String()
 	now := time.Now()
 
 	claims := JWTClaims{
-		UserID:   userID,
-		ClientID: clientID,
-		Role:     role,
-		Scopes:   scopes,
-		TokenID:  tokenID,
+		UserID:    userID,
+		ClientID:  clientID,
+		Role:      role,
+		Scopes:    scopes,
+		TokenID:   tokenID,
+		TokenType: TokenTypeAccess,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.issuer,
 			Subject:   userID,
@@ -53,8 +183,7 @@ func (j *JWTUtil) GenerateAccessToken(userID, clientID, role string, scopes []st
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secret))
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -64,13 +193,20 @@ func (j *JWTUtil) GenerateAccessToken(userID, clientID, role string, scopes []st
 
 // GenerateRefreshToken generates a new refresh token
 func (j *JWTUtil) GenerateRefreshToken(userID, clientID string, expiry time.Duration) (string, string, error) {
+	return j.GenerateSessionRefreshToken("", userID, clientID, expiry)
+}
+
+// GenerateSessionRefreshToken generates a refresh token bound to a login session
+func (j *JWTUtil) GenerateSessionRefreshToken(sessionID, userID, clientID string, expiry time.Duration) (string, string, error) {
 	tokenID := uuid.New().String()
 	now := time.Now()
 
 	claims := JWTClaims{
-		UserID:   userID,
-		ClientID: clientID,
-		TokenID:  tokenID,
+		UserID:    userID,
+		ClientID:  clientID,
+		TokenID:   tokenID,
+		TokenType: TokenTypeRefresh,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.issuer,
 			Subject:   userID,
@@ -81,8 +217,7 @@ func (j *JWTUtil) GenerateRefreshToken(userID, clientID string, expiry time.Dura
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secret))
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign refresh token: %w", err)
 	}
@@ -96,9 +231,10 @@ func (j *JWTUtil) GenerateClientToken(clientID string, scopes []string, expiry t
 	now := time.Now()
 
 	claims := JWTClaims{
-		ClientID: clientID,
-		Scopes:   scopes,
-		TokenID:  tokenID,
+		ClientID:  clientID,
+		Scopes:    scopes,
+		TokenID:   tokenID,
+		TokenType: TokenTypeAccess,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    j.issuer,
 			Subject:   clientID,
@@ -109,8 +245,7 @@ func (j *JWTUtil) GenerateClientToken(clientID string, scopes []string, expiry t
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(j.secret))
+	tokenString, err := j.sign(claims)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to sign client token: %w", err)
 	}
@@ -120,24 +255,25 @@ func (j *JWTUtil) GenerateClientToken(clientID string, scopes []string, expiry t
 
 // ValidateToken validates a JWT token and returns the claims
 func (j *JWTUtil) ValidateToken(tokenString string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(j.secret), nil
-	})
+	// Tokens minted by another issuer or for another audience are rejected
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithIssuer(j.issuer),
+		jwt.WithExpirationRequired(),
+		// Tolerate clock drift between servers on exp and nbf
+		jwt.WithLeeway(j.leeway),
+	}
+	if j.audience != "" {
+		options = append(options, jwt.WithAudience(j.audience))
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, j.verificationKey, options...)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
 	}
 
 	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		// Additional validation
-		if claims.ExpiresAt != nil && claims.ExpiresAt.Before(time.Now()) {
-			return nil, fmt.Errorf("token has expired")
-		}
-
 		return claims, nil
 	}
 
@@ -167,6 +303,28 @@ func (j *JWTUtil) GetTokenID(tokenString string) (string, error) {
 	return claims.TokenID, nil
 }
 
+// IsRefreshToken reports whether the token may only be used to obtain new tokens
+func (c *JWTClaims) IsRefreshToken() bool {
+	return c.TokenType == TokenTypeRefresh
+}
+
+// IsAccessToken reports whether the token may be used to call the API. Tokens
+// without a token_type claim predate it and are never access tokens; their
+// holders use the refresh token to get a typed pair.
+func (c *JWTClaims) IsAccessToken() bool {
+	return c.TokenType == TokenTypeAccess
+}
+
+// AcceptsRefreshToken reports whether claims may be exchanged for new tokens. Besides
+// typed refresh tokens this admits untyped tokens until JWT_UNTYPED_REFRESH_UNTIL, so
+// sessions started before the token_type claim existed survive the upgrade.
+func (j *JWTUtil) AcceptsRefreshToken(claims *JWTClaims) bool {
+	if claims.IsRefreshToken() {
+		return true
+	}
+	return claims.TokenType == "" && time.Now().Before(j.untypedRefreshUntil)
+}
+
 // HasScope checks if the token has a specific scope
 func (c *JWTClaims) HasScope(scope string) bool {
 	for _, s := range c.Scopes {
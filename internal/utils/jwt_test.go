@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestTokenTypesAreNotInterchangeable(t *testing.T) {
+	j := NewJWTUtil("test-secret-with-at-least-32-characters", "test")
+
+	access, _, err := j.GenerateAccessToken("user-1", "client-1", "user", []string{"read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	refresh, _, err := j.GenerateRefreshToken("user-1", "client-1", time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateRefreshToken: %v", err)
+	}
+	client, _, err := j.GenerateClientToken("client-1", []string{"read"}, time.Hour)
+	if err != nil {
+		t.Fatalf("GenerateClientToken: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		wantAccess  bool
+		wantRefresh bool
+	}{
+		{"access token", access, true, false},
+		{"refresh token", refresh, false, true},
+		{"client token", client, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := j.ValidateToken(tt.token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if got := claims.IsAccessToken(); got != tt.wantAccess {
+				t.Errorf("IsAccessToken() = %v, want %v", got, tt.wantAccess)
+			}
+			if got := j.AcceptsRefreshToken(claims); got != tt.wantRefresh {
+				t.Errorf("AcceptsRefreshToken() = %v, want %v", got, tt.wantRefresh)
+			}
+		})
+	}
+}
+
+func TestUntypedTokensOnlyRefreshWithinWindow(t *testing.T) {
+	tests := []struct {
+		name        string
+		until       time.Time
+		wantRefresh bool
+	}{
+		{"no window", time.Time{}, false},
+		{"window open", time.Now().Add(time.Hour), true},
+		{"window closed", time.Now().Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := NewJWTUtil("test-secret-with-at-least-32-characters", "test")
+			j.untypedRefreshUntil = tt.until
+
+			// Tokens minted before the token_type claim existed
+			now := time.Now()
+			token, err := j.sign(JWTClaims{
+				UserID:  "user-1",
+				TokenID: "legacy",
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "test",
+					ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+					IssuedAt:  jwt.NewNumericDate(now),
+				},
+			})
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			claims, err := j.ValidateToken(token)
+			if err != nil {
+				t.Fatalf("ValidateToken: %v", err)
+			}
+			if claims.IsAccessToken() {
+				t.Error("untyped token accepted as an access token")
+			}
+			if got := j.AcceptsRefreshToken(claims); got != tt.wantRefresh {
+				t.Errorf("AcceptsRefreshToken() = %v, want %v", got, tt.wantRefresh)
+			}
+		})
+	}
+}
+
+func TestValidateTokenLeeway(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiresIn time.Duration
+		notBefore time.Duration
+		wantValid bool
+	}{
+		{"valid", time.Hour, 0, true},
+		{"expired within leeway", -10 * time.Second, -time.Hour, true},
+		{"expired past leeway", -time.Minute, -time.Hour, false},
+		{"not yet valid within leeway", time.Hour, 10 * time.Second, true},
+		{"not yet valid past leeway", time.Hour, time.Minute, false},
+	}
+
+	j := NewJWTUtil("test-secret-with-at-least-32-characters", "test")
+	j.leeway = 30 * time.Second
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			token, err := j.sign(JWTClaims{
+				TokenType: TokenTypeAccess,
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    "test",
+					ExpiresAt: jwt.NewNumericDate(now.Add(tt.expiresIn)),
+					NotBefore: jwt.NewNumericDate(now.Add(tt.notBefore)),
+					IssuedAt:  jwt.NewNumericDate(now.Add(-time.Hour)),
+				},
+			})
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+
+			_, err = j.ValidateToken(token)
+			if valid := err == nil; valid != tt.wantValid {
+				t.Errorf("ValidateToken() error = %v, want valid %v", err, tt.wantValid)
+			}
+		})
+	}
+}
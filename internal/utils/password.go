@@ -1,7 +1,14 @@
 package utils
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gogin/internal/config"
 
 	"golang.org/x/crypto/bcrypt"
 )
@@ -33,39 +40,115 @@ func VerifyPassword(password, hash string) bool {
 	return err == nil
 }
 
-// IsPasswordValid checks if a password meets strength requirements
-func IsPasswordValid(password string) (bool, string) {
-	if len(password) < MinPasswordLength {
-		return false, fmt.Sprintf("Password must be at least %d characters long", MinPasswordLength)
+// PasswordPolicy lists the requirements a new password must meet
+type PasswordPolicy struct {
+	MinLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// BreachChecker, when set, rejects passwords found in known breaches
+	BreachChecker *BreachedPasswordChecker
+}
+
+// PasswordPolicyFromConfig builds the password policy from the users configuration
+func PasswordPolicyFromConfig(cfg config.UsersConfig) PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:     cfg.PasswordMinLength,
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+	}
+	if cfg.PasswordBreachCheck {
+		policy.BreachChecker = NewBreachedPasswordChecker(cfg.PasswordBreachAPIURL, cfg.PasswordBreachTimeout)
 	}
+	return policy
+}
 
-	// Check for at least one uppercase letter
-	hasUpper := false
-	// Check for at least one lowercase letter
-	hasLower := false
-	// Check for at least one digit
-	hasDigit := false
+// PasswordRequirement is one rule of a password policy and whether a password meets it
+type PasswordRequirement struct {
+	Code    string `json:"code"` // min_length, uppercase, lowercase, digit, symbol or not_breached
+	Message string `json:"message"`
+	Met     bool   `json:"met"`
+}
+
+// PasswordPolicyError reports the requirements a password failed to meet
+type PasswordPolicyError struct {
+	Failed []PasswordRequirement
+}
+
+func (e *PasswordPolicyError) Error() string {
+	messages := make([]string, len(e.Failed))
+	for i, requirement := range e.Failed {
+		messages[i] = requirement.Message
+	}
+	return strings.Join(messages, "; ")
+}
 
+// Evaluate checks a password against every requirement of the policy except the
+// breach check, which needs a network call and is only made by Validate
+func (p PasswordPolicy) Evaluate(password string) []PasswordRequirement {
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
 	for _, char := range password {
 		switch {
-		case char >= 'A' && char <= 'Z':
+		case unicode.IsUpper(char):
 			hasUpper = true
-		case char >= 'a' && char <= 'z':
+		case unicode.IsLower(char):
 			hasLower = true
-		case char >= '0' && char <= '9':
+		case unicode.IsDigit(char):
 			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSymbol = true
 		}
 	}
 
-	if !hasUpper {
-		return false, "Password must contain at least one uppercase letter"
+	requirements := []PasswordRequirement{{
+		Code:    "min_length",
+		Message: fmt.Sprintf("Password must be at least %d characters long", p.MinLength),
+		Met:     utf8.RuneCountInString(password) >= p.MinLength,
+	}}
+	if p.RequireUpper {
+		requirements = append(requirements, PasswordRequirement{Code: "uppercase", Message: "Password must contain at least one uppercase letter", Met: hasUpper})
+	}
+	if p.RequireLower {
+		requirements = append(requirements, PasswordRequirement{Code: "lowercase", Message: "Password must contain at least one lowercase letter", Met: hasLower})
 	}
-	if !hasLower {
-		return false, "Password must contain at least one lowercase letter"
+	if p.RequireDigit {
+		requirements = append(requirements, PasswordRequirement{Code: "digit", Message: "Password must contain at least one digit", Met: hasDigit})
 	}
-	if !hasDigit {
-		return false, "Password must contain at least one digit"
+	if p.RequireSymbol {
+		requirements = append(requirements, PasswordRequirement{Code: "symbol", Message: "Password must contain at least one symbol", Met: hasSymbol})
 	}
 
-	return true, ""
+	return requirements
+}
+
+// Validate returns a *PasswordPolicyError listing every unmet requirement, or nil.
+// The breach check fails open: if the lookup fails the password is allowed.
+func (p PasswordPolicy) Validate(ctx context.Context, password string) error {
+	var failed []PasswordRequirement
+	for _, requirement := range p.Evaluate(password) {
+		if !requirement.Met {
+			failed = append(failed, requirement)
+		}
+	}
+
+	// Only spend the lookup on passwords that would otherwise be accepted
+	if len(failed) == 0 && p.BreachChecker != nil {
+		breached, err := p.BreachChecker.IsBreached(ctx, password)
+		if err != nil {
+			log.Printf("Warning: breached password check failed, allowing password: %v", err)
+		} else if breached {
+			failed = append(failed, PasswordRequirement{
+				Code:    "not_breached",
+				Message: "Password has appeared in a data breach, choose a different one",
+			})
+		}
+	}
+
+	if len(failed) > 0 {
+		return &PasswordPolicyError{Failed: failed}
+	}
+	return nil
 }
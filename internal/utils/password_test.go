@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	passwords := []struct {
+		password                       string
+		long, upper, lower, digit, sym bool
+	}{
+		{"", false, false, false, false, false},
+		{"short", false, false, true, false, false},
+		{"lowercaseonly", true, false, true, false, false},
+		{"UPPERCASEONLY", true, true, false, false, false},
+		{"12345678", true, false, false, true, false},
+		{"!@#$%^&*", true, false, false, false, true},
+		{"Mixed1234", true, true, true, true, false},
+		{"Aa1!", false, true, true, true, true},
+		{"Str0ng!Passw0rd", true, true, true, true, true},
+		{"ÜnïcödeÄ1!", true, true, true, true, true},
+	}
+
+	// Every combination of the character class requirements
+	for mask := 0; mask < 16; mask++ {
+		policy := PasswordPolicy{
+			MinLength:     8,
+			RequireUpper:  mask&1 != 0,
+			RequireLower:  mask&2 != 0,
+			RequireDigit:  mask&4 != 0,
+			RequireSymbol: mask&8 != 0,
+		}
+
+		for _, tt := range passwords {
+			var want []string
+			if !tt.long {
+				want = append(want, "min_length")
+			}
+			if policy.RequireUpper && !tt.upper {
+				want = append(want, "uppercase")
+			}
+			if policy.RequireLower && !tt.lower {
+				want = append(want, "lowercase")
+			}
+			if policy.RequireDigit && !tt.digit {
+				want = append(want, "digit")
+			}
+			if policy.RequireSymbol && !tt.sym {
+				want = append(want, "symbol")
+			}
+
+			err := policy.Validate(context.Background(), tt.password)
+			var got []string
+			var policyErr *PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				for _, requirement := range policyErr.Failed {
+					got = append(got, requirement.Code)
+				}
+			} else if err != nil {
+				t.Fatalf("policy %+v, password %q: unexpected error %v", policy, tt.password, err)
+			}
+
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("policy %+v, password %q: failed %v, want %v", policy, tt.password, got, want)
+			}
+		}
+	}
+}
+
+func TestPasswordPolicyEvaluateListsEnabledRequirements(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy PasswordPolicy
+		want   []string
+	}{
+		{"length only", PasswordPolicy{MinLength: 8}, []string{"min_length"}},
+		{"all classes", PasswordPolicy{MinLength: 12, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true},
+			[]string{"min_length", "uppercase", "lowercase", "digit", "symbol"}},
+		{"digit and symbol", PasswordPolicy{MinLength: 8, RequireDigit: true, RequireSymbol: true}, []string{"min_length", "digit", "symbol"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, requirement := range tt.policy.Evaluate("anything") {
+				got = append(got, requirement.Code)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Evaluate() codes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
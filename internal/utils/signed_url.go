@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// SignFileURL returns an HMAC-SHA256 signature binding a file ID to an expiry time
+func SignFileURL(secret, fileID string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%s:%d", fileID, expiresAt)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyFileURL checks that a signature is valid for the file ID and has not expired
+func VerifyFileURL(secret, fileID string, expiresAt int64, signature string) bool {
+	if time.Now().Unix() > expiresAt {
+		return false
+	}
+
+	expected := SignFileURL(secret, fileID, expiresAt)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
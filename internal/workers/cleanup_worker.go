@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gogin/internal/clients"
+	"gogin/internal/config"
+	"gogin/internal/modules/notifications"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/sendgrid"
+	"gogin/internal/modules/storage"
+	"gogin/internal/modules/twilio"
+	"gogin/internal/modules/users"
+	"gogin/internal/utils"
+)
+
+// cleanupLockKey guards the cleanup so only one instance purges at a time
+const cleanupLockKey = "workers:cleanup"
+
+// cleanupTarget is a table whose soft-deleted rows are purged after its retention period
+type cleanupTarget struct {
+	table     string
+	retention time.Duration
+	purge     func(cutoff time.Time) (int64, error)
+}
+
+// CleanupWorker permanently removes soft-deleted users, files and notifications once
+// their retention period has passed, along with the stored objects they own
+type CleanupWorker struct {
+	targets     []cleanupTarget
+	redisHelper *redishelper.RedisHelper
+	config      *config.Config
+	stop        chan struct{}
+	stats       workerStats
+}
+
+// NewCleanupWorker creates a new cleanup worker
+func NewCleanupWorker(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, cfg *config.Config) *CleanupWorker {
+	redisHelper := redishelper.NewRedisHelper(redis)
+	storageService := storage.NewStorageService(db, cfg)
+	twilioClient := twilio.NewTwilioClient(cfg.Twilio)
+	userService := users.NewUserService(db, utils.NewJWTUtilFromConfig(cfg.OAuth), redisHelper, storageService, twilioClient, cfg)
+	notificationService := notifications.NewNotificationsService(db, nats, sendgrid.NewSendGridClient(cfg.SMTP), twilioClient)
+
+	return &CleanupWorker{
+		// Users go first so their files are removed with them rather than orphaned
+		targets: []cleanupTarget{
+			{
+				table:     "users",
+				retention: cfg.Users.DeletedRetention,
+				purge: func(cutoff time.Time) (int64, error) {
+					purged, err := userService.PurgeDeletedUsers(context.Background(), cutoff)
+					return int64(purged), err
+				},
+			},
+			{
+				table:     "files",
+				retention: cfg.Storage.DeletedRetention,
+				purge: func(cutoff time.Time) (int64, error) {
+					purged, err := storageService.PurgeDeletedFiles(cutoff)
+					return int64(purged), err
+				},
+			},
+			{
+				table:     "notifications",
+				retention: cfg.Notifications.DeletedRetention,
+				purge: func(cutoff time.Time) (int64, error) {
+					return notificationService.PurgeDeletedNotifications(context.Background(), cutoff)
+				},
+			},
+		},
+		redisHelper: redisHelper,
+		config:      cfg,
+		stop:        make(chan struct{}),
+	}
+}
+
+// Start starts the cleanup worker
+func (w *CleanupWorker) Start() error {
+	log.Println("🗑️  Starting cleanup worker...")
+
+	go w.run()
+
+	log.Println("✓ Cleanup worker started successfully")
+	return nil
+}
+
+// Stop stops the cleanup worker
+func (w *CleanupWorker) Stop() {
+	close(w.stop)
+}
+
+// Status reports whether the cleanup loop is running and how many rows it purged
+func (w *CleanupWorker) Status() WorkerStatus {
+	return w.stats.status("cleanup")
+}
+
+// run purges expired rows on every tick until stopped
+func (w *CleanupWorker) run() {
+	w.stats.setRunning(true)
+	defer w.stats.setRunning(false)
+
+	ticker := time.NewTicker(w.config.Cleanup.Interval)
+	defer ticker.Stop()
+
+	w.cleanup()
+	for {
+		select {
+		case <-ticker.C:
+			w.cleanup()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// cleanup purges every table while holding the distributed cleanup lock
+func (w *CleanupWorker) cleanup() {
+	token, acquired, err := w.redisHelper.AcquireLock(cleanupLockKey, w.config.Cleanup.Interval)
+	if err != nil {
+		log.Printf("Failed to acquire cleanup lock: %v", err)
+		w.stats.recordError(err)
+		return
+	}
+	if !acquired {
+		// Another instance is cleaning up this tick
+		return
+	}
+	defer w.redisHelper.ReleaseLock(cleanupLockKey, token)
+
+	for _, target := range w.targets {
+		// Keep the lock while tables are still being worked through
+		if held, err := w.redisHelper.ExtendLock(cleanupLockKey, token, w.config.Cleanup.Interval); err != nil || !held {
+			log.Printf("Lost cleanup lock: %v", err)
+			return
+		}
+
+		cutoff := time.Now().UTC().Add(-target.retention)
+		purged, err := target.purge(cutoff)
+		if err != nil {
+			log.Printf("Failed to purge deleted %s: %v", target.table, err)
+			w.stats.recordError(fmt.Errorf("purge %s: %w", target.table, err))
+			continue
+		}
+
+		if purged > 0 {
+			w.stats.recordProcessed(purged)
+			log.Printf("✓ Purged %d deleted %s", purged, target.table)
+		}
+	}
+}
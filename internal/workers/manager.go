@@ -1,6 +1,8 @@
 package workers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 
 	"gogin/internal/clients"
@@ -9,31 +11,67 @@ import (
 
 // WorkerManager manages background workers
 type WorkerManager struct {
-	notificationWorker *NotificationWorker
+	notificationWorker     *NotificationWorker
+	ticketEscalationWorker *TicketEscalationWorker
+	cleanupWorker          *CleanupWorker
+	scheduledNotifWorker   *ScheduledNotificationWorker
 }
 
 // NewWorkerManager creates a new worker manager
-func NewWorkerManager(db *clients.Database, nats *clients.NATSClient, cfg *config.Config) *WorkerManager {
+func NewWorkerManager(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, cfg *config.Config) *WorkerManager {
 	return &WorkerManager{
-		notificationWorker: NewNotificationWorker(db, nats, cfg),
+		notificationWorker:     NewNotificationWorker(db, nats, cfg),
+		ticketEscalationWorker: NewTicketEscalationWorker(db, nats, cfg),
+		cleanupWorker:          NewCleanupWorker(db, redis, nats, cfg),
+		scheduledNotifWorker:   NewScheduledNotificationWorker(db, redis, nats, cfg),
 	}
 }
 
-// Start starts all background workers
+// Start starts all background workers. Each worker starts independently, so one that
+// fails (e.g. its NATS consumer can't be set up) doesn't keep the others from running;
+// the failures are returned together and show up as not running in Status.
 func (m *WorkerManager) Start() error {
 	log.Println("🚀 Starting background workers...")
 
-	// Start notification worker
-	if err := m.notificationWorker.Start(); err != nil {
-		return err
+	starts := []struct {
+		name  string
+		start func() error
+	}{
+		{"notification", m.notificationWorker.Start},
+		{"ticket escalation", m.ticketEscalationWorker.Start},
+		{"cleanup", m.cleanupWorker.Start},
+		{"scheduled notification", m.scheduledNotifWorker.Start},
+	}
+
+	var errs []error
+	for _, worker := range starts {
+		if err := worker.start(); err != nil {
+			log.Printf("Warning: Failed to start %s worker: %v", worker.name, err)
+			errs = append(errs, fmt.Errorf("%s worker: %w", worker.name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
 	log.Println("✓ All workers started successfully")
 	return nil
 }
 
+// Status reports the state of every background worker
+func (m *WorkerManager) Status() []WorkerStatus {
+	return []WorkerStatus{
+		m.notificationWorker.Status(),
+		m.ticketEscalationWorker.Status(),
+		m.cleanupWorker.Status(),
+		m.scheduledNotifWorker.Status(),
+	}
+}
+
 // Stop stops all background workers
 func (m *WorkerManager) Stop() {
 	log.Println("Stopping background workers...")
+	m.cleanupWorker.Stop()
+	m.scheduledNotifWorker.Stop()
 	log.Println("Workers stopped")
 }
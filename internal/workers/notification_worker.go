@@ -1,15 +1,23 @@
 package workers
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
+	"strconv"
+	"time"
 
 	"gogin/internal/clients"
 	"gogin/internal/config"
+	"gogin/internal/metrics"
 	"gogin/internal/modules/notifications"
 	"gogin/internal/modules/sendgrid"
 	"gogin/internal/modules/twilio"
+	"gogin/internal/utils"
 
 	"github.com/nats-io/nats.go"
 )
@@ -20,7 +28,10 @@ type NotificationWorker struct {
 	nats     *clients.NATSClient
 	sendgrid *sendgrid.SendGridClient
 	twilio   *twilio.TwilioClient
+	webhooks *http.Client
 	config   *config.Config
+	sub      *nats.Subscription
+	stats    workerStats
 }
 
 // NewNotificationWorker creates a new notification worker
@@ -30,6 +41,7 @@ func NewNotificationWorker(db *clients.Database, nats *clients.NATSClient, cfg *
 		nats:     nats,
 		sendgrid: sendgrid.NewSendGridClient(cfg.SMTP),
 		twilio:   twilio.NewTwilioClient(cfg.Twilio),
+		webhooks: utils.NewPublicHTTPClient(cfg.Notifications.WebhookTimeout),
 		config:   cfg,
 	}
 }
@@ -38,32 +50,46 @@ func NewNotificationWorker(db *clients.Database, nats *clients.NATSClient, cfg *
 func (w *NotificationWorker) Start() error {
 	log.Println("📬 Starting notification worker...")
 
-	// Subscribe to notification send events
-	_, err := w.nats.QueueSubscribe(
+	// Subscribe to notification send events. Each message is handled independently,
+	// touching only its own notification row and external call, so deliveries run in
+	// parallel; there is no ordering guarantee between them, even for the same user.
+	sub, err := w.nats.QueueSubscribeConcurrent(
 		"notification.send",
 		"notification-workers",
 		"notification-worker-durable",
+		w.config.NATS.WorkerConcurrency,
 		w.handleNotificationSend,
 	)
 
 	if err != nil {
 		return fmt.Errorf("failed to subscribe to notification.send: %w", err)
 	}
+	w.sub = sub
 
 	log.Println("✓ Notification worker started successfully")
 	return nil
 }
 
+// Status reports the worker's subscription state and delivery counters
+func (w *NotificationWorker) Status() WorkerStatus {
+	status := w.stats.status("notification")
+	status.Running = w.sub != nil && w.sub.IsValid()
+	return status
+}
+
 // handleNotificationSend handles notification send messages
 func (w *NotificationWorker) handleNotificationSend(msg *nats.Msg) {
 	var req notifications.SendNotificationRequest
 	if err := json.Unmarshal(msg.Data, &req); err != nil {
 		log.Printf("Failed to unmarshal notification: %v", err)
-		msg.Nak()
+		w.stats.recordError(fmt.Errorf("unmarshal: %w", err))
+		// A malformed message will never succeed, so park it instead of redelivering
+		deadLetter(w.nats, msg, fmt.Sprintf("unmarshal: %v", err))
 		return
 	}
 
-	log.Printf("Processing notification: %s to %s via %s", req.Type, req.UserID, req.Channel)
+	attempt := deliveryAttempt(msg)
+	log.Printf("Processing notification: %s to %s via %s (attempt %d)", req.Type, req.UserID, req.Channel, attempt)
 
 	var err error
 	switch req.Channel {
@@ -73,26 +99,73 @@ func (w *NotificationWorker) handleNotificationSend(msg *nats.Msg) {
 		err = w.sendSMS(&req)
 	case "push":
 		err = w.sendPushNotification(&req)
+	case "webhook":
+		err = w.sendWebhook(&req)
 	default:
 		log.Printf("Unknown notification channel: %s", req.Channel)
-		msg.Nak()
+		w.stats.recordError(fmt.Errorf("unknown channel: %s", req.Channel))
+		w.updateNotificationStatus(req.ID, "failed", fmt.Sprintf("unknown channel: %s", req.Channel), attempt)
+		metrics.NotificationSends.WithLabelValues(req.Channel, "failed").Inc()
+		deadLetter(w.nats, msg, fmt.Sprintf("unknown channel: %s", req.Channel))
 		return
 	}
 
 	if err != nil {
 		log.Printf("Failed to send notification: %v", err)
-		// Update status to failed
-		w.updateNotificationStatus(req.UserID, "failed", err.Error())
-		msg.Nak()
+		w.stats.recordError(err)
+
+		if attempt >= w.config.Notifications.MaxAttempts {
+			// Give up permanently
+			w.updateNotificationStatus(req.ID, "failed", err.Error(), attempt)
+			metrics.NotificationSends.WithLabelValues(req.Channel, "failed").Inc()
+			msg.Term()
+			return
+		}
+
+		// Keep pending and redeliver after an exponential backoff
+		w.updateNotificationStatus(req.ID, "pending", err.Error(), attempt)
+		metrics.NotificationSends.WithLabelValues(req.Channel, "retry").Inc()
+		msg.NakWithDelay(w.retryDelay(attempt))
 		return
 	}
 
 	// Update status to sent
-	w.updateNotificationStatus(req.UserID, "sent", "")
+	w.updateNotificationStatus(req.ID, "sent", "", attempt)
+	metrics.NotificationSends.WithLabelValues(req.Channel, "sent").Inc()
+	w.stats.recordProcessed(1)
 	msg.Ack()
 	log.Printf("✓ Notification sent successfully")
 }
 
+// deliveryAttempt returns the JetStream delivery count of a message (1 for the first delivery)
+func deliveryAttempt(msg *nats.Msg) int {
+	meta, err := msg.Metadata()
+	if err != nil || meta.NumDelivered == 0 {
+		return 1
+	}
+	return int(meta.NumDelivered)
+}
+
+// deadLetter moves a message to the dead-letter subject and terminates it so JetStream stops redelivering
+func deadLetter(nc *clients.NATSClient, msg *nats.Msg, reason string) {
+	if err := nc.PublishDeadLetter(msg, reason); err != nil {
+		log.Printf("Failed to dead-letter message on %s: %v", msg.Subject, err)
+	}
+	msg.Term()
+}
+
+// retryDelay returns the backoff before the next attempt: base * 2^(attempt-1), capped
+func (w *NotificationWorker) retryDelay(attempt int) time.Duration {
+	delay := w.config.Notifications.RetryBaseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= w.config.Notifications.RetryMaxDelay {
+			return w.config.Notifications.RetryMaxDelay
+		}
+	}
+	return delay
+}
+
 // sendEmail sends an email notification
 func (w *NotificationWorker) sendEmail(req *notifications.SendNotificationRequest) error {
 	// Get user email from database
@@ -106,7 +179,13 @@ func (w *NotificationWorker) sendEmail(req *notifications.SendNotificationReques
 		To:          []string{email},
 		Subject:     req.Title,
 		TextContent: req.Content,
-		HTMLContent: fmt.Sprintf("<h2>%s</h2><p>%s</p>", req.Title, req.Content),
+		HTMLContent: req.HTMLContent,
+		RequestID:   req.RequestID,
+	}
+
+	// Fall back to a simple layout when no template HTML was rendered
+	if msg.HTMLContent == "" {
+		msg.HTMLContent = fmt.Sprintf("<h2>%s</h2><p>%s</p>", req.Title, req.Content)
 	}
 
 	return w.sendgrid.SendEmail(msg)
@@ -126,11 +205,26 @@ func (w *NotificationWorker) sendSMS(req *notifications.SendNotificationRequest)
 	}
 
 	msg := &twilio.SMSMessage{
-		To:   phone,
-		Body: fmt.Sprintf("%s: %s", req.Title, req.Content),
+		To:        phone,
+		Body:      fmt.Sprintf("%s: %s", req.Title, req.Content),
+		RequestID: req.RequestID,
+	}
+
+	result, err := w.twilio.SendSMS(msg)
+	if err != nil {
+		return err
 	}
 
-	return w.twilio.SendSMS(msg)
+	// Remember the message SID so Twilio status callbacks can find this notification
+	_, err = w.db.Exec(
+		`UPDATE notifications SET provider = 'twilio', provider_id = $1, provider_status = $2, updated_at = $3 WHERE id = $4`,
+		result.SID, result.Status, clients.Now(), req.ID,
+	)
+	if err != nil {
+		log.Printf("Failed to store Twilio message SID for notification %s: %v", req.ID, err)
+	}
+
+	return nil
 }
 
 // sendPushNotification sends a push notification (placeholder)
@@ -140,14 +234,80 @@ func (w *NotificationWorker) sendPushNotification(req *notifications.SendNotific
 	return nil
 }
 
-// updateNotificationStatus updates notification status in database
-func (w *NotificationWorker) updateNotificationStatus(userID, status, errorMsg string) {
+// sendWebhook posts a notification to the user's webhook, signed with its secret.
+// Any non-2xx response, including a redirect, is a failure so the delivery is retried
+// with backoff. The client refuses to connect to internal addresses.
+func (w *NotificationWorker) sendWebhook(req *notifications.SendNotificationRequest) error {
+	var url, encryptedSecret string
+	err := w.db.QueryRow("SELECT url, secret FROM user_webhooks WHERE user_id = $1", req.UserID).Scan(&url, &encryptedSecret)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user has no webhook")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user webhook: %w", err)
+	}
+
+	// Secrets saved before ENCRYPTION_KEY existed were keyed by the JWT secret
+	secret, _, err := utils.DecryptStringWithFallback(w.config.App.EncryptionKey, w.config.OAuth.JWTSecret, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+
+	body, _ := json.Marshal(&notifications.WebhookPayload{
+		ID:      req.ID,
+		UserID:  req.UserID,
+		Type:    req.Type,
+		Title:   req.Title,
+		Content: req.Content,
+		SentAt:  clients.Now(),
+	})
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	httpReq, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	// Webhooks registered before https was required are not called
+	if httpReq.URL.Scheme != "https" {
+		return fmt.Errorf("webhook URL must use https")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(notifications.WebhookTimestampHeader, timestamp)
+	httpReq.Header.Set(notifications.WebhookSignatureHeader, notifications.SignWebhookPayload(secret, timestamp, body))
+
+	resp, err := w.webhooks.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// updateNotificationStatus updates the status of a single notification in database
+func (w *NotificationWorker) updateNotificationStatus(notificationID, status, errorMsg string, attempts int) {
+	if notificationID == "" {
+		log.Printf("Cannot update notification status to %s: message has no notification ID", status)
+		return
+	}
+
+	// A provider callback may already have recorded delivered or failed; sent only
+	// advances a notification that is still waiting in the queue
 	query := `
 		UPDATE notifications
-		SET status = $1, error_message = $2, updated_at = NOW()
-		WHERE user_id = $3 AND status = 'pending'
+		SET status = CASE WHEN $1 = 'sent' AND status NOT IN ('pending', 'queued') THEN status ELSE $1 END,
+			error_msg = NULLIF($2, ''),
+			sent_at = CASE WHEN $3 THEN $4 ELSE sent_at END,
+			attempts = $5,
+			updated_at = $4
+		WHERE id = $6
 	`
-	_, err := w.db.Exec(query, status, errorMsg, userID)
+	_, err := w.db.Exec(query, status, errorMsg, status == "sent", clients.Now(), attempts, notificationID)
 	if err != nil {
 		log.Printf("Failed to update notification status: %v", err)
 	}
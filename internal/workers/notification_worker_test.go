@@ -0,0 +1,55 @@
+package workers
+
+import (
+	"testing"
+
+	"gogin/internal/config"
+	"gogin/internal/testutil"
+)
+
+func TestUpdateNotificationStatusTargetsOneNotification(t *testing.T) {
+	db := testutil.Database(t)
+	userID := testutil.CreateUser(t, db, "user")
+	worker := NewNotificationWorker(db, nil, &config.Config{})
+
+	// Three jobs queued for the same user; a provider callback already settled the third
+	insert := func(status string) string {
+		var id string
+		err := db.QueryRow(`
+			INSERT INTO notifications (user_id, type, channel, title, content, status)
+			VALUES ($1, 'test', 'sms', 'Title', 'Content', $2)
+			RETURNING id
+		`, userID, status).Scan(&id)
+		if err != nil {
+			t.Fatalf("insert notification: %v", err)
+		}
+		return id
+	}
+	sentID := insert("pending")
+	failedID := insert("pending")
+	deliveredID := insert("delivered")
+
+	worker.updateNotificationStatus(sentID, "sent", "", 1)
+	worker.updateNotificationStatus(failedID, "failed", "provider error", 3)
+	worker.updateNotificationStatus(deliveredID, "sent", "", 1)
+
+	tests := []struct {
+		id           string
+		wantStatus   string
+		wantAttempts int
+	}{
+		{sentID, "sent", 1},
+		{failedID, "failed", 3},
+		{deliveredID, "delivered", 1},
+	}
+	for _, tt := range tests {
+		var status string
+		var attempts int
+		if err := db.QueryRow(`SELECT status, attempts FROM notifications WHERE id = $1`, tt.id).Scan(&status, &attempts); err != nil {
+			t.Fatalf("load notification: %v", err)
+		}
+		if status != tt.wantStatus || attempts != tt.wantAttempts {
+			t.Errorf("notification %s = %s after %d attempts, want %s after %d", tt.id, status, attempts, tt.wantStatus, tt.wantAttempts)
+		}
+	}
+}
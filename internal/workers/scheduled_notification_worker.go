@@ -0,0 +1,117 @@
+package workers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gogin/internal/clients"
+	"gogin/internal/config"
+	"gogin/internal/modules/notifications"
+	"gogin/internal/modules/redishelper"
+	"gogin/internal/modules/sendgrid"
+	"gogin/internal/modules/twilio"
+)
+
+const (
+	// scheduledNotificationLockKey guards the scheduler so only one instance dispatches at a time
+	scheduledNotificationLockKey = "workers:scheduled-notifications"
+	// scheduledNotificationBatchSize caps how many due notifications are released per tick
+	scheduledNotificationBatchSize = 100
+)
+
+// ScheduledNotificationWorker releases scheduled notifications once they are due
+type ScheduledNotificationWorker struct {
+	notifications *notifications.NotificationsService
+	redisHelper   *redishelper.RedisHelper
+	config        *config.Config
+	stop          chan struct{}
+	stats         workerStats
+}
+
+// NewScheduledNotificationWorker creates a new scheduled notification worker
+func NewScheduledNotificationWorker(db *clients.Database, redis *clients.RedisClient, nats *clients.NATSClient, cfg *config.Config) *ScheduledNotificationWorker {
+	return &ScheduledNotificationWorker{
+		notifications: notifications.NewNotificationsService(db, nats, sendgrid.NewSendGridClient(cfg.SMTP), twilio.NewTwilioClient(cfg.Twilio)),
+		redisHelper:   redishelper.NewRedisHelper(redis),
+		config:        cfg,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Start starts the scheduled notification worker
+func (w *ScheduledNotificationWorker) Start() error {
+	log.Println("⏰ Starting scheduled notification worker...")
+
+	go w.run()
+
+	log.Println("✓ Scheduled notification worker started successfully")
+	return nil
+}
+
+// Stop stops the scheduled notification worker
+func (w *ScheduledNotificationWorker) Stop() {
+	close(w.stop)
+}
+
+// Status reports whether the scheduler loop is running and how many notifications it released
+func (w *ScheduledNotificationWorker) Status() WorkerStatus {
+	return w.stats.status("scheduled_notification")
+}
+
+// run dispatches due notifications on every tick until stopped
+func (w *ScheduledNotificationWorker) run() {
+	w.stats.setRunning(true)
+	defer w.stats.setRunning(false)
+
+	ticker := time.NewTicker(w.config.Notifications.SchedulerInterval)
+	defer ticker.Stop()
+
+	w.dispatch()
+	for {
+		select {
+		case <-ticker.C:
+			w.dispatch()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// dispatch releases due notifications while holding the distributed scheduler lock
+func (w *ScheduledNotificationWorker) dispatch() {
+	token, acquired, err := w.redisHelper.AcquireLock(scheduledNotificationLockKey, w.config.Notifications.SchedulerInterval)
+	if err != nil {
+		log.Printf("Failed to acquire scheduled notification lock: %v", err)
+		w.stats.recordError(err)
+		return
+	}
+	if !acquired {
+		// Another instance is dispatching this tick
+		return
+	}
+	defer w.redisHelper.ReleaseLock(scheduledNotificationLockKey, token)
+
+	for {
+		// Keep the lock while batches are still being worked through
+		if held, err := w.redisHelper.ExtendLock(scheduledNotificationLockKey, token, w.config.Notifications.SchedulerInterval); err != nil || !held {
+			log.Printf("Lost scheduled notification lock: %v", err)
+			return
+		}
+
+		dispatched, err := w.notifications.DispatchScheduledNotifications(context.Background(), scheduledNotificationBatchSize)
+		if err != nil {
+			log.Printf("Failed to dispatch scheduled notifications: %v", err)
+			w.stats.recordError(err)
+			return
+		}
+
+		if dispatched > 0 {
+			w.stats.recordProcessed(int64(dispatched))
+			log.Printf("✓ Dispatched %d scheduled notifications", dispatched)
+		}
+		if dispatched < scheduledNotificationBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package workers
+
+import (
+	"sync"
+	"time"
+)
+
+// WorkerStatus reports the state of a background worker
+type WorkerStatus struct {
+	Name              string     `json:"name"`
+	Running           bool       `json:"running"`
+	MessagesProcessed int64      `json:"messages_processed"`
+	MessagesFailed    int64      `json:"messages_failed"`
+	LastProcessedAt   *time.Time `json:"last_processed_at,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+	LastErrorAt       *time.Time `json:"last_error_at,omitempty"`
+}
+
+// workerStats tracks a worker's activity for status reporting. It is safe for
+// concurrent use, since message handlers run on NATS delivery goroutines.
+type workerStats struct {
+	mu              sync.Mutex
+	running         bool
+	processed       int64
+	failed          int64
+	lastProcessedAt time.Time
+	lastError       string
+	lastErrorAt     time.Time
+}
+
+// setRunning records whether the worker is running
+func (s *workerStats) setRunning(running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running = running
+}
+
+// recordProcessed counts n successfully handled messages or items
+func (s *workerStats) recordProcessed(n int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.processed += n
+	s.lastProcessedAt = time.Now().UTC()
+}
+
+// recordError counts a failure and keeps it as the last error
+func (s *workerStats) recordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed++
+	s.lastError = err.Error()
+	s.lastErrorAt = time.Now().UTC()
+}
+
+// status snapshots the stats under the worker's name
+func (s *workerStats) status(name string) WorkerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := WorkerStatus{
+		Name:              name,
+		Running:           s.running,
+		MessagesProcessed: s.processed,
+		MessagesFailed:    s.failed,
+		LastError:         s.lastError,
+	}
+	if !s.lastProcessedAt.IsZero() {
+		lastProcessedAt := s.lastProcessedAt
+		status.LastProcessedAt = &lastProcessedAt
+	}
+	if !s.lastErrorAt.IsZero() {
+		lastErrorAt := s.lastErrorAt
+		status.LastErrorAt = &lastErrorAt
+	}
+	return status
+}
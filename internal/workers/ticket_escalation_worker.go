@@ -0,0 +1,147 @@
+package workers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+
+	"gogin/internal/clients"
+	"gogin/internal/config"
+	"gogin/internal/modules/sendgrid"
+	"gogin/internal/modules/tickets"
+
+	"github.com/nats-io/nats.go"
+)
+
+// TicketEscalationWorker emails the ops distribution list when a ticket escalates to urgent
+type TicketEscalationWorker struct {
+	db       *clients.Database
+	nats     *clients.NATSClient
+	sendgrid *sendgrid.SendGridClient
+	config   *config.Config
+	sub      *nats.Subscription
+	stats    workerStats
+}
+
+// NewTicketEscalationWorker creates a new ticket escalation worker
+func NewTicketEscalationWorker(db *clients.Database, nats *clients.NATSClient, cfg *config.Config) *TicketEscalationWorker {
+	return &TicketEscalationWorker{
+		db:       db,
+		nats:     nats,
+		sendgrid: sendgrid.NewSendGridClient(cfg.SMTP),
+		config:   cfg,
+	}
+}
+
+// Start starts the ticket escalation worker
+func (w *TicketEscalationWorker) Start() error {
+	log.Println("🚨 Starting ticket escalation worker...")
+
+	sub, err := w.nats.QueueSubscribe(
+		tickets.EscalationSubject,
+		"ticket-escalation-workers",
+		"ticket-escalation-worker-durable",
+		w.handleTicketEscalated,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to %s: %w", tickets.EscalationSubject, err)
+	}
+	w.sub = sub
+
+	log.Println("✓ Ticket escalation worker started successfully")
+	return nil
+}
+
+// Status reports the worker's subscription state and delivery counters
+func (w *TicketEscalationWorker) Status() WorkerStatus {
+	status := w.stats.status("ticket_escalation")
+	status.Running = w.sub != nil && w.sub.IsValid()
+	return status
+}
+
+// handleTicketEscalated handles ticket escalation messages
+func (w *TicketEscalationWorker) handleTicketEscalated(msg *nats.Msg) {
+	var event tickets.TicketEscalatedEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil {
+		log.Printf("Failed to unmarshal ticket escalation: %v", err)
+		w.stats.recordError(fmt.Errorf("unmarshal: %w", err))
+		// A malformed message will never succeed, so park it instead of redelivering
+		deadLetter(w.nats, msg, fmt.Sprintf("unmarshal: %v", err))
+		return
+	}
+
+	log.Printf("Processing escalation for ticket %s", event.TicketID)
+
+	recipients, err := w.getRecipients()
+	if err != nil {
+		log.Printf("Failed to load escalation recipients: %v", err)
+		w.stats.recordError(err)
+		w.retryOrDeadLetter(msg, err)
+		return
+	}
+
+	if len(recipients) == 0 {
+		log.Printf("No escalation recipients configured (system setting %s), skipping ticket %s", tickets.EscalationRecipientsSettingKey, event.TicketID)
+		w.stats.recordProcessed(1)
+		msg.Ack()
+		return
+	}
+
+	email := &sendgrid.EmailMessage{
+		To:          recipients,
+		Subject:     fmt.Sprintf("[Urgent] Ticket escalated: %s", event.Subject),
+		TextContent: fmt.Sprintf("Ticket %s was escalated to urgent priority.\n\nSubject: %s\nStatus: %s\n\n%s", event.TicketID, event.Subject, event.Status, event.Description),
+		// Subject and description come from the ticket author, so they are escaped
+		HTMLContent: fmt.Sprintf("<h2>Ticket escalated to urgent</h2><p><strong>Ticket:</strong> %s</p><p><strong>Subject:</strong> %s</p><p><strong>Status:</strong> %s</p><p>%s</p>",
+			html.EscapeString(event.TicketID), html.EscapeString(event.Subject), html.EscapeString(event.Status), html.EscapeString(event.Description)),
+		RequestID: event.RequestID,
+	}
+
+	if err := w.sendgrid.SendEmail(email); err != nil {
+		log.Printf("Failed to send escalation email for ticket %s: %v", event.TicketID, err)
+		w.stats.recordError(err)
+		w.retryOrDeadLetter(msg, err)
+		return
+	}
+
+	w.stats.recordProcessed(1)
+	msg.Ack()
+	log.Printf("✓ Escalation email sent for ticket %s", event.TicketID)
+}
+
+// retryOrDeadLetter redelivers a failed message until its final allowed delivery, then dead-letters it
+func (w *TicketEscalationWorker) retryOrDeadLetter(msg *nats.Msg, err error) {
+	if deliveryAttempt(msg) >= w.config.NATS.MaxDeliver {
+		deadLetter(w.nats, msg, err.Error())
+		return
+	}
+	msg.Nak()
+}
+
+// getRecipients reads the escalation distribution list from system settings
+func (w *TicketEscalationWorker) getRecipients() ([]string, error) {
+	var value string
+	err := w.db.QueryRow(
+		`SELECT value FROM settings WHERE user_id IS NULL AND key = $1 AND is_encrypted = FALSE`,
+		tickets.EscalationRecipientsSettingKey,
+	).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []string
+	for _, address := range strings.Split(value, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			recipients = append(recipients, address)
+		}
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,17 @@
+-- Create settings history table (immutable change log for system settings)
+CREATE TABLE IF NOT EXISTS settings_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    setting_key VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL, -- create, update, delete
+    old_value TEXT, -- NULL when redacted or not applicable
+    new_value TEXT, -- NULL when redacted or not applicable
+    value_changed BOOLEAN NOT NULL DEFAULT FALSE,
+    is_encrypted BOOLEAN NOT NULL DEFAULT FALSE,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_settings_history_setting_key ON settings_history(setting_key);
+CREATE INDEX idx_settings_history_changed_by ON settings_history(changed_by);
+CREATE INDEX idx_settings_history_created_at ON settings_history(created_at);
@@ -0,0 +1,5 @@
+-- Track when staff first responded to a ticket
+ALTER TABLE support_tickets ADD COLUMN IF NOT EXISTS first_response_at TIMESTAMP;
+
+-- Create indexes for new columns
+CREATE INDEX IF NOT EXISTS idx_support_tickets_first_response_at ON support_tickets(first_response_at);
@@ -0,0 +1,13 @@
+-- Create file_shares table
+CREATE TABLE IF NOT EXISTS file_shares (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    shared_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(file_id, user_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_file_shares_file_id ON file_shares(file_id);
+CREATE INDEX IF NOT EXISTS idx_file_shares_user_id ON file_shares(user_id);
@@ -0,0 +1,16 @@
+-- Create notification_templates table
+CREATE TABLE IF NOT EXISTS notification_templates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    channel VARCHAR(50) NOT NULL, -- email, sms, push, in_app
+    title TEXT NOT NULL,
+    content TEXT NOT NULL,
+    html_content TEXT, -- email only
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE(name, channel)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_notification_templates_name ON notification_templates(name);
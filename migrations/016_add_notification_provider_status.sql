@@ -0,0 +1,5 @@
+-- Track the delivery status reported by the provider (e.g. Twilio message status)
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS provider_status VARCHAR(50);
+
+-- Create indexes for new columns
+CREATE INDEX IF NOT EXISTS idx_notifications_provider_id ON notifications(provider_id);
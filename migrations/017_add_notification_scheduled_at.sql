@@ -0,0 +1,5 @@
+-- Allow notifications to be scheduled for future delivery
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMP;
+
+-- Create indexes for new columns
+CREATE INDEX IF NOT EXISTS idx_notifications_scheduled_at ON notifications(scheduled_at) WHERE status = 'scheduled';
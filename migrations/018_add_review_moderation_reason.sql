@@ -0,0 +1,2 @@
+-- Record why a moderator changed a review's status
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderation_reason TEXT;
@@ -0,0 +1,10 @@
+-- Create review_votes table
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id UUID NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_review_votes_user_id ON review_votes(user_id);
@@ -0,0 +1,9 @@
+-- Create review_responses table
+CREATE TABLE IF NOT EXISTS review_responses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    review_id UUID NOT NULL UNIQUE REFERENCES reviews(id) ON DELETE CASCADE,
+    responder_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
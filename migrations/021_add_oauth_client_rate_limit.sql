@@ -0,0 +1,2 @@
+-- Optional per-client rate limit; NULL falls back to the global RATE_LIMIT_RPS
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS rate_limit_rps INT CHECK (rate_limit_rps > 0);
@@ -0,0 +1,5 @@
+-- Track when a client last had a token issued
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP;
+
+-- Create indexes for token statistics
+CREATE INDEX IF NOT EXISTS idx_oauth_tokens_client_created ON oauth_tokens(client_id, created_at);
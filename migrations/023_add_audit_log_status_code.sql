@@ -0,0 +1,2 @@
+-- Record the HTTP response status of each audited request
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS status_code INTEGER;
@@ -0,0 +1,6 @@
+-- Support anchored action prefix searches regardless of collation
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action_pattern ON audit_logs(action varchar_pattern_ops);
+
+-- Support per-actor listings ordered by time
+CREATE INDEX IF NOT EXISTS idx_audit_logs_user_created ON audit_logs(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_client_created ON audit_logs(client_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Support keyset pagination ordered by (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_support_tickets_created_id ON support_tickets(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_user_created_id ON notifications(user_id, created_at DESC, id DESC);
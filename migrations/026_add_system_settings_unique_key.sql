@@ -0,0 +1,2 @@
+-- UNIQUE(user_id, key) does not apply to system settings because their user_id is NULL
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settings_system_key ON settings(key) WHERE user_id IS NULL;
@@ -0,0 +1,4 @@
+-- Email only has to be unique among active users so a soft-deleted account's email can be
+-- registered again. Whether that is allowed is decided by USER_RESERVE_DELETED_EMAILS.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_active ON users(email) WHERE deleted_at IS NULL;
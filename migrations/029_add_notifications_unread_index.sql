@@ -0,0 +1,2 @@
+-- Keep per-user unread counts cheap enough to poll
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE is_read = FALSE;
@@ -0,0 +1,8 @@
+-- Create user_webhooks table; secret is the AES-GCM encrypted HMAC signing secret
+CREATE TABLE IF NOT EXISTS user_webhooks (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,5 @@
+-- SHA-256 of the file contents; rows of the same user with equal checksums share one stored object
+ALTER TABLE files ADD COLUMN IF NOT EXISTS checksum VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_files_user_checksum ON files(user_id, checksum);
+CREATE INDEX IF NOT EXISTS idx_files_path ON files(path);
@@ -0,0 +1,8 @@
+-- Soft delete notifications so deletions can be undone until the purge worker removes them
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_notifications_deleted_at ON notifications(deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Unread counts only consider notifications that have not been deleted
+DROP INDEX IF EXISTS idx_notifications_user_unread;
+CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE is_read = FALSE AND deleted_at IS NULL;
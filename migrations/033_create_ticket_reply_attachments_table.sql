@@ -0,0 +1,10 @@
+-- Files attached to ticket replies
+CREATE TABLE IF NOT EXISTS ticket_reply_attachments (
+    reply_id UUID NOT NULL REFERENCES support_ticket_replies(id) ON DELETE CASCADE,
+    file_id UUID NOT NULL REFERENCES files(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (reply_id, file_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_ticket_reply_attachments_file_id ON ticket_reply_attachments(file_id);
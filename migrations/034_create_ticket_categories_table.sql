@@ -0,0 +1,20 @@
+-- Create ticket_categories table; tickets store the category name
+CREATE TABLE IF NOT EXISTS ticket_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Names are unique regardless of case so "Billing" and "billing" can't both exist
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ticket_categories_name ON ticket_categories(LOWER(name));
+CREATE INDEX IF NOT EXISTS idx_support_tickets_category ON support_tickets(category);
+
+-- Seed from the categories already used on tickets, keeping one spelling per name
+INSERT INTO ticket_categories (name)
+SELECT DISTINCT ON (LOWER(TRIM(category))) TRIM(category)
+FROM support_tickets
+WHERE category IS NOT NULL AND TRIM(category) <> ''
+ORDER BY LOWER(TRIM(category)), TRIM(category)
+ON CONFLICT DO NOTHING;
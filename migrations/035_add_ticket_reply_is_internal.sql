@@ -0,0 +1,2 @@
+-- Internal notes are staff-only replies hidden from the ticket owner
+ALTER TABLE support_ticket_replies ADD COLUMN IF NOT EXISTS is_internal BOOLEAN NOT NULL DEFAULT FALSE;
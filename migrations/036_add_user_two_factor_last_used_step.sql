@@ -0,0 +1,2 @@
+-- TOTP time step of the last accepted code; codes for this step or earlier are rejected as replays
+ALTER TABLE user_two_factor ADD COLUMN IF NOT EXISTS last_used_step BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+-- Keep the rendered HTML body so scheduled emails are sent as they were composed
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS html_content TEXT;
@@ -46,9 +46,15 @@ migrate_reset() {
 DROP TABLE IF EXISTS team_members CASCADE;
 DROP TABLE IF EXISTS support_ticket_replies CASCADE;
 DROP TABLE IF EXISTS support_tickets CASCADE;
+DROP TABLE IF EXISTS review_responses CASCADE;
+DROP TABLE IF EXISTS review_votes CASCADE;
 DROP TABLE IF EXISTS reviews CASCADE;
+DROP TABLE IF EXISTS file_shares CASCADE;
 DROP TABLE IF EXISTS files CASCADE;
+DROP TABLE IF EXISTS settings_history CASCADE;
 DROP TABLE IF EXISTS settings CASCADE;
+DROP TABLE IF EXISTS notification_templates CASCADE;
+DROP TABLE IF EXISTS notification_preferences CASCADE;
 DROP TABLE IF EXISTS notifications CASCADE;
 DROP TABLE IF EXISTS audit_logs CASCADE;
 DROP TABLE IF EXISTS oauth_authorization_codes CASCADE;